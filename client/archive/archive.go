@@ -0,0 +1,358 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive stores a range of the entries of a CT log in a directory,
+// together with the STH they were downloaded against, so that they can be
+// verified against it and served again through the RFC 6962 read API.
+//
+// An archive directory holds:
+//   - archive.json: the Manifest, describing the range of entries.
+//   - sth.json: the STH, as returned by the log's get-sth endpoint.
+//   - roots.json: optionally, the log's roots, as returned by get-roots.
+//   - segments/<index>.ndjson.zst: zstd-compressed files with one JSON
+//     ct.LeafEntry per line. Segments are aligned to multiples of the
+//     manifest's segment size, and named by the zero-padded index of the
+//     first entry of their slot, except that the first and last segments are
+//     cut to the archived range.
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultSegmentSize is the number of entries in each segment of an archive
+// whose manifest doesn't specify one.
+const DefaultSegmentSize = 4096
+
+const (
+	manifestFile = "archive.json"
+	sthFile      = "sth.json"
+	rootsFile    = "roots.json"
+	segmentsDir  = "segments"
+)
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	// LogURI is the base URI of the log the entries were downloaded from.
+	LogURI string `json:"log_uri"`
+	// Start and End delimit the archived entries, [Start, End).
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// SegmentSize is the number of entries in a full segment.
+	SegmentSize int64 `json:"segment_size"`
+}
+
+// Archive is a range of log entries stored in a directory.
+type Archive struct {
+	dir      string
+	manifest Manifest
+	sth      *ct.GetSTHResponse
+}
+
+// Create creates an archive in dir for the entries described by m, which
+// were logged under sth. Use Open to resume filling an existing archive.
+func Create(dir string, m Manifest, sth *ct.GetSTHResponse) (*Archive, error) {
+	if m.SegmentSize <= 0 {
+		m.SegmentSize = DefaultSegmentSize
+	}
+	if m.Start < 0 || m.Start > m.End || uint64(m.End) > sth.TreeSize {
+		return nil, fmt.Errorf("invalid range [%d, %d) for tree size %d", m.Start, m.End, sth.TreeSize)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds an archive", dir)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, segmentsDir), 0o755); err != nil {
+		return nil, err
+	}
+	a := &Archive{dir: dir, manifest: m, sth: sth}
+	// Write the manifest last, as its presence marks the archive as created.
+	if err := writeJSON(filepath.Join(dir, sthFile), sth); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(dir, manifestFile), m); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Open opens the archive in dir. The returned error wraps os.ErrNotExist if
+// there is no archive in dir.
+func Open(dir string) (*Archive, error) {
+	a := &Archive{dir: dir}
+	if err := readJSON(filepath.Join(dir, manifestFile), &a.manifest); err != nil {
+		return nil, err
+	}
+	if a.manifest.SegmentSize <= 0 {
+		return nil, fmt.Errorf("%s: invalid segment size %d", dir, a.manifest.SegmentSize)
+	}
+	if err := readJSON(filepath.Join(dir, sthFile), &a.sth); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Manifest returns the manifest of the archive.
+func (a *Archive) Manifest() Manifest {
+	return a.manifest
+}
+
+// STH returns the STH the archived entries were downloaded against.
+func (a *Archive) STH() *ct.GetSTHResponse {
+	return a.sth
+}
+
+// SetRoots stores the log's roots in the archive.
+func (a *Archive) SetRoots(roots []ct.ASN1Cert) error {
+	rsp := ct.GetRootsResponse{Certificates: []string{}}
+	for _, r := range roots {
+		rsp.Certificates = append(rsp.Certificates, base64.StdEncoding.EncodeToString(r.Data))
+	}
+	return writeJSON(filepath.Join(a.dir, rootsFile), rsp)
+}
+
+// Roots returns the log's roots stored in the archive, in the get-roots
+// response format, or nil if there are none.
+func (a *Archive) Roots() (*ct.GetRootsResponse, error) {
+	var rsp ct.GetRootsResponse
+	if err := readJSON(filepath.Join(a.dir, rootsFile), &rsp); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &rsp, nil
+}
+
+// segment returns the range [start, end) of the segment holding index.
+func (a *Archive) segment(index int64) (int64, int64) {
+	size := a.manifest.SegmentSize
+	start := index / size * size
+	end := start + size
+	if start < a.manifest.Start {
+		start = a.manifest.Start
+	}
+	if end > a.manifest.End {
+		end = a.manifest.End
+	}
+	return start, end
+}
+
+func (a *Archive) segmentPath(start int64) string {
+	slot := start / a.manifest.SegmentSize * a.manifest.SegmentSize
+	return filepath.Join(a.dir, segmentsDir, fmt.Sprintf("%016d.ndjson.zst", slot))
+}
+
+// Next returns the index of the first entry that is not stored, after the
+// contiguous run of stored segments from the start of the range. It is the
+// index to resume downloading from, or the end of the range if the archive is
+// complete.
+func (a *Archive) Next() (int64, error) {
+	next := a.manifest.Start
+	for next < a.manifest.End {
+		_, end := a.segment(next)
+		if _, err := os.Stat(a.segmentPath(next)); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		next = end
+	}
+	return next, nil
+}
+
+// WriteSegment stores the entries of the segment starting at start, which
+// must be complete.
+func (a *Archive) WriteSegment(start int64, entries []ct.LeafEntry) error {
+	segStart, segEnd := a.segment(start)
+	if start != segStart || start < a.manifest.Start || start >= a.manifest.End {
+		return fmt.Errorf("no segment starts at index %d", start)
+	}
+	if got, want := int64(len(entries)), segEnd-segStart; got != want {
+		return fmt.Errorf("segment at %d has %d entries, want %d", start, got, want)
+	}
+	path := a.segmentPath(start)
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	enc := json.NewEncoder(zw)
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			zw.Close() //nolint:errcheck
+			f.Close()  //nolint:errcheck
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// ReadSegment returns the entries of the segment holding index, and the index
+// of its first entry. The returned error wraps os.ErrNotExist if the segment
+// is not stored.
+func (a *Archive) ReadSegment(index int64) (int64, []ct.LeafEntry, error) {
+	if index < a.manifest.Start || index >= a.manifest.End {
+		return 0, nil, fmt.Errorf("index %d outside of archived range [%d, %d): %w", index, a.manifest.Start, a.manifest.End, os.ErrNotExist)
+	}
+	start, end := a.segment(index)
+	f, err := os.Open(a.segmentPath(start))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	entries := make([]ct.LeafEntry, 0, end-start)
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for dec.More() {
+		var e ct.LeafEntry
+		if err := dec.Decode(&e); err != nil {
+			return 0, nil, fmt.Errorf("segment at %d: %v", start, err)
+		}
+		entries = append(entries, e)
+	}
+	if got, want := int64(len(entries)), end-start; got != want {
+		return 0, nil, fmt.Errorf("segment at %d has %d entries, want %d", start, got, want)
+	}
+	return start, entries, nil
+}
+
+// GetEntries returns the stored entries [start, end], like the get-entries
+// endpoint. As logs may do, it returns fewer entries than requested if the
+// range spans several segments.
+func (a *Archive) GetEntries(start, end int64) ([]ct.LeafEntry, error) {
+	if start > end {
+		return nil, fmt.Errorf("start %d > end %d", start, end)
+	}
+	segStart, entries, err := a.ReadSegment(start)
+	if err != nil {
+		return nil, err
+	}
+	entries = entries[start-segStart:]
+	if n := end - start + 1; int64(len(entries)) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
+
+// Appender stores contiguous batches of entries in an archive, writing each
+// segment once it is complete.
+type Appender struct {
+	a       *Archive
+	next    int64 // Index of the next entry to append.
+	start   int64 // Index of the first buffered entry.
+	pending []ct.LeafEntry
+}
+
+// NewAppender returns an Appender which stores entries from index next, which
+// must be at a segment boundary, e.g. as returned by Next.
+func (a *Archive) NewAppender(next int64) (*Appender, error) {
+	if start, _ := a.segment(next); start != next && next != a.manifest.End {
+		return nil, fmt.Errorf("index %d is not at a segment boundary", next)
+	}
+	return &Appender{a: a, next: next, start: next}, nil
+}
+
+// Append adds entries starting at index start, which must follow on from the
+// previously appended ones, and writes the segments they complete.
+func (ap *Appender) Append(start int64, entries []ct.LeafEntry) error {
+	if start != ap.next {
+		return fmt.Errorf("got entries from index %d, want %d", start, ap.next)
+	}
+	if start+int64(len(entries)) > ap.a.manifest.End {
+		return fmt.Errorf("entries [%d, %d) beyond the end of the archive %d", start, start+int64(len(entries)), ap.a.manifest.End)
+	}
+	ap.pending = append(ap.pending, entries...)
+	ap.next += int64(len(entries))
+	for len(ap.pending) > 0 {
+		_, end := ap.a.segment(ap.start)
+		n := end - ap.start
+		if int64(len(ap.pending)) < n {
+			break
+		}
+		if err := ap.a.WriteSegment(ap.start, ap.pending[:n]); err != nil {
+			return err
+		}
+		ap.pending = append([]ct.LeafEntry(nil), ap.pending[n:]...)
+		ap.start = end
+	}
+	return nil
+}
+
+// Next returns the index of the next entry to append.
+func (ap *Appender) Next() int64 {
+	return ap.next
+}
+
+// Stored returns the index of the first entry which hasn't been written to a
+// segment yet.
+func (ap *Appender) Stored() int64 {
+	return ap.start
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// writeJSON atomically replaces the file at path with v encoded as JSON.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
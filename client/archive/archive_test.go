@@ -0,0 +1,324 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/client/archive"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+)
+
+func testEntries(n int) []ct.LeafEntry {
+	entries := make([]ct.LeafEntry, n)
+	for i := range entries {
+		entries[i] = ct.LeafEntry{
+			LeafInput: []byte(fmt.Sprintf("leaf %d", i)),
+			ExtraData: []byte(fmt.Sprintf("extra %d", i)),
+		}
+	}
+	return entries
+}
+
+// testSTH returns an STH for the tree of entries, with a dummy signature.
+func testSTH(t *testing.T, entries []ct.LeafEntry) *ct.GetSTHResponse {
+	t.Helper()
+	tree := testonly.New(rfc6962.DefaultHasher)
+	for _, e := range entries {
+		tree.AppendData(e.LeafInput)
+	}
+	sig, err := tls.Marshal(ct.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+		Signature: []byte{0x01},
+	})
+	if err != nil {
+		t.Fatalf("tls.Marshal(): %v", err)
+	}
+	return &ct.GetSTHResponse{
+		TreeSize:          uint64(len(entries)),
+		Timestamp:         1700000000000,
+		SHA256RootHash:    tree.Hash(),
+		TreeHeadSignature: sig,
+	}
+}
+
+// createArchive creates an archive of entries [start, end) of the log.
+func createArchive(t *testing.T, log []ct.LeafEntry, start, end int64) *archive.Archive {
+	t.Helper()
+	a, err := archive.Create(t.TempDir(), archive.Manifest{LogURI: "https://log.example.com", Start: start, End: end, SegmentSize: 4}, testSTH(t, log))
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	ap, err := a.NewAppender(start)
+	if err != nil {
+		t.Fatalf("NewAppender(): %v", err)
+	}
+	if err := ap.Append(start, log[start:end]); err != nil {
+		t.Fatalf("Append(): %v", err)
+	}
+	return a
+}
+
+// serveLog returns a client for a log holding entries, served from a complete
+// archive.
+func serveLog(t *testing.T, entries []ct.LeafEntry) *client.LogClient {
+	t.Helper()
+	a := createArchive(t, entries, 0, int64(len(entries)))
+	ts := httptest.NewServer(archive.NewServer(a))
+	t.Cleanup(ts.Close)
+	lc, err := client.New(ts.URL, ts.Client(), jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("client.New(): %v", err)
+	}
+	return lc
+}
+
+func TestArchiveResume(t *testing.T) {
+	log := testEntries(23)
+	dir := t.TempDir()
+	sth := testSTH(t, log)
+	m := archive.Manifest{LogURI: "https://log.example.com", Start: 3, End: 21, SegmentSize: 4}
+	a, err := archive.Create(dir, m, sth)
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if _, err := archive.Create(dir, m, sth); err == nil {
+		t.Error("Create() over an existing archive succeeded")
+	}
+	if _, err := a.NewAppender(5); err == nil {
+		t.Error("NewAppender(5) succeeded off a segment boundary")
+	}
+
+	ap, err := a.NewAppender(3)
+	if err != nil {
+		t.Fatalf("NewAppender(): %v", err)
+	}
+	// Segments are [3, 4), [4, 8), [8, 12), ... so this completes two of them.
+	if err := ap.Append(3, log[3:10]); err != nil {
+		t.Fatalf("Append(): %v", err)
+	}
+	if err := ap.Append(11, log[11:12]); err == nil {
+		t.Error("Append() with a gap succeeded")
+	}
+	if got, want := ap.Stored(), int64(8); got != want {
+		t.Errorf("Stored()=%d, want %d", got, want)
+	}
+
+	// Resume as if the download had been interrupted.
+	a, err = archive.Open(dir)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	if got, want := a.Manifest(), m; got != want {
+		t.Errorf("Manifest()=%+v, want %+v", got, want)
+	}
+	next, err := a.Next()
+	if err != nil {
+		t.Fatalf("Next(): %v", err)
+	}
+	if next != 8 {
+		t.Fatalf("Next()=%d, want 8", next)
+	}
+	if err := a.Verify(context.Background(), nil); !errors.Is(err, archive.ErrIncomplete) {
+		t.Errorf("Verify(incomplete)=%v, want ErrIncomplete", err)
+	}
+	ap, err = a.NewAppender(next)
+	if err != nil {
+		t.Fatalf("NewAppender(): %v", err)
+	}
+	for i := next; i < m.End; i += 5 {
+		end := i + 5
+		if end > m.End {
+			end = m.End
+		}
+		if err := ap.Append(i, log[i:end]); err != nil {
+			t.Fatalf("Append(%d): %v", i, err)
+		}
+	}
+	if next, err := a.Next(); err != nil || next != m.End {
+		t.Errorf("Next()=%d, %v, want %d", next, err, m.End)
+	}
+
+	for _, tc := range []struct {
+		start, end int64
+		want       []ct.LeafEntry
+		wantErr    bool
+	}{
+		{start: 3, end: 3, want: log[3:4]},
+		{start: 5, end: 20, want: log[5:8]},
+		{start: 18, end: 30, want: log[18:20]},
+		{start: 20, end: 30, want: log[20:21]},
+		{start: 2, end: 3, wantErr: true},
+		{start: 21, end: 22, wantErr: true},
+	} {
+		got, err := a.GetEntries(tc.start, tc.end)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("GetEntries(%d, %d)=%v, want error %t", tc.start, tc.end, err, tc.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("GetEntries(%d, %d) diff (-want +got):\n%s", tc.start, tc.end, diff)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	log := testEntries(37)
+	lc := serveLog(t, log)
+	for _, tc := range []struct {
+		name       string
+		start, end int64
+		tamper     bool
+		wantErr    bool
+	}{
+		{name: "whole-tree", start: 0, end: 37},
+		{name: "prefix", start: 0, end: 17},
+		{name: "suffix", start: 13, end: 37},
+		{name: "middle", start: 5, end: 30},
+		{name: "single", start: 16, end: 17},
+		{name: "empty", start: 9, end: 9},
+		{name: "tampered-whole-tree", start: 0, end: 37, tamper: true, wantErr: true},
+		{name: "tampered-middle", start: 5, end: 30, tamper: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := log
+			if tc.tamper {
+				entries = append([]ct.LeafEntry(nil), log...)
+				entries[tc.end-2] = ct.LeafEntry{LeafInput: []byte("forged")}
+			}
+			a, err := archive.Create(t.TempDir(), archive.Manifest{Start: tc.start, End: tc.end, SegmentSize: 8}, testSTH(t, log))
+			if err != nil {
+				t.Fatalf("Create(): %v", err)
+			}
+			ap, err := a.NewAppender(tc.start)
+			if err != nil {
+				t.Fatalf("NewAppender(): %v", err)
+			}
+			if err := ap.Append(tc.start, entries[tc.start:tc.end]); err != nil {
+				t.Fatalf("Append(): %v", err)
+			}
+			if err := a.Verify(ctx, lc); (err != nil) != tc.wantErr {
+				t.Errorf("Verify()=%v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyCorruptSegment(t *testing.T) {
+	log := testEntries(8)
+	dir := t.TempDir()
+	a, err := archive.Create(dir, archive.Manifest{Start: 0, End: 8, SegmentSize: 4}, testSTH(t, log))
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	for _, start := range []int64{0, 4} {
+		if err := a.WriteSegment(start, log[start:start+4]); err != nil {
+			t.Fatalf("WriteSegment(%d): %v", start, err)
+		}
+	}
+	if err := a.Verify(context.Background(), nil); err != nil {
+		t.Fatalf("Verify(): %v", err)
+	}
+	path := filepath.Join(dir, "segments", "0000000000000004.ndjson.zst")
+	if err := os.WriteFile(path, []byte("garbage"), 0o644); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	if err := a.Verify(context.Background(), nil); err == nil {
+		t.Error("Verify() of a corrupt archive succeeded")
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	log := testEntries(21)
+	lc := serveLog(t, log)
+
+	sth, err := lc.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("GetSTH(): %v", err)
+	}
+	if got, want := sth.TreeSize, uint64(len(log)); got != want {
+		t.Errorf("GetSTH().TreeSize=%d, want %d", got, want)
+	}
+	rsp, err := lc.GetRawEntries(ctx, 5, 7)
+	if err != nil {
+		t.Fatalf("GetRawEntries(): %v", err)
+	}
+	if diff := cmp.Diff(log[5:8], rsp.Entries); diff != "" {
+		t.Errorf("GetRawEntries() diff (-want +got):\n%s", diff)
+	}
+	if _, err := lc.GetRawEntries(ctx, 21, 22); err == nil {
+		t.Error("GetRawEntries() beyond the archive succeeded")
+	}
+	if roots, err := lc.GetAcceptedRoots(ctx); err != nil || len(roots) != 0 {
+		t.Errorf("GetAcceptedRoots()=%v, %v, want none", roots, err)
+	}
+
+	// Check the proofs at a smaller tree size against an STH of that size.
+	old := testSTH(t, log[:13])
+	oldSTH, err := old.ToSignedTreeHead()
+	if err != nil {
+		t.Fatalf("ToSignedTreeHead(): %v", err)
+	}
+	for _, s := range []*ct.SignedTreeHead{sth, oldSTH} {
+		for _, index := range []int64{0, 7, int64(s.TreeSize) - 1} {
+			hash := rfc6962.DefaultHasher.HashLeaf(log[index].LeafInput)
+			rsp, err := lc.GetProofByHash(ctx, hash, s.TreeSize)
+			if err != nil {
+				t.Fatalf("GetProofByHash(%d, %d): %v", index, s.TreeSize, err)
+			}
+			if rsp.LeafIndex != index {
+				t.Errorf("GetProofByHash(%d, %d).LeafIndex=%d", index, s.TreeSize, rsp.LeafIndex)
+			}
+			if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(index), s.TreeSize, hash, rsp.AuditPath, s.SHA256RootHash[:]); err != nil {
+				t.Errorf("GetProofByHash(%d, %d) gave bad proof: %v", index, s.TreeSize, err)
+			}
+			ep, err := lc.GetEntryAndProof(ctx, uint64(index), s.TreeSize)
+			if err != nil {
+				t.Fatalf("GetEntryAndProof(%d, %d): %v", index, s.TreeSize, err)
+			}
+			if diff := cmp.Diff(rsp.AuditPath, ep.AuditPath); diff != "" {
+				t.Errorf("GetEntryAndProof(%d, %d) proof diff (-by-hash +by-index):\n%s", index, s.TreeSize, diff)
+			}
+		}
+	}
+	cons, err := lc.GetSTHConsistency(ctx, oldSTH.TreeSize, sth.TreeSize)
+	if err != nil {
+		t.Fatalf("GetSTHConsistency(): %v", err)
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, oldSTH.TreeSize, sth.TreeSize, cons, oldSTH.SHA256RootHash[:], sth.SHA256RootHash[:]); err != nil {
+		t.Errorf("GetSTHConsistency() gave bad proof: %v", err)
+	}
+	if _, err := lc.GetProofByHash(ctx, rfc6962.DefaultHasher.HashLeaf([]byte("unknown")), sth.TreeSize); err == nil {
+		t.Error("GetProofByHash(unknown) succeeded")
+	} else if rspErr, ok := err.(client.RspError); !ok || rspErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetProofByHash(unknown)=%v, want HTTP 404", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"k8s.io/klog/v2"
+)
+
+// Server serves an archive through the read-only endpoints of the RFC 6962
+// API, e.g. for testing tools against a log offline. The archive's STH is
+// served as the log's STH. Proofs can only be served if the archive starts at
+// index 0, in which case they are available for any tree size up to the end of
+// the archive; the Merkle tree is computed when the first proof is requested.
+type Server struct {
+	a *Archive
+
+	once sync.Once
+	tree *tree
+	err  error
+}
+
+// NewServer returns a Server for the archive.
+func NewServer(a *Archive) *Server {
+	return &Server{a: a}
+}
+
+// ServeHTTP serves the requests to the RFC 6962 read API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	var rsp interface{}
+	var err error
+	switch r.URL.Path {
+	case ct.GetSTHPath:
+		rsp = s.a.sth
+	case ct.GetRootsPath:
+		rsp, err = s.getRoots()
+	case ct.GetEntriesPath:
+		rsp, err = s.getEntries(r)
+	case ct.GetProofByHashPath:
+		rsp, err = s.getProofByHash(r)
+	case ct.GetSTHConsistencyPath:
+		rsp, err = s.getSTHConsistency(r)
+	case ct.GetEntryAndProofPath:
+		rsp, err = s.getEntryAndProof(r)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		var hErr *httpError
+		if errors.As(err, &hErr) {
+			http.Error(w, hErr.msg, hErr.code)
+			return
+		}
+		klog.Errorf("%s: %v", r.URL, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rsp); err != nil {
+		klog.Warningf("%s: failed to write response: %v", r.URL, err)
+	}
+}
+
+// httpError is an error to report to the client with an HTTP status.
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{code: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func intParam(r *http.Request, name string) (int64, error) {
+	v, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+	if err != nil || v < 0 {
+		return 0, badRequest("invalid %s parameter %q", name, r.URL.Query().Get(name))
+	}
+	return v, nil
+}
+
+func (s *Server) getRoots() (*ct.GetRootsResponse, error) {
+	rsp, err := s.a.Roots()
+	if err != nil {
+		return nil, err
+	}
+	if rsp == nil {
+		rsp = &ct.GetRootsResponse{Certificates: []string{}}
+	}
+	return rsp, nil
+}
+
+func (s *Server) getEntries(r *http.Request) (*ct.GetEntriesResponse, error) {
+	start, err := intParam(r, "start")
+	if err != nil {
+		return nil, err
+	}
+	end, err := intParam(r, "end")
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, badRequest("end %d before start %d", end, start)
+	}
+	entries, err := s.a.GetEntries(start, end)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, badRequest("entries from %d are not in the archive", start)
+	} else if err != nil {
+		return nil, err
+	}
+	return &ct.GetEntriesResponse{Entries: entries}, nil
+}
+
+// treeSizeParam returns the tree size parameter name of r, checking that the
+// archive holds the tree of that size.
+func (s *Server) treeSizeParam(r *http.Request, name string) (uint64, error) {
+	size, err := intParam(r, name)
+	if err != nil {
+		return 0, err
+	}
+	if size > s.a.manifest.End {
+		return 0, badRequest("%s %d beyond the end of the archive %d", name, size, s.a.manifest.End)
+	}
+	return uint64(size), nil
+}
+
+// getTree returns the Merkle tree of the archived entries.
+func (s *Server) getTree() (*tree, error) {
+	if s.a.manifest.Start != 0 {
+		return nil, badRequest("archive doesn't hold the entries before index %d, needed for proofs", s.a.manifest.Start)
+	}
+	s.once.Do(func() {
+		s.tree, s.err = newTree(s.a)
+	})
+	return s.tree, s.err
+}
+
+func (s *Server) getProofByHash(r *http.Request) (*ct.GetProofByHashResponse, error) {
+	hash, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("hash"))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, badRequest("invalid hash parameter %q", r.URL.Query().Get("hash"))
+	}
+	size, err := s.treeSizeParam(r, "tree_size")
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.getTree()
+	if err != nil {
+		return nil, err
+	}
+	index, ok := t.index[[sha256.Size]byte(hash)]
+	if !ok || index >= size {
+		return nil, &httpError{code: http.StatusNotFound, msg: fmt.Sprintf("hash %x not found in tree of size %d", hash, size)}
+	}
+	path, err := t.inclusion(index, size)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetProofByHashResponse{LeafIndex: int64(index), AuditPath: path}, nil
+}
+
+func (s *Server) getSTHConsistency(r *http.Request) (*ct.GetSTHConsistencyResponse, error) {
+	first, err := s.treeSizeParam(r, "first")
+	if err != nil {
+		return nil, err
+	}
+	second, err := s.treeSizeParam(r, "second")
+	if err != nil {
+		return nil, err
+	}
+	if first > second {
+		return nil, badRequest("first %d after second %d", first, second)
+	}
+	t, err := s.getTree()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := proof.Consistency(first, second)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+	path, err := t.proof(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetSTHConsistencyResponse{Consistency: path}, nil
+}
+
+func (s *Server) getEntryAndProof(r *http.Request) (*ct.GetEntryAndProofResponse, error) {
+	index, err := intParam(r, "leaf_index")
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.treeSizeParam(r, "tree_size")
+	if err != nil {
+		return nil, err
+	}
+	if uint64(index) >= size {
+		return nil, badRequest("leaf_index %d not in tree of size %d", index, size)
+	}
+	t, err := s.getTree()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.a.GetEntries(index, index)
+	if err != nil {
+		return nil, err
+	}
+	path, err := t.inclusion(uint64(index), size)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetEntryAndProofResponse{LeafInput: entries[0].LeafInput, ExtraData: entries[0].ExtraData, AuditPath: path}, nil
+}
+
+// tree holds the hashes of all the complete subtrees of the archived
+// entries, which must start at index 0.
+type tree struct {
+	// levels[l][i] is the hash of the subtree of size 2^l starting at leaf
+	// i * 2^l.
+	levels [][][]byte
+	// index maps leaf hashes to the index of their first occurrence.
+	index map[[sha256.Size]byte]uint64
+}
+
+func newTree(a *Archive) (*tree, error) {
+	t := &tree{index: make(map[[sha256.Size]byte]uint64)}
+	leaves := make([][]byte, 0, a.manifest.End)
+	for next := int64(0); next < a.manifest.End; {
+		_, entries, err := a.ReadSegment(next)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			hash := rfc6962.DefaultHasher.HashLeaf(e.LeafInput)
+			if _, ok := t.index[[sha256.Size]byte(hash)]; !ok {
+				t.index[[sha256.Size]byte(hash)] = uint64(len(leaves))
+			}
+			leaves = append(leaves, hash)
+		}
+		next += int64(len(entries))
+	}
+	for level := leaves; len(level) > 0; {
+		t.levels = append(t.levels, level)
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = rfc6962.DefaultHasher.HashChildren(level[2*i], level[2*i+1])
+		}
+		level = next
+	}
+	return t, nil
+}
+
+func (t *tree) inclusion(index, size uint64) ([][]byte, error) {
+	nodes, err := proof.Inclusion(index, size)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+	return t.proof(nodes)
+}
+
+// proof returns the hashes of a proof.
+func (t *tree) proof(nodes proof.Nodes) ([][]byte, error) {
+	hashes := make([][]byte, len(nodes.IDs))
+	for i, id := range nodes.IDs {
+		if int(id.Level) >= len(t.levels) || id.Index >= uint64(len(t.levels[id.Level])) {
+			return nil, fmt.Errorf("node %+v not in tree", id)
+		}
+		hashes[i] = t.levels[id.Level][id.Index]
+	}
+	return nodes.Rehash(hashes, rfc6962.DefaultHasher.HashChildren)
+}
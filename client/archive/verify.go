@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// ProofClient is the part of the log API used to verify an archive which
+// doesn't cover the whole tree of its STH.
+type ProofClient interface {
+	GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error)
+	GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error)
+}
+
+// ErrIncomplete is returned by Verify if some segments of the archive are
+// missing.
+var ErrIncomplete = errors.New("archive is incomplete")
+
+var rf = &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+
+// Verify checks that the archived entries are the entries [Start, End) of the
+// tree of the archive's STH, by computing the Merkle tree over them. The
+// signature of the STH is not checked, as the archive doesn't hold the log's
+// public key.
+//
+// If the archive doesn't start at index 0, the hashes of the entries before
+// it are taken from the inclusion proof of its first entry, and if it doesn't
+// end at the tree size, the root of the archived prefix of the tree is checked
+// with a consistency proof; pc is used to fetch these proofs from the log,
+// and may be nil if the archive covers the whole tree.
+func (a *Archive) Verify(ctx context.Context, pc ProofClient) error {
+	m, sth := a.manifest, a.sth
+	if m.Start == m.End {
+		return nil
+	}
+	rng := rf.NewEmptyRange(uint64(m.Start))
+	var first []byte
+	for next := m.Start; next < m.End; {
+		_, entries, err := a.ReadSegment(next)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIncomplete, err)
+		}
+		for _, e := range entries {
+			hash := rfc6962.DefaultHasher.HashLeaf(e.LeafInput)
+			if first == nil {
+				first = hash
+			}
+			if err := rng.Append(hash, nil); err != nil {
+				return err
+			}
+		}
+		next += int64(len(entries))
+	}
+
+	if m.Start > 0 {
+		if pc == nil {
+			return fmt.Errorf("need a log to verify an archive starting at index %d", m.Start)
+		}
+		rsp, err := pc.GetProofByHash(ctx, first, sth.TreeSize)
+		if err != nil {
+			return fmt.Errorf("failed to get inclusion proof of entry %d: %v", m.Start, err)
+		}
+		if rsp.LeafIndex != m.Start {
+			return fmt.Errorf("log returned inclusion proof for index %d, not %d", rsp.LeafIndex, m.Start)
+		}
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(m.Start), sth.TreeSize, first, rsp.AuditPath, sth.SHA256RootHash); err != nil {
+			return fmt.Errorf("entry %d is not in the tree: %v", m.Start, err)
+		}
+		left, err := leftRange(uint64(m.Start), sth.TreeSize, rsp.AuditPath)
+		if err != nil {
+			return err
+		}
+		if err := left.AppendRange(rng, nil); err != nil {
+			return err
+		}
+		rng = left
+	}
+
+	root, err := rng.GetRootHash(nil)
+	if err != nil {
+		return err
+	}
+	if uint64(m.End) == sth.TreeSize {
+		if !bytes.Equal(root, sth.SHA256RootHash) {
+			return fmt.Errorf("archived entries give root hash %x, but the STH has %x", root, sth.SHA256RootHash)
+		}
+		return nil
+	}
+	if pc == nil {
+		return fmt.Errorf("need a log to verify an archive ending at index %d before the tree size %d", m.End, sth.TreeSize)
+	}
+	cons, err := pc.GetSTHConsistency(ctx, uint64(m.End), sth.TreeSize)
+	if err != nil {
+		return fmt.Errorf("failed to get consistency proof: %v", err)
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, uint64(m.End), sth.TreeSize, cons, root, sth.SHA256RootHash); err != nil {
+		return fmt.Errorf("archived entries are not consistent with the STH: %v", err)
+	}
+	return nil
+}
+
+// leftRange returns the compact range [0, index) made of the nodes of the
+// inclusion proof of the entry at index in a tree of the given size.
+func leftRange(index, size uint64, path [][]byte) (*compact.Range, error) {
+	nodes, err := proof.Inclusion(index, size)
+	if err != nil {
+		return nil, err
+	}
+	// The proof holds the hashes of nodes.IDs, except that the nodes in
+	// IDs[begin:end] are replaced by their ephemeral ancestor, which is to
+	// the right of index.
+	ids := nodes.IDs
+	if ephem, begin, end := nodes.Ephem(); begin < end {
+		ids = append(append(ids[:begin:begin], ephem), ids[end:]...)
+	}
+	if len(ids) != len(path) {
+		return nil, fmt.Errorf("inclusion proof has %d hashes, want %d", len(path), len(ids))
+	}
+	type node struct {
+		id   compact.NodeID
+		hash []byte
+	}
+	var left []node
+	for i, id := range ids {
+		if _, end := id.Coverage(); end <= index {
+			left = append(left, node{id: id, hash: path[i]})
+		}
+	}
+	// Compact ranges list their nodes from left to right.
+	sort.Slice(left, func(i, j int) bool { return left[i].id.Level > left[j].id.Level })
+	hashes := make([][]byte, len(left))
+	for i, n := range left {
+		hashes[i] = n.hash
+	}
+	return rf.NewRange(0, index, hashes)
+}
@@ -0,0 +1,382 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ResponseCache stores the bodies of responses to log requests whose results
+// never change: get-entries, get-sth-consistency, get-proof-by-hash and
+// get-entry-and-proof. Keys identify the log, endpoint and parameters of a
+// request. Implementations must be safe for concurrent use.
+//
+// Responses are stored once they parse, before callers verify them (e.g. a
+// consistency proof against the STHs it links), so a cache must not be
+// trusted more than the log: a bad response stays cached, and is served again
+// in place of the log's.
+type ResponseCache interface {
+	// Get returns the cached response body for key, if there is one.
+	Get(key string) ([]byte, bool)
+	// Put caches the response body for key. Failures to cache are not
+	// reported, as the cache is only an optimization.
+	Put(key string, body []byte)
+	// Stats returns the cache's counters.
+	Stats() CacheStats
+}
+
+// CacheStats holds the counters of a ResponseCache.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+	// Size is the number of bytes currently cached.
+	Size int64
+}
+
+// getAndParseImmutable is like GetAndParse, but consults c.Cache first, and
+// stores successful responses in it, as the response to the request never
+// changes.
+func (c *LogClient) getAndParseImmutable(ctx context.Context, path string, params map[string]string, rsp interface{}) error {
+	if c.Cache == nil {
+		_, _, err := c.GetAndParse(ctx, path, params, rsp)
+		return err
+	}
+	vals := url.Values{}
+	for k, v := range params {
+		vals.Add(k, v)
+	}
+	key := fmt.Sprintf("%s%s?%s", c.BaseURI(), path, vals.Encode())
+	if body, ok := c.Cache.Get(key); ok {
+		if err := json.Unmarshal(body, rsp); err == nil {
+			return nil
+		}
+		klog.Warningf("Ignoring unparseable cached response for %s", key)
+	}
+	_, body, err := c.GetAndParse(ctx, path, params, rsp)
+	if err != nil {
+		return err
+	}
+	c.Cache.Put(key, body)
+	return nil
+}
+
+// MemoryCache is a ResponseCache which keeps up to a maximum number of bytes
+// of responses in memory, evicting the least recently used ones.
+type MemoryCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List // Of *memoryCacheEntry, most recently used first.
+	entries map[string]*list.Element
+	size    int64
+	hits    int64
+	misses  int64
+}
+
+type memoryCacheEntry struct {
+	key  string
+	body []byte
+}
+
+// NewMemoryCache returns a MemoryCache which holds up to maxBytes of keys and
+// responses.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for key.
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		m.misses++
+		return nil, false
+	}
+	m.hits++
+	m.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).body, true
+}
+
+// Put caches the response for key, unless it is larger than the whole
+// cache.
+func (m *MemoryCache) Put(key string, body []byte) {
+	size := int64(len(key) + len(body))
+	if size > m.maxBytes {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+	m.entries[key] = m.lru.PushFront(&memoryCacheEntry{key: key, body: body})
+	m.size += size
+	for m.size > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *MemoryCache) remove(elem *list.Element) {
+	e := m.lru.Remove(elem).(*memoryCacheEntry)
+	delete(m.entries, e.key)
+	m.size -= int64(len(e.key) + len(e.body))
+}
+
+// Stats returns the cache's counters.
+func (m *MemoryCache) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return CacheStats{Hits: m.hits, Misses: m.misses, Size: m.size}
+}
+
+// DiskCache is a ResponseCache which stores responses in a directory, so that
+// they survive restarts and can be shared between tools. Responses are stored
+// by the SHA-256 hash of their content, so identical responses are only
+// stored once and corruption is detected; an index maps the hash of each key
+// to the hash of its response. Both count towards the maximum size, and when
+// they exceed it, the least recently used responses are removed, along with
+// the index entries for them.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu     sync.Mutex // Serializes changes to the size and eviction.
+	size   int64
+	hits   int64 // Accessed atomically.
+	misses int64 // Accessed atomically.
+}
+
+// NewDiskCache returns a DiskCache storing up to maxBytes of responses and
+// index entries in dir, which is created if needed. Responses already in dir
+// are kept.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	d := &DiskCache{dir: dir, maxBytes: maxBytes}
+	for _, sub := range []string{"objects", "keys"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+		files, err := d.files(sub)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			d.size += f.size
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.evict()
+	return d, nil
+}
+
+func (d *DiskCache) objectPath(hash string) string {
+	return filepath.Join(d.dir, "objects", hash[:2], hash)
+}
+
+func (d *DiskCache) keyPath(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, "keys", hex.EncodeToString(h[:]))
+}
+
+// Get returns the cached response for key.
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	body, ok := d.get(key)
+	if ok {
+		atomic.AddInt64(&d.hits, 1)
+	} else {
+		atomic.AddInt64(&d.misses, 1)
+	}
+	return body, ok
+}
+
+func (d *DiskCache) get(key string) ([]byte, bool) {
+	hash, err := os.ReadFile(d.keyPath(key))
+	if err != nil || len(hash) != 2*sha256.Size {
+		return nil, false
+	}
+	path := d.objectPath(string(hash))
+	body, err := os.ReadFile(path)
+	if err != nil {
+		// Removed, so drop the index entry too.
+		d.removeFile(d.keyPath(key), int64(len(hash)))
+		return nil, false
+	}
+	if h := sha256.Sum256(body); hex.EncodeToString(h[:]) != string(hash) {
+		klog.Warningf("Removing corrupt cached response %s", path)
+		d.removeFile(path, int64(len(body)))
+		return nil, false
+	}
+	// Record the use for LRU eviction.
+	now := time.Now()
+	os.Chtimes(path, now, now) //nolint:errcheck
+	return body, true
+}
+
+// Put caches the response for key, unless it is larger than the whole
+// cache.
+func (d *DiskCache) Put(key string, body []byte) {
+	h := sha256.Sum256(body)
+	hash := hex.EncodeToString(h[:])
+	if int64(len(body)+len(hash)) > d.maxBytes {
+		return
+	}
+	path := d.objectPath(hash)
+	keyPath := d.keyPath(key)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := os.Stat(path); err != nil {
+		if err := writeFileAtomic(path, body); err != nil {
+			klog.Warningf("Failed to cache response: %v", err)
+			return
+		}
+		d.size += int64(len(body))
+	}
+	var oldSize int64
+	if info, err := os.Stat(keyPath); err == nil {
+		oldSize = info.Size()
+	}
+	if err := writeFileAtomic(keyPath, []byte(hash)); err != nil {
+		klog.Warningf("Failed to cache response: %v", err)
+		return
+	}
+	d.size += int64(len(hash)) - oldSize
+	d.evict()
+}
+
+// writeFileAtomic writes data to path through a temporary file, so that
+// readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+	}
+	return err
+}
+
+type diskFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// files lists the files in the given subdirectory of the cache: "objects" for
+// the stored responses, or "keys" for the index.
+func (d *DiskCache) files(sub string) ([]diskFile, error) {
+	var files []diskFile
+	err := filepath.WalkDir(filepath.Join(d.dir, sub), func(path string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, diskFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+func (d *DiskCache) removeFile(path string, size int64) {
+	if err := os.Remove(path); err == nil {
+		d.mu.Lock()
+		d.size -= size
+		d.mu.Unlock()
+	}
+}
+
+// evict removes the least recently used responses until the cache is within
+// its maximum size, and then the index entries which don't lead to a response.
+// Must be called with d.mu held.
+func (d *DiskCache) evict() {
+	if d.size <= d.maxBytes {
+		return
+	}
+	objects, err := d.files("objects")
+	if err != nil {
+		klog.Warningf("Failed to list cached responses: %v", err)
+		return
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].modTime.Before(objects[j].modTime) })
+	// Evict down to 90% of the maximum size, so that listing the files is
+	// amortized over several Puts.
+	target := d.maxBytes - d.maxBytes/10
+	kept := make(map[string]bool)
+	for _, o := range objects {
+		if d.size > target {
+			if err := os.Remove(o.path); err == nil {
+				d.size -= o.size
+				continue
+			}
+		}
+		kept[filepath.Base(o.path)] = true
+	}
+
+	keys, err := d.files("keys")
+	if err != nil {
+		klog.Warningf("Failed to list cache index: %v", err)
+		return
+	}
+	for _, k := range keys {
+		if hash, err := os.ReadFile(k.path); err == nil && kept[string(hash)] {
+			continue
+		}
+		if err := os.Remove(k.path); err == nil {
+			d.size -= k.size
+		}
+	}
+}
+
+// Stats returns the cache's counters.
+func (d *DiskCache) Stats() CacheStats {
+	d.mu.Lock()
+	size := d.size
+	d.mu.Unlock()
+	return CacheStats{Hits: atomic.LoadInt64(&d.hits), Misses: atomic.LoadInt64(&d.misses), Size: size}
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := client.NewMemoryCache(30)
+	c.Put("a", bytes.Repeat([]byte("a"), 9)) // 10 bytes with the key.
+	c.Put("b", bytes.Repeat([]byte("b"), 9))
+	c.Put("c", bytes.Repeat([]byte("c"), 9))
+	if _, ok := c.Get("a"); !ok { // Makes "b" the least recently used.
+		t.Error("Get(a) missed")
+	}
+	c.Put("d", bytes.Repeat([]byte("d"), 9))
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) hit after it should have been evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if got, ok := c.Get(key); !ok || got[0] != key[0] {
+			t.Errorf("Get(%s) = %q, %v", key, got, ok)
+		}
+	}
+	c.Put("huge", make([]byte, 100))
+	if _, ok := c.Get("huge"); ok {
+		t.Error("Get(huge) hit for a response larger than the cache")
+	}
+	if got, want := c.Stats(), (client.CacheStats{Hits: 4, Misses: 2, Size: 30}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := client.NewDiskCache(dir, 1000)
+	if err != nil {
+		t.Fatalf("NewDiskCache(): %v", err)
+	}
+	c.Put("a", []byte("same"))
+	c.Put("b", []byte("same"))
+	c.Put("c", []byte("other"))
+	for key, want := range map[string]string{"a": "same", "b": "same", "c": "other"} {
+		if got, ok := c.Get(key); !ok || string(got) != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := c.Get("d"); ok {
+		t.Error("Get(d) hit")
+	}
+	// Each key has a 64-byte index entry.
+	if got, want := c.Stats(), (client.CacheStats{Hits: 3, Misses: 1, Size: 9 + 3*64}); got != want {
+		t.Errorf("Stats() = %+v, want %+v (identical responses stored once)", got, want)
+	}
+	c.Put("a", []byte("same"))
+	if got, want := c.Stats().Size, int64(9+3*64); got != want {
+		t.Errorf("Stats().Size after storing again = %d, want %d", got, want)
+	}
+
+	// The responses survive reopening the cache.
+	c, err = client.NewDiskCache(dir, 1000)
+	if err != nil {
+		t.Fatalf("NewDiskCache(): %v", err)
+	}
+	if got, ok := c.Get("c"); !ok || string(got) != "other" {
+		t.Errorf("Get(c) after reopening = %q, %v", got, ok)
+	}
+	if got, want := c.Stats().Size, int64(9+3*64); got != want {
+		t.Errorf("Stats().Size after reopening = %d, want %d", got, want)
+	}
+
+	// Corrupt responses are detected.
+	objects, _ := filepath.Glob(filepath.Join(dir, "objects", "*", "*"))
+	for _, o := range objects {
+		if err := os.WriteFile(o, []byte("junk!"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, ok := c.Get("a"); ok {
+		t.Errorf("Get(a) = %q for a corrupted response", got)
+	}
+
+	// Old responses are evicted, along with their index entries.
+	dir = t.TempDir()
+	c, err = client.NewDiskCache(dir, 300)
+	if err != nil {
+		t.Fatalf("NewDiskCache(): %v", err)
+	}
+	c.Put("big1", bytes.Repeat([]byte("1"), 100))
+	c.Put("big2", bytes.Repeat([]byte("2"), 100))
+	if _, ok := c.Get("big2"); !ok {
+		t.Error("Get(big2) missed")
+	}
+	if got, want := c.Stats().Size, int64(100+64); got != want {
+		t.Errorf("Stats().Size = %d, want %d", got, want)
+	}
+	if keys, _ := filepath.Glob(filepath.Join(dir, "keys", "*")); len(keys) != 1 {
+		t.Errorf("Index holds %d entries after eviction, want 1", len(keys))
+	}
+	if _, ok := c.Get("big1"); ok {
+		t.Error("Get(big1) hit after eviction")
+	}
+}
+
+func TestLogClientCache(t *testing.T) {
+	ctx := context.Background()
+	l := newProofLog(t, 30)
+	var requests int64
+	handler := l.handler(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	lc, err := client.New(ts.URL, ts.Client(), jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	lc.Cache = client.NewMemoryCache(1 << 20)
+
+	want, err := lc.GetSTHConsistency(ctx, 10, 30)
+	if err != nil {
+		t.Fatalf("GetSTHConsistency(): %v", err)
+	}
+	got, err := lc.GetSTHConsistency(ctx, 10, 30)
+	if err != nil {
+		t.Fatalf("GetSTHConsistency(): %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cached GetSTHConsistency() = %x, want %x", got, want)
+	}
+	if _, err := lc.GetSTHConsistency(ctx, 11, 30); err != nil {
+		t.Fatalf("GetSTHConsistency(): %v", err)
+	}
+	// The STH is not immutable, so is never cached.
+	for i := 0; i < 2; i++ {
+		if _, err := lc.GetSTH(ctx); err != nil {
+			t.Fatalf("GetSTH(): %v", err)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("made %d requests, want 4", requests)
+	}
+	if got, want := lc.Cache.Stats(), (client.CacheStats{Hits: 1, Misses: 2}); got.Hits != want.Hits || got.Misses != want.Misses {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
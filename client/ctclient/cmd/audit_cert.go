@@ -0,0 +1,474 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/ctutil"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/loglist3"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	auditCertChain  string
+	auditURL        string
+	auditWait       bool
+	auditStateDir   string
+	auditSTHRefresh time.Duration
+)
+
+func init() {
+	cmd := cobra.Command{
+		Use:   "audit-cert {--cert_chain=file | --url=https://host[:port]} [--log_list {file|uri}] [--wait] [--state_dir=dir]",
+		Short: "Check that each SCT of a certificate was honoured by its log",
+		Long: `For each SCT embedded in the certificate, or served alongside it on a TLS
+connection, finds the issuing log in --log_list, verifies the SCT's signature,
+and once the log's Maximum Merge Delay has passed, checks that the log has
+included the certificate: it fetches an inclusion proof and verifies it against
+a signed STH. With --state_dir, the STH is also checked to be consistent with
+the last STH seen from the log, as kept by previous runs (or by the monitor
+command). Prints a verdict for each SCT, and fails if any SCT was not honoured, with
+exit code 4 if an SCT failed its checks.`,
+		Args: cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			runAuditCert(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVar(&auditCertChain, "cert_chain", "", "Name of file containing certificate chain as concatenated PEM files")
+	cmd.Flags().StringVar(&auditURL, "url", "", "HTTPS URL of a site whose certificate chain and SCTs should be audited")
+	cmd.Flags().BoolVar(&auditWait, "wait", false, "Wait for the logs' Maximum Merge Delay to pass, rather than reporting SCTs as pending")
+	cmd.Flags().StringVar(&auditStateDir, "state_dir", "", "Directory holding the last STH seen from each log, to check consistency against")
+	cmd.Flags().DurationVar(&auditSTHRefresh, "sth_refresh", time.Minute, "With --wait, interval between fetches of an STH that is too old to cover an SCT")
+	rootCmd.AddCommand(&cmd)
+}
+
+// Verdicts for audited SCTs.
+const (
+	// The SCT is valid, and the log has included the certificate.
+	verdictOK = "OK"
+	// The SCT is valid, but the log's MMD has not passed yet, and the
+	// certificate is not included yet.
+	verdictPending = "PENDING"
+	// The SCT is invalid, or the log has broken its promise to include the
+	// certificate.
+	verdictFail = "FAIL"
+	// The SCT's log is not in the log list.
+	verdictUnknownLog = "UNKNOWN_LOG"
+	// The SCT couldn't be checked, e.g. because the log couldn't be reached.
+	verdictError = "ERROR"
+)
+
+// auditTarget is an SCT to audit.
+type auditTarget struct {
+	name     string
+	sct      *ct.SignedCertificateTimestamp
+	embedded bool
+	err      error // Set if the SCT couldn't be parsed.
+}
+
+// sctAudit is the result of auditing an SCT.
+type sctAudit struct {
+	name      string
+	log       string
+	timestamp uint64
+	signature string
+	sth       string
+	inclusion string
+	verdict   string
+	detail    string
+}
+
+// auditLog holds a log used by some audited SCTs, and its latest checked
+// STH.
+type auditLog struct {
+	lc  *client.LogClient
+	sth *ct.SignedTreeHead
+	err error // Set if the log's STH couldn't be fetched or trusted.
+	// consistency describes how the STH was checked.
+	consistency string
+}
+
+// auditor audits the SCTs of a certificate chain.
+type auditor struct {
+	hc    *http.Client
+	ll    *loglist3.LogList
+	chain []*x509.Certificate // The leaf and, if found, its issuer.
+	logs  map[string]*auditLog
+}
+
+// runAuditCert runs the audit-cert command.
+func runAuditCert(ctx context.Context) {
+	if (auditCertChain == "") == (auditURL == "") {
+		exitf(exitUsage, "Exactly one of --cert_chain and --url must be supplied")
+	}
+	hc := newHTTPClient()
+	llData, err := x509util.ReadFileOrURL(logList, hc)
+	if err != nil {
+		exitf(exitFailure, "Failed to read log list: %v", err)
+	}
+	ll, err := loglist3.NewFromJSON(llData)
+	if err != nil {
+		exitf(exitFailure, "Failed to parse log list: %v", err)
+	}
+
+	var chain []*x509.Certificate
+	var tlsSCTs [][]byte
+	if auditURL != "" {
+		chain, tlsSCTs, err = siteChain(auditURL, hc.Timeout)
+		if err != nil {
+			exitf(exitFailure, "Failed to get certificate chain from %s: %v", auditURL, err)
+		}
+	} else {
+		data, err := os.ReadFile(auditCertChain)
+		if err != nil {
+			exitf(exitFailure, "Failed to read certificate file: %v", err)
+		}
+		chain, err = x509util.CertificatesFromPEM(data)
+		if err != nil {
+			exitf(exitUsage, "Failed to parse certificates in %s: %v", auditCertChain, err)
+		}
+	}
+	if len(chain) == 0 {
+		exitf(exitUsage, "No certificates found")
+	}
+
+	a := &auditor{hc: hc, ll: ll, chain: leafAndIssuer(chain, hc), logs: make(map[string]*auditLog)}
+	var targets []auditTarget
+	for i, sctData := range chain[0].SCTList.SCTList {
+		sct, err := x509util.ExtractSCT(&sctData)
+		targets = append(targets, auditTarget{name: fmt.Sprintf("embedded[%d]", i), sct: sct, embedded: true, err: err})
+	}
+	for i, sctData := range tlsSCTs {
+		sct, err := x509util.ExtractSCT(&x509.SerializedSCT{Val: sctData})
+		targets = append(targets, auditTarget{name: fmt.Sprintf("tls[%d]", i), sct: sct, err: err})
+	}
+	if len(targets) == 0 {
+		exitf(exitUsage, "No SCTs found for the certificate")
+	}
+
+	audits := make([]sctAudit, 0, len(targets))
+	for _, t := range targets {
+		audits = append(audits, a.audit(ctx, t))
+	}
+
+	code := auditExitCode(audits)
+	if jsonOutput() {
+		out := auditOutput{SCTs: make([]sctAuditOutput, 0, len(audits))}
+		for _, au := range audits {
+			out.SCTs = append(out.SCTs, sctAuditOutput{
+				Name:      au.name,
+				Log:       au.log,
+				Timestamp: au.timestamp,
+				Signature: au.signature,
+				STH:       au.sth,
+				Inclusion: au.inclusion,
+				Verdict:   au.verdict,
+				Detail:    au.detail,
+			})
+		}
+		printJSON(out)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCT\tLOG\tTIMESTAMP\tSIGNATURE\tSTH\tINCLUSION\tVERDICT\tDETAIL")
+		for _, au := range audits {
+			ts := "-"
+			if au.timestamp != 0 {
+				ts = ct.TimestampToTime(au.timestamp).UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", au.name, au.log, ts, au.signature, au.sth, au.inclusion, au.verdict, au.detail)
+		}
+		if err := w.Flush(); err != nil {
+			exitf(exitFailure, "Failed to write results: %v", err)
+		}
+	}
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// auditExitCode returns the exit code for the given audits: exitVerifyFailed
+// if any SCT failed, exitFailure if any couldn't be checked, and 0 otherwise.
+func auditExitCode(audits []sctAudit) int {
+	code := 0
+	for _, au := range audits {
+		switch au.verdict {
+		case verdictFail:
+			code = exitVerifyFailed
+		case verdictUnknownLog, verdictError:
+			if code == 0 {
+				code = exitFailure
+			}
+		}
+	}
+	return code
+}
+
+// auditOutput is the JSON output of audit-cert.
+type auditOutput struct {
+	SCTs []sctAuditOutput `json:"scts"`
+}
+
+// sctAuditOutput is the JSON form of an sctAudit.
+type sctAuditOutput struct {
+	Name      string `json:"name"`
+	Log       string `json:"log"`
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	Signature string `json:"signature"`
+	STH       string `json:"sth"`
+	Inclusion string `json:"inclusion"`
+	Verdict   string `json:"verdict"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// leafAndIssuer returns the leaf of chain and its issuer, which is looked for
+// in the chain, and fetched using the leaf's AIA extension otherwise. The
+// issuer is needed to reconstruct the precertificate for embedded SCTs.
+func leafAndIssuer(chain []*x509.Certificate, hc *http.Client) []*x509.Certificate {
+	leaf := chain[0]
+	for _, c := range chain[1:] {
+		if bytes.Equal(c.RawSubject, leaf.RawIssuer) && c.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature) == nil {
+			return []*x509.Certificate{leaf, c}
+		}
+	}
+	if len(leaf.SCTList.SCTList) == 0 {
+		return []*x509.Certificate{leaf}
+	}
+	klog.Info("No issuer in chain; attempting online retrieval")
+	issuer, err := x509util.GetIssuer(leaf, hc)
+	if err != nil {
+		klog.Warningf("Failed to get issuer online: %v", err)
+		return []*x509.Certificate{leaf}
+	}
+	if issuer == nil {
+		klog.Warning("No issuer URL in the leaf")
+		return []*x509.Certificate{leaf}
+	}
+	return []*x509.Certificate{leaf, issuer}
+}
+
+// siteChain returns the certificate chain and the SCTs served by the HTTPS
+// site at target.
+func siteChain(target string, timeout time.Duration) ([]*x509.Certificate, [][]byte, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse URL: %v", err)
+	}
+	if u.Scheme != "https" {
+		return nil, nil, errors.New("non-https URL provided")
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	dialer := net.Dialer{Timeout: timeout}
+	// The chain is audited rather than trusted, so don't verify it here.
+	conn, err := tls.DialWithDialer(&dialer, "tcp", host, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial %q: %v", host, err)
+	}
+	defer conn.Close() //nolint:errcheck
+	state := conn.ConnectionState()
+	chain := make([]*x509.Certificate, len(state.PeerCertificates))
+	for i, goCert := range state.PeerCertificates {
+		cert, err := x509.ParseCertificate(goCert.Raw)
+		if x509.IsFatal(err) {
+			return nil, nil, fmt.Errorf("failed to parse certificate [%d]: %v", i, err)
+		}
+		chain[i] = cert
+	}
+	return chain, state.SignedCertificateTimestamps, nil
+}
+
+// audit checks one SCT.
+func (a *auditor) audit(ctx context.Context, t auditTarget) sctAudit {
+	au := sctAudit{name: t.name, log: "-", signature: "-", sth: "-", inclusion: "-"}
+	if t.err != nil {
+		au.verdict, au.detail = verdictFail, fmt.Sprintf("failed to parse SCT: %v", t.err)
+		return au
+	}
+	au.timestamp = t.sct.Timestamp
+	log := a.ll.FindLogByKeyHash(t.sct.LogID.KeyID)
+	if log == nil {
+		au.log = hex.EncodeToString(t.sct.LogID.KeyID[:])
+		au.verdict, au.detail = verdictUnknownLog, "log not found in log list"
+		return au
+	}
+	au.log = log.Description
+
+	pubKey, err := x509.ParsePKIXPublicKey(log.Key)
+	if err != nil {
+		au.verdict, au.detail = verdictError, fmt.Sprintf("failed to parse log's public key: %v", err)
+		return au
+	}
+	// Embedded SCTs and SCTs for precertificates are over the issuer's key
+	// hash, so they can't be checked without the issuer. That says nothing
+	// about the log.
+	if (t.embedded || a.chain[0].IsPrecertificate()) && len(a.chain) < 2 {
+		au.verdict, au.detail = verdictError, "issuer certificate not available to verify the SCT"
+		return au
+	}
+	if err := ctutil.VerifySCT(pubKey, a.chain, t.sct, t.embedded); err != nil {
+		au.signature = "invalid"
+		au.verdict, au.detail = verdictFail, err.Error()
+		return au
+	}
+	au.signature = "valid"
+
+	var leaf *ct.MerkleTreeLeaf
+	switch {
+	case t.embedded:
+		leaf, err = ct.MerkleTreeLeafForEmbeddedSCT(a.chain, t.sct.Timestamp)
+	case a.chain[0].IsPrecertificate():
+		leaf, err = ct.MerkleTreeLeafFromChain(a.chain, ct.PrecertLogEntryType, t.sct.Timestamp)
+	default:
+		leaf, err = ct.MerkleTreeLeafFromChain(a.chain, ct.X509LogEntryType, t.sct.Timestamp)
+	}
+	if err != nil {
+		au.verdict, au.detail = verdictError, fmt.Sprintf("failed to build Merkle leaf: %v", err)
+		return au
+	}
+
+	// The log must have included the certificate in any STH issued after the
+	// SCT's timestamp plus the MMD.
+	due := ct.TimestampToTime(t.sct.Timestamp).Add(time.Duration(log.MMD) * time.Second)
+	if auditWait {
+		if wait := time.Until(due); wait > 0 {
+			klog.Infof("%s: waiting %s for the MMD of log %q to pass", t.name, wait.Round(time.Second), log.Description)
+			select {
+			case <-ctx.Done():
+				au.verdict, au.detail = verdictError, ctx.Err().Error()
+				return au
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	l := a.log(ctx, log, due)
+	if l.err != nil {
+		// Bad STHs are misbehaviour of the log, other errors are inconclusive.
+		au.verdict, au.detail = verdictError, l.err.Error()
+		if errors.As(l.err, new(*client.SignatureError)) || errors.As(l.err, new(*client.ProofError)) || errors.As(l.err, new(*client.SplitViewError)) {
+			au.verdict = verdictFail
+		}
+		return au
+	}
+	au.sth = fmt.Sprintf("%d (%s)", l.sth.TreeSize, l.consistency)
+	covered := !ct.TimestampToTime(l.sth.Timestamp).Before(due)
+
+	index, err := l.lc.VerifyInclusionOf(ctx, leaf, l.sth)
+	var rspErr client.RspError
+	switch {
+	case err == nil:
+		au.inclusion = fmt.Sprintf("index %d", index)
+		au.verdict = verdictOK
+	case errors.As(err, new(*client.ProofError)):
+		au.inclusion = "invalid proof"
+		au.verdict, au.detail = verdictFail, err.Error()
+	case errors.As(err, &rspErr) && (rspErr.StatusCode == http.StatusNotFound || rspErr.StatusCode == http.StatusBadRequest):
+		// Logs reply to get-proof-by-hash for unknown hashes with one of
+		// these.
+		au.inclusion = "not found"
+		if covered {
+			au.verdict, au.detail = verdictFail, fmt.Sprintf("not included in STH of %s, after MMD ended at %s", ct.TimestampToTime(l.sth.Timestamp).UTC().Format(time.RFC3339), due.UTC().Format(time.RFC3339))
+		} else {
+			au.verdict, au.detail = verdictPending, fmt.Sprintf("MMD ends at %s", due.UTC().Format(time.RFC3339))
+		}
+	default:
+		au.verdict, au.detail = verdictError, fmt.Sprintf("failed to get inclusion proof: %v", err)
+	}
+	return au
+}
+
+// log returns the log, with a signed STH which has been checked for
+// consistency with the previous STH seen from the log, if any. With --wait,
+// it waits for an STH issued at or after due.
+func (a *auditor) log(ctx context.Context, log *loglist3.Log, due time.Time) *auditLog {
+	l, ok := a.logs[log.URL]
+	if !ok {
+		l = &auditLog{consistency: "unchecked"}
+		a.logs[log.URL] = l
+		l.lc, l.err = client.New(log.URL, a.hc, jsonclient.Options{PublicKeyDER: log.Key, UserAgent: "ct-go-ctclient/1.0"})
+		if l.err != nil {
+			return l
+		}
+		if auditStateDir != "" {
+			if l.sth, l.err = readSTHState(a.statePath(log), l.lc.BaseURI()); l.err != nil {
+				return l
+			}
+		}
+		l.err = a.refreshSTH(ctx, l, log)
+	}
+	for l.err == nil && auditWait && ct.TimestampToTime(l.sth.Timestamp).Before(due) {
+		klog.Infof("STH of log %q predates the end of the MMD, refreshing in %s", log.Description, auditSTHRefresh)
+		select {
+		case <-ctx.Done():
+			l.err = ctx.Err()
+			return l
+		case <-time.After(auditSTHRefresh):
+		}
+		l.err = a.refreshSTH(ctx, l, log)
+	}
+	return l
+}
+
+// refreshSTH fetches a new STH for the log, and checks it for consistency with
+// the log's current STH, if any.
+func (a *auditor) refreshSTH(ctx context.Context, l *auditLog, log *loglist3.Log) error {
+	sth, err := l.lc.VerifiedGetSTH(ctx)
+	if err != nil {
+		return err
+	}
+	if l.sth != nil {
+		if err := l.lc.VerifyConsistencyBetween(ctx, l.sth, sth); err != nil {
+			return err
+		}
+		l.consistency = fmt.Sprintf("consistent with %d", l.sth.TreeSize)
+		if sth.TreeSize < l.sth.TreeSize {
+			// Keep checking against the larger tree.
+			return nil
+		}
+	}
+	l.sth = sth
+	if auditStateDir != "" {
+		if err := writeSTHState(a.statePath(log), l.lc.BaseURI(), sth); err != nil {
+			klog.Warningf("Failed to save STH of log %q: %v", log.Description, err)
+		}
+	}
+	return nil
+}
+
+// statePath returns the path of the file holding the last STH seen from log.
+func (a *auditor) statePath(log *loglist3.Log) string {
+	return filepath.Join(auditStateDir, hex.EncodeToString(log.LogID)+".json")
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/certificate-transparency-go/loglist3"
+	"github.com/google/certificate-transparency-go/testdata"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+)
+
+func certsOrDie(t *testing.T, pem string) []*x509.Certificate {
+	t.Helper()
+	certs, err := x509util.CertificatesFromPEM([]byte(pem))
+	if err != nil {
+		t.Fatalf("Failed to parse certificates: %v", err)
+	}
+	return certs
+}
+
+func TestAudit(t *testing.T) {
+	key, err := base64.StdEncoding.DecodeString(testdata.LogPublicKeyB64)
+	if err != nil {
+		t.Fatalf("Failed to decode log key: %v", err)
+	}
+	keyHash := sha256.Sum256(key)
+	ll := &loglist3.LogList{Operators: []*loglist3.Operator{{
+		Name: "Test",
+		Logs: []*loglist3.Log{{Description: "Test Log", LogID: keyHash[:], Key: key, MMD: 86400}},
+	}}}
+
+	embedded := certsOrDie(t, testdata.TestEmbeddedCertPEM)[0]
+	sct, err := x509util.ExtractSCT(&embedded.SCTList.SCTList[0])
+	if err != nil {
+		t.Fatalf("Failed to extract SCT: %v", err)
+	}
+	unknownSCT := *sct
+	unknownSCT.LogID.KeyID[0] ^= 0xff
+
+	for _, tc := range []struct {
+		desc          string
+		chain         string
+		target        auditTarget
+		wantSignature string
+		wantVerdict   string
+		wantCode      int
+	}{
+		{
+			desc:          "embedded-no-issuer",
+			chain:         testdata.TestEmbeddedCertPEM,
+			target:        auditTarget{sct: sct, embedded: true},
+			wantSignature: "-",
+			wantVerdict:   verdictError,
+			wantCode:      exitFailure,
+		},
+		{
+			desc:          "precert-no-issuer",
+			chain:         testdata.TestPreCertPEM,
+			target:        auditTarget{sct: sct},
+			wantSignature: "-",
+			wantVerdict:   verdictError,
+			wantCode:      exitFailure,
+		},
+		{
+			desc:          "invalid-signature",
+			chain:         testdata.TestInvalidEmbeddedCertPEM + testdata.CACertPEM,
+			target:        auditTarget{sct: sct, embedded: true},
+			wantSignature: "invalid",
+			wantVerdict:   verdictFail,
+			wantCode:      exitVerifyFailed,
+		},
+		{
+			desc:          "unknown-log",
+			chain:         testdata.TestEmbeddedCertPEM + testdata.CACertPEM,
+			target:        auditTarget{sct: &unknownSCT, embedded: true},
+			wantSignature: "-",
+			wantVerdict:   verdictUnknownLog,
+			wantCode:      exitFailure,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			chain := certsOrDie(t, tc.chain)
+			a := &auditor{ll: ll, chain: leafAndIssuer(chain, nil), logs: make(map[string]*auditLog)}
+			au := a.audit(context.Background(), tc.target)
+			if au.signature != tc.wantSignature || au.verdict != tc.wantVerdict {
+				t.Errorf("audit()=%s/%s (%s), want %s/%s", au.signature, au.verdict, au.detail, tc.wantSignature, tc.wantVerdict)
+			}
+			if got := auditExitCode([]sctAudit{au}); got != tc.wantCode {
+				t.Errorf("auditExitCode()=%d, want %d", got, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestAuditExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		verdicts []string
+		want     int
+	}{
+		{verdicts: []string{verdictOK, verdictPending}, want: 0},
+		{verdicts: []string{verdictOK, verdictError}, want: exitFailure},
+		{verdicts: []string{verdictUnknownLog, verdictFail, verdictError}, want: exitVerifyFailed},
+	} {
+		var audits []sctAudit
+		for _, v := range tc.verdicts {
+			audits = append(audits, sctAudit{verdict: v})
+		}
+		if got := auditExitCode(audits); got != tc.want {
+			t.Errorf("auditExitCode(%v)=%d, want %d", tc.verdicts, got, tc.want)
+		}
+	}
+}
@@ -17,24 +17,46 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"sort"
+	"sync"
+	"time"
 
 	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/trillian/client/backoff"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2"
 )
 
+var (
+	endTimestamp   int64
+	bisectBatch    int64
+	bisectParallel int
+)
+
 func init() {
 	cmd := cobra.Command{
-		Use:     fmt.Sprintf("bisect %s --timestamp=ts [--chain] [--text=false]", connectionFlags),
+		Use:     fmt.Sprintf("bisect %s --timestamp=ts [--end_timestamp=ts] [--log_mmd=duration] [--chain] [--text=false]", connectionFlags),
 		Aliases: []string{"find-timestamp"},
-		Short:   "Find a log entry by timestamp",
-		Args:    cobra.MaximumNArgs(0),
+		Short:   "Find log entries by timestamp",
+		Long: `Finds the first entry of the log with a timestamp at or after --timestamp, or,
+with --end_timestamp, all the entries with timestamps in the range
+[--timestamp, --end_timestamp].
+
+Entry timestamps are not monotonic in the log, but an entry is sequenced at
+most one Maximum Merge Delay (--log_mmd) after its timestamp. So the search
+bisects the log for the entries that are more than an MMD outside of the
+range, and then scans the entries between them.`,
+		Args: cobra.MaximumNArgs(0),
 		Run: func(cmd *cobra.Command, _ []string) {
 			runBisect(cmd.Context())
 		},
 	}
 	cmd.Flags().Int64Var(&timestamp, "timestamp", 0, "Timestamp to use for inclusion checking")
+	cmd.Flags().Int64Var(&endTimestamp, "end_timestamp", 0, "If set, find all entries with timestamps from --timestamp up to this one, inclusive")
+	cmd.Flags().DurationVar(&logMMD, "log_mmd", 24*time.Hour, "Log's maximum merge delay")
+	cmd.Flags().Int64Var(&bisectBatch, "batch_size", 256, "Number of entries to fetch for each probe")
+	cmd.Flags().IntVar(&bisectParallel, "parallel", 4, "Number of concurrent get-entries requests")
 	// TODO(pavelkalinnikov): Don't share these parameters with get-entries.
 	cmd.Flags().BoolVar(&chainOut, "chain", false, "Display entire certificate chain")
 	cmd.Flags().BoolVar(&textOut, "text", true, "Display certificates as text")
@@ -45,39 +67,349 @@ func init() {
 func runBisect(ctx context.Context) {
 	logClient := connect(ctx)
 	if timestamp == 0 {
-		klog.Exit("No -timestamp option supplied")
+		exitf(exitUsage, "No -timestamp option supplied")
+	}
+	if endTimestamp != 0 && endTimestamp < timestamp {
+		exitf(exitUsage, "--end_timestamp is before --timestamp")
+	}
+	if bisectBatch <= 0 || bisectParallel <= 0 {
+		exitf(exitUsage, "--batch_size and --parallel must be positive")
 	}
-	target := timestamp
 	sth, err := logClient.GetSTH(ctx)
 	if err != nil {
 		exitWithDetails(err)
 	}
-	getEntry := func(idx int64) *ct.RawLogEntry {
-		entries, err := logClient.GetRawEntries(ctx, idx, idx)
+
+	first, last := uint64(timestamp), uint64(endTimestamp)
+	if endTimestamp == 0 {
+		last = first
+	}
+	b := newBisector(logClient, int64(sth.TreeSize), bisectBatch, bisectParallel)
+	lo, hi, err := b.bounds(ctx, first, last, uint64(logMMD.Milliseconds()))
+	if err != nil {
+		exitWithDetails(err)
+	}
+	klog.V(1).Infof("Scanning entries [%d, %d) for timestamps in [%d, %d]", lo, hi, first, last)
+
+	out := bisectOutput{
+		LogURI:       logClient.BaseURI(),
+		TreeSize:     sth.TreeSize,
+		Timestamp:    first,
+		EndTimestamp: uint64(endTimestamp),
+		Entries:      []entryOutput{},
+	}
+	when := ct.TimestampToTime(first)
+	if endTimestamp == 0 {
+		// Find the first entry at or after the timestamp. The entry at hi, if
+		// any, is after it, so the scan can stop there.
+		if hi < int64(sth.TreeSize) {
+			hi++
+		}
+		var found *ct.RawLogEntry
+		err := b.scan(ctx, lo, hi, func(e *ct.RawLogEntry) bool {
+			if e.Leaf.TimestampedEntry.Timestamp >= first {
+				found = e
+				return false
+			}
+			return true
+		})
 		if err != nil {
 			exitWithDetails(err)
 		}
-		if l := len(entries.Entries); l != 1 {
-			klog.Exitf("Unexpected number (%d) of entries received requesting index %d", l, idx)
+		if jsonOutput() {
+			if found != nil {
+				out.Entries = append(out.Entries, newEntryOutput(found))
+			}
+			printJSON(out)
+			return
 		}
-		logEntry, err := ct.RawLogEntryFromLeaf(idx, &entries.Entries[0])
-		if err != nil {
-			klog.Exitf("Failed to parse leaf %d: %v", idx, err)
+		if found == nil {
+			showf("No entry with timestamp>=%d (%v) found up to tree size %d\n", first, when, sth.TreeSize)
+			return
 		}
-		return logEntry
+		showf("First entry with timestamp>=%d (%v) found at index %d\n", first, when, found.Index)
+		showRawLogEntry(found)
+		return
 	}
-	// Performing a binary search assumes that the timestamps are monotonically
-	// increasing.
-	idx := sort.Search(int(sth.TreeSize), func(idx int) bool {
-		klog.V(1).Infof("check timestamp at index %d", idx)
-		entry := getEntry(int64(idx))
-		return entry.Leaf.TimestampedEntry.Timestamp >= uint64(target)
+
+	count := 0
+	err = b.scan(ctx, lo, hi, func(e *ct.RawLogEntry) bool {
+		if ts := e.Leaf.TimestampedEntry.Timestamp; ts >= first && ts <= last {
+			count++
+			if jsonOutput() {
+				out.Entries = append(out.Entries, newEntryOutput(e))
+			} else {
+				showRawLogEntry(e)
+			}
+		}
+		return true
 	})
-	when := ct.TimestampToTime(uint64(target))
-	if idx >= int(sth.TreeSize) {
-		fmt.Printf("No entry with timestamp>=%d (%v) found up to tree size %d\n", target, when, sth.TreeSize)
+	if err != nil {
+		exitWithDetails(err)
+	}
+	if jsonOutput() {
+		printJSON(out)
 		return
 	}
-	fmt.Printf("First entry with timestamp>=%d (%v) found at index %d\n", target, when, idx)
-	showRawLogEntry(getEntry(int64(idx)))
+	showf("Found %d entries with timestamps in [%d (%v), %d (%v)] in index range [%d, %d) of tree size %d\n",
+		count, first, when, last, ct.TimestampToTime(last), lo, hi, sth.TreeSize)
+}
+
+// bisectOutput is the JSON output of bisect.
+type bisectOutput struct {
+	LogURI       string `json:"log_uri"`
+	TreeSize     uint64 `json:"tree_size"`
+	Timestamp    uint64 `json:"timestamp"`
+	EndTimestamp uint64 `json:"end_timestamp,omitempty"`
+	// Entries holds the first entry with a timestamp at or after Timestamp,
+	// if any, or with EndTimestamp, every entry with a timestamp between the
+	// two.
+	Entries []entryOutput `json:"entries"`
+}
+
+// bisector searches a log for entries by timestamp. It fetches entries in
+// batches aligned to multiples of the batch size, and caches the batches
+// fetched while bisecting, so that they are not fetched again when scanning.
+type bisector struct {
+	lc       *client.LogClient
+	size     int64
+	batch    int64
+	parallel int
+
+	mu    sync.Mutex
+	cache map[int64][]*ct.RawLogEntry // By batch start.
+}
+
+func newBisector(lc *client.LogClient, size, batch int64, parallel int) *bisector {
+	return &bisector{lc: lc, size: size, batch: batch, parallel: parallel, cache: make(map[int64][]*ct.RawLogEntry)}
+}
+
+// fetch returns the entries of the batch which starts at start. Transient
+// errors are retried a few times with backoff.
+func (b *bisector) fetch(ctx context.Context, start int64, cache bool) ([]*ct.RawLogEntry, error) {
+	b.mu.Lock()
+	entries, ok := b.cache[start]
+	b.mu.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	end := start + b.batch
+	if end > b.size {
+		end = b.size
+	}
+	entries = make([]*ct.RawLogEntry, 0, end-start)
+	bo := &backoff.Backoff{Min: time.Second, Max: 30 * time.Second, Factor: 2, Jitter: true}
+	for failures := 0; int64(len(entries)) < end-start; {
+		next := start + int64(len(entries))
+		// Logs may return fewer entries than requested.
+		rsp, err := b.lc.GetRawEntries(ctx, next, end-1)
+		if err == nil && len(rsp.Entries) == 0 {
+			err = fmt.Errorf("log returned no entries from index %d", next)
+		}
+		if err != nil {
+			if failures++; failures >= 5 || ctx.Err() != nil {
+				return nil, err
+			}
+			wait := bo.Duration()
+			klog.Warningf("Failed to get entries from index %d, retrying in %s: %v", next, wait, err)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		for i := range rsp.Entries {
+			e, err := ct.RawLogEntryFromLeaf(next+int64(i), &rsp.Entries[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse leaf %d: %v", next+int64(i), err)
+			}
+			entries = append(entries, e)
+		}
+	}
+	if cache {
+		b.mu.Lock()
+		b.cache[start] = entries
+		b.mu.Unlock()
+	}
+	return entries, nil
+}
+
+// probe fetches the batches holding the given indices concurrently, and
+// returns them by batch start.
+func (b *bisector) probe(ctx context.Context, indices []int64) (map[int64][]*ct.RawLogEntry, error) {
+	var mu sync.Mutex
+	batches := make(map[int64][]*ct.RawLogEntry)
+	seen := make(map[int64]bool)
+	var g errgroup.Group
+	for _, idx := range indices {
+		start := idx / b.batch * b.batch
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		g.Go(func() error {
+			klog.V(1).Infof("Probing entries from index %d", start)
+			entries, err := b.fetch(ctx, start, true)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			batches[start] = entries
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// probePoints returns up to b.parallel indices which split [lo, hi) evenly.
+func (b *bisector) probePoints(lo, hi int64) []int64 {
+	var points []int64
+	for i := 1; i <= b.parallel; i++ {
+		points = append(points, lo+(hi-lo)*int64(i)/int64(b.parallel+1))
+	}
+	return points
+}
+
+// bounds returns an index range [lo, hi) which holds all the entries with
+// timestamps in [first, last], given the log's MMD in milliseconds.
+//
+// An entry is sequenced at most an MMD after its timestamp, so if an entry
+// has a timestamp more than an MMD before first, then all the entries before
+// it have timestamps before first. Similarly, if an entry has a timestamp more
+// than an MMD after last, then all the entries after it have timestamps after
+// last. The bounds are found by a k-ary search for such entries, probing a
+// batch of entries at each of k points in parallel. As timestamps are not
+// monotonic, the probes can only exclude entries, so the bounds are safe, but
+// may hold up to about two MMDs' worth of entries outside of the range.
+func (b *bisector) bounds(ctx context.Context, first, last, mmd uint64) (int64, int64, error) {
+	var lo, hi int64
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		lo, err = b.search(gctx, func(ts uint64) bool { return ts+mmd < first }, true)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		hi, err = b.search(gctx, func(ts uint64) bool { return ts > last+mmd }, false)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return 0, 0, err
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi, nil
+}
+
+// search looks for the boundary of the entries excluded by the timestamp
+// predicate excluded. If left is set, an excluded entry excludes all the
+// entries before it, and search returns the index after the last one known
+// to be excluded. Otherwise, an excluded entry excludes all the entries after
+// it, and search returns the index of the first one known to be excluded, or
+// the tree size.
+func (b *bisector) search(ctx context.Context, excluded func(uint64) bool, left bool) (int64, error) {
+	// [lo, hi) is the range still to be searched. If left, the entries before
+	// lo are excluded; otherwise the entries from hi are excluded.
+	lo, hi := int64(0), b.size
+	for hi-lo > b.batch {
+		batches, err := b.probe(ctx, b.probePoints(lo, hi))
+		if err != nil {
+			return 0, err
+		}
+		newLo, newHi := lo, hi
+		for start, entries := range batches {
+			if left {
+				// Find the last excluded entry of the batch.
+				for i := len(entries) - 1; i >= 0; i-- {
+					if excluded(entries[i].Leaf.TimestampedEntry.Timestamp) {
+						if idx := start + int64(i) + 1; idx > newLo {
+							newLo = idx
+						}
+						break
+					}
+				}
+			} else {
+				// Find the first excluded entry of the batch.
+				for i, e := range entries {
+					if excluded(e.Leaf.TimestampedEntry.Timestamp) {
+						if idx := start + int64(i); idx < newHi {
+							newHi = idx
+						}
+						break
+					}
+				}
+			}
+		}
+		// Narrow the search to the part between the probes next to the
+		// boundary.
+		for start, entries := range batches {
+			end := start + int64(len(entries))
+			if left && start >= newLo && start < newHi && !anyExcluded(entries, excluded) {
+				newHi = start
+			}
+			if !left && end <= newHi && end > newLo && !anyExcluded(entries, excluded) {
+				newLo = end
+			}
+		}
+		if newLo == lo && newHi == hi {
+			break
+		}
+		lo, hi = newLo, newHi
+	}
+	if left {
+		return lo, nil
+	}
+	return hi, nil
+}
+
+func anyExcluded(entries []*ct.RawLogEntry, excluded func(uint64) bool) bool {
+	for _, e := range entries {
+		if excluded(e.Leaf.TimestampedEntry.Timestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan passes the entries [lo, hi) to fn in index order, until fn returns
+// false. Batches are fetched b.parallel at a time.
+func (b *bisector) scan(ctx context.Context, lo, hi int64, fn func(*ct.RawLogEntry) bool) error {
+	for start := lo / b.batch * b.batch; start < hi; {
+		var starts []int64
+		for ; start < hi && len(starts) < b.parallel; start += b.batch {
+			starts = append(starts, start)
+		}
+		batches := make([][]*ct.RawLogEntry, len(starts))
+		var g errgroup.Group
+		for i, s := range starts {
+			i, s := i, s
+			g.Go(func() error {
+				var err error
+				batches[i], err = b.fetch(ctx, s, false)
+				return err
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+		for _, entries := range batches {
+			for _, e := range entries {
+				if e.Index < lo || e.Index >= hi {
+					continue
+				}
+				if !fn(e) {
+					return nil
+				}
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// mmdTimestamps returns entry timestamps which are not monotonic, but where
+// each entry is sequenced within mmd of its timestamp: entry i is sequenced
+// at 1000+10*i.
+func mmdTimestamps(size int, mmd uint64) []uint64 {
+	r := rand.New(rand.NewSource(1))
+	timestamps := make([]uint64, size)
+	for i := range timestamps {
+		timestamps[i] = 1000 + 10*uint64(i) - uint64(r.Int63n(int64(mmd)+1))
+	}
+	return timestamps
+}
+
+func TestBisector(t *testing.T) {
+	ctx := context.Background()
+	const size, mmd = 1000, 100
+	timestamps := mmdTimestamps(size, mmd)
+
+	for _, tc := range []struct {
+		desc        string
+		first, last uint64
+		batch       int64
+		parallel    int
+		pageSize    int
+		// wantMaxRange is the most entries that the bounds should hold, if
+		// set.
+		wantMaxRange int64
+	}{
+		{desc: "point", first: 5000, last: 5000, batch: 16, parallel: 3, wantMaxRange: 64},
+		{desc: "range", first: 3000, last: 4000, batch: 16, parallel: 3, wantMaxRange: 164},
+		{desc: "before-log", first: 1, last: 500, batch: 16, parallel: 3, wantMaxRange: 32},
+		{desc: "start-of-log", first: 1, last: 1100, batch: 16, parallel: 3, wantMaxRange: 48},
+		{desc: "end-of-log", first: 10900, last: 20000, batch: 16, parallel: 3, wantMaxRange: 48},
+		{desc: "after-log", first: 20000, last: 30000, batch: 16, parallel: 3, wantMaxRange: 32},
+		{desc: "whole-log", first: 500, last: 20000, batch: 16, parallel: 3},
+		{desc: "one-probe", first: 7000, last: 7500, batch: 16, parallel: 1, wantMaxRange: 114},
+		{desc: "short-pages", first: 5000, last: 5000, batch: 16, parallel: 3, pageSize: 5, wantMaxRange: 64},
+		{desc: "batch-beyond-log", first: 5000, last: 5000, batch: 2000, parallel: 2},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l := newFakeLogWithTimestamps(t, timestamps)
+			l.pageSize = tc.pageSize
+			b := newBisector(newFakeLogClient(t, l), size, tc.batch, tc.parallel)
+
+			lo, hi, err := b.bounds(ctx, tc.first, tc.last, mmd)
+			if err != nil {
+				t.Fatalf("bounds()=%v", err)
+			}
+			if lo < 0 || hi < lo || hi > size {
+				t.Fatalf("bounds()=[%d, %d), want a range within [0, %d)", lo, hi, size)
+			}
+			if tc.wantMaxRange > 0 && hi-lo > tc.wantMaxRange {
+				t.Errorf("bounds()=[%d, %d), want at most %d entries", lo, hi, tc.wantMaxRange)
+			}
+
+			// All the entries in the timestamp range are within the bounds.
+			var want, got []int64
+			for i, ts := range timestamps {
+				if ts >= tc.first && ts <= tc.last {
+					want = append(want, int64(i))
+				}
+			}
+			var prev int64 = -1
+			err = b.scan(ctx, lo, hi, func(e *ct.RawLogEntry) bool {
+				if e.Index <= prev {
+					t.Errorf("scan() passed entry %d after %d", e.Index, prev)
+				}
+				prev = e.Index
+				if ts := e.Leaf.TimestampedEntry.Timestamp; ts >= tc.first && ts <= tc.last {
+					got = append(got, e.Index)
+				}
+				return true
+			})
+			if err != nil {
+				t.Fatalf("scan()=%v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("Found entries %v, want %v", got, want)
+			}
+
+			// The first entry at or after first is within the bounds, or is
+			// the entry at hi.
+			wantFirst := int64(-1)
+			for i, ts := range timestamps {
+				if ts >= tc.first {
+					wantFirst = int64(i)
+					break
+				}
+			}
+			if hi < size {
+				hi++
+			}
+			gotFirst := int64(-1)
+			err = b.scan(ctx, lo, hi, func(e *ct.RawLogEntry) bool {
+				if e.Leaf.TimestampedEntry.Timestamp >= tc.first {
+					gotFirst = e.Index
+					return false
+				}
+				return true
+			})
+			if err != nil {
+				t.Fatalf("scan()=%v", err)
+			}
+			if gotFirst != wantFirst {
+				t.Errorf("Found first entry %d, want %d", gotFirst, wantFirst)
+			}
+		})
+	}
+}
+
+func TestBisectorFetchError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := newFakeLog(t, 100)
+	l.status = http.StatusInternalServerError
+	b := newBisector(newFakeLogClient(t, l), 100, 16, 2)
+	// Cancel so that the failed fetches are not retried.
+	cancel()
+	if _, _, err := b.bounds(ctx, 50, 50, 10); err == nil {
+		t.Error("bounds()=nil, want error")
+	}
+	if err := b.scan(ctx, 0, 100, func(*ct.RawLogEntry) bool { return true }); err == nil {
+		t.Error("scan()=nil, want error")
+	}
+}
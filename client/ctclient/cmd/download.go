@@ -0,0 +1,226 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/client/archive"
+	"github.com/google/certificate-transparency-go/scanner"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	downloadStart       int64
+	downloadEnd         int64
+	downloadOut         string
+	downloadSegmentSize int64
+	downloadBatchSize   int
+	downloadParallel    int
+	downloadVerify      bool
+)
+
+func init() {
+	cmd := cobra.Command{
+		Use:   fmt.Sprintf("download %s --out=dir [--start=idx] [--end=idx]", connectionFlags),
+		Short: "Download a range of entries of the log into an archive",
+		Long: `Downloads the entries [--start, --end) of the log into an archive directory,
+as compressed segment files, together with the STH they were downloaded against
+and the log's roots. Once complete, the entries are verified against the root
+hash of the STH. Running the command again on the same directory resumes an
+interrupted download. The archive can be served with serve-archive.`,
+		Args: cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			runDownload(cmd.Context(), cmd)
+		},
+	}
+	cmd.Flags().Int64Var(&downloadStart, "start", 0, "Index of the first entry to download")
+	cmd.Flags().Int64Var(&downloadEnd, "end", 0, "Index after the last entry to download; 0 for the tree size")
+	cmd.Flags().StringVar(&downloadOut, "out", "", "Directory to store the archive in")
+	cmd.Flags().Int64Var(&downloadSegmentSize, "segment_size", archive.DefaultSegmentSize, "Number of entries in each segment file")
+	cmd.Flags().IntVar(&downloadBatchSize, "batch_size", 1000, "Number of entries to request at a time")
+	cmd.Flags().IntVar(&downloadParallel, "parallel_fetch", 2, "Number of concurrent get-entries requests")
+	cmd.Flags().BoolVar(&downloadVerify, "verify", true, "Verify the downloaded entries against the STH")
+	rootCmd.AddCommand(&cmd)
+}
+
+// runDownload runs the download command.
+func runDownload(ctx context.Context, cmd *cobra.Command) {
+	if downloadOut == "" {
+		exitf(exitUsage, "No --out directory supplied")
+	}
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	logClient := connect(ctx)
+
+	a, err := archive.Open(downloadOut)
+	switch {
+	case err == nil:
+		m := a.Manifest()
+		if m.LogURI != logClient.BaseURI() {
+			exitf(exitUsage, "%s holds an archive of log %s, not %s", downloadOut, m.LogURI, logClient.BaseURI())
+		}
+		if cmd.Flags().Changed("start") && downloadStart != m.Start || cmd.Flags().Changed("end") && downloadEnd != m.End {
+			exitf(exitUsage, "%s holds an archive of entries [%d, %d)", downloadOut, m.Start, m.End)
+		}
+		klog.Infof("Resuming download of entries [%d, %d) at tree size %d", m.Start, m.End, a.STH().TreeSize)
+	case errors.Is(err, os.ErrNotExist):
+		a = createArchive(ctx, logClient)
+	default:
+		exitf(exitFailure, "Failed to open archive: %v", err)
+	}
+
+	m := a.Manifest()
+	next, err := a.Next()
+	if err != nil {
+		exitf(exitFailure, "Failed to find downloaded entries: %v", err)
+	}
+	if next < m.End {
+		if err := downloadEntries(ctx, logClient, a, next); err != nil {
+			exitWithDetails(fmt.Errorf("download failed, run again to resume: %w", err))
+		}
+	}
+	if !jsonOutput() {
+		fmt.Printf("Downloaded entries [%d, %d) to %s\n", m.Start, m.End, downloadOut)
+	}
+
+	sth := a.STH()
+	if downloadVerify {
+		if err := a.Verify(ctx, logClient); err != nil {
+			exitf(exitVerifyFailed, "Failed to verify entries: %v", err)
+		}
+		if !jsonOutput() {
+			fmt.Printf("Verified entries against STH of size %d with root hash %x\n", sth.TreeSize, sth.SHA256RootHash)
+		}
+	}
+	if jsonOutput() {
+		printJSON(downloadOutput{
+			LogURI:   m.LogURI,
+			Dir:      downloadOut,
+			Start:    m.Start,
+			End:      m.End,
+			TreeSize: sth.TreeSize,
+			RootHash: sth.SHA256RootHash,
+			Verified: downloadVerify,
+		})
+	}
+}
+
+// downloadOutput is the JSON output of download.
+type downloadOutput struct {
+	LogURI string `json:"log_uri"`
+	Dir    string `json:"dir"`
+	Start  int64  `json:"start"`
+	End    int64  `json:"end"`
+	// TreeSize and RootHash are those of the archive's STH.
+	TreeSize uint64 `json:"tree_size"`
+	RootHash []byte `json:"root_hash"`
+	// Verified is true if the entries were verified against the STH.
+	Verified bool `json:"verified"`
+}
+
+// createArchive creates the archive of the requested range of entries, under
+// the log's current STH.
+func createArchive(ctx context.Context, logClient *client.LogClient) *archive.Archive {
+	sth, err := logClient.VerifiedGetSTH(ctx)
+	if errors.Is(err, client.ErrNoPublicKey) {
+		klog.Warning("No public key for the log, so its STH signature is not checked; use --pub_key or --log_name")
+		sth, err = logClient.GetSTH(ctx)
+	}
+	if err != nil {
+		exitWithDetails(err)
+	}
+	end := downloadEnd
+	if end == 0 {
+		end = int64(sth.TreeSize)
+	}
+	rsp, err := sthResponse(sth)
+	if err != nil {
+		exitf(exitFailure, "Failed to encode STH: %v", err)
+	}
+	a, err := archive.Create(downloadOut, archive.Manifest{
+		LogURI:      logClient.BaseURI(),
+		Start:       downloadStart,
+		End:         end,
+		SegmentSize: downloadSegmentSize,
+	}, rsp)
+	if err != nil {
+		exitf(exitFailure, "Failed to create archive: %v", err)
+	}
+	if roots, err := logClient.GetAcceptedRoots(ctx); err != nil {
+		klog.Warningf("Failed to get the log's roots: %v", err)
+	} else if err := a.SetRoots(roots); err != nil {
+		klog.Warningf("Failed to store the log's roots: %v", err)
+	}
+	klog.Infof("Downloading entries [%d, %d) at tree size %d", downloadStart, end, sth.TreeSize)
+	return a
+}
+
+// downloadEntries fetches the entries of the archive from next onwards, and
+// stores them.
+func downloadEntries(ctx context.Context, logClient *client.LogClient, a *archive.Archive, next int64) error {
+	ap, err := a.NewAppender(next)
+	if err != nil {
+		return err
+	}
+	m := a.Manifest()
+	opts := scanner.DefaultFetcherOptions()
+	opts.BatchSize = downloadBatchSize
+	opts.ParallelFetch = downloadParallel
+	opts.StartIndex = next
+	opts.EndIndex = m.End
+	opts.Ordered = true
+	fetcher := scanner.NewFetcher(logClient, opts)
+
+	var (
+		mu        sync.Mutex
+		appendErr error
+	)
+	err = fetcher.Run(ctx, func(b scanner.EntryBatch) {
+		// Batches are delivered in order, one at a time.
+		stored := ap.Stored()
+		if err := ap.Append(b.Start, b.Entries); err != nil {
+			mu.Lock()
+			appendErr = err
+			mu.Unlock()
+			fetcher.Stop()
+			return
+		}
+		if ap.Stored() != stored {
+			klog.V(1).Infof("Stored entries up to %d of [%d, %d)", ap.Stored(), m.Start, m.End)
+		}
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case appendErr != nil:
+		return appendErr
+	case err != nil:
+		return err
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case ap.Stored() != m.End:
+		return fmt.Errorf("stored entries up to %d of [%d, %d)", ap.Stored(), m.Start, m.End)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+)
+
+// fakeLog is an RFC 6962 log serving STHs, proofs and entries, with knobs to
+// make it misbehave. The knobs are guarded by mu.
+type fakeLog struct {
+	key     *ecdsa.PrivateKey
+	tree    *testonly.Tree
+	entries []ct.LeafEntry
+
+	mu           sync.Mutex
+	sthSize      uint64 // Tree size of the served STH.
+	sthTimestamp uint64 // Timestamp of the served STH, or now if 0.
+	forgeRoot    bool   // Serves a validly signed STH with a wrong root hash.
+	badSignature bool   // Corrupts the signature of the served STH.
+	badProofs    bool   // Corrupts consistency proofs.
+	status       int    // Fails all requests with this HTTP status if set.
+	pageSize     int    // Serves at most this many entries at once if set.
+}
+
+// newFakeLog returns a log holding size entries, with their index as
+// timestamp, which serves an STH for all of them.
+func newFakeLog(t *testing.T, size int) *fakeLog {
+	t.Helper()
+	timestamps := make([]uint64, size)
+	for i := range timestamps {
+		timestamps[i] = uint64(i)
+	}
+	return newFakeLogWithTimestamps(t, timestamps)
+}
+
+// newFakeLogWithTimestamps returns a log holding an entry for each of the
+// given timestamps, which serves an STH for all of them.
+func newFakeLogWithTimestamps(t *testing.T, timestamps []uint64) *fakeLog {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	chain, err := tls.Marshal(ct.CertificateChain{})
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	l := &fakeLog{key: key, tree: testonly.New(rfc6962.DefaultHasher)}
+	for i, ts := range timestamps {
+		leaf := ct.MerkleTreeLeaf{
+			Version:  ct.V1,
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				Timestamp: ts,
+				EntryType: ct.X509LogEntryType,
+				X509Entry: &ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert-%d", i))},
+			},
+		}
+		data, err := tls.Marshal(leaf)
+		if err != nil {
+			t.Fatalf("Marshal(): %v", err)
+		}
+		l.entries = append(l.entries, ct.LeafEntry{LeafInput: data, ExtraData: chain})
+		l.tree.AppendData(data)
+	}
+	l.sthSize = l.tree.Size()
+	return l
+}
+
+// update changes the knobs of the log with fn.
+func (l *fakeLog) update(fn func(l *fakeLog)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fn(l)
+}
+
+// sth returns the STH that the log currently serves.
+func (l *fakeLog) sth(t *testing.T) *ct.SignedTreeHead {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sth := &ct.SignedTreeHead{Version: ct.V1, TreeSize: l.sthSize, Timestamp: l.sthTimestamp}
+	if sth.Timestamp == 0 {
+		sth.Timestamp = uint64(time.Now().UnixMilli())
+	}
+	copy(sth.SHA256RootHash[:], l.tree.HashAt(l.sthSize))
+	if l.forgeRoot {
+		sth.SHA256RootHash[0] ^= 1
+	}
+	input, err := ct.SerializeSTHSignatureInput(*sth)
+	if err != nil {
+		t.Fatalf("SerializeSTHSignatureInput(): %v", err)
+	}
+	digest := sha256.Sum256(input)
+	sig, err := ecdsa.SignASN1(rand.Reader, l.key, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1(): %v", err)
+	}
+	if l.badSignature {
+		digest[0] ^= 1
+		if sig, err = ecdsa.SignASN1(rand.Reader, l.key, digest[:]); err != nil {
+			t.Fatalf("SignASN1(): %v", err)
+		}
+	}
+	sth.TreeHeadSignature = ct.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+		Signature: sig,
+	}
+	return sth
+}
+
+func (l *fakeLog) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("Encode(): %v", err)
+		}
+	}
+	mux.HandleFunc("/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		sth := l.sth(t)
+		sig, err := tls.Marshal(sth.TreeHeadSignature)
+		if err != nil {
+			t.Errorf("Marshal(): %v", err)
+		}
+		writeJSON(w, ct.GetSTHResponse{TreeSize: sth.TreeSize, Timestamp: sth.Timestamp, SHA256RootHash: sth.SHA256RootHash[:], TreeHeadSignature: sig})
+	})
+	mux.HandleFunc("/ct/v1/get-sth-consistency", func(w http.ResponseWriter, r *http.Request) {
+		first, _ := strconv.ParseUint(r.FormValue("first"), 10, 64)
+		second, _ := strconv.ParseUint(r.FormValue("second"), 10, 64)
+		p, err := l.tree.ConsistencyProof(first, second)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.mu.Lock()
+		if l.badProofs && len(p) > 0 {
+			p[0] = append([]byte{}, p[0]...)
+			p[0][0] ^= 1
+		}
+		l.mu.Unlock()
+		writeJSON(w, ct.GetSTHConsistencyResponse{Consistency: p})
+	})
+	mux.HandleFunc("/ct/v1/get-entries", func(w http.ResponseWriter, r *http.Request) {
+		start, err1 := strconv.Atoi(r.FormValue("start"))
+		end, err2 := strconv.Atoi(r.FormValue("end"))
+		if err1 != nil || err2 != nil || start < 0 || end < start || start >= len(l.entries) {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		if end >= len(l.entries) {
+			end = len(l.entries) - 1
+		}
+		l.mu.Lock()
+		if l.pageSize > 0 && end-start+1 > l.pageSize {
+			end = start + l.pageSize - 1
+		}
+		l.mu.Unlock()
+		writeJSON(w, ct.GetEntriesResponse{Entries: l.entries[start : end+1]})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		status := l.status
+		l.mu.Unlock()
+		if status != 0 {
+			http.Error(w, "fake log failure", status)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// newFakeLogClient returns a client for l, with its public key, and closes
+// its server at the end of the test.
+func newFakeLogClient(t *testing.T, l *fakeLog) *client.LogClient {
+	t.Helper()
+	ts := httptest.NewServer(l.handler(t))
+	t.Cleanup(ts.Close)
+	der, err := x509.MarshalPKIXPublicKey(l.key.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey(): %v", err)
+	}
+	lc, err := client.New(ts.URL, ts.Client(), jsonclient.Options{PublicKeyDER: der})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	return lc
+}
@@ -25,7 +25,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
-	"k8s.io/klog/v2"
 )
 
 var (
@@ -56,49 +55,67 @@ func init() {
 func runGetConsistencyProof(ctx context.Context) {
 	logClient := connect(ctx)
 	if treeSize <= 0 {
-		klog.Exit("No valid --size supplied")
+		exitf(exitUsage, "No valid --size supplied")
 	}
 	if prevSize <= 0 {
-		klog.Exit("No valid --prev_size supplied")
+		exitf(exitUsage, "No valid --prev_size supplied")
 	}
 	var hash1, hash2 []byte
 	if prevHash != "" {
 		var err error
 		hash1, err = hashFromString(prevHash)
 		if err != nil {
-			klog.Exitf("Invalid --prev_hash: %v", err)
+			exitf(exitUsage, "Invalid --prev_hash: %v", err)
 		}
 	}
 	if treeHash != "" {
 		var err error
 		hash2, err = hashFromString(treeHash)
 		if err != nil {
-			klog.Exitf("Invalid --tree_hash: %v", err)
+			exitf(exitUsage, "Invalid --tree_hash: %v", err)
 		}
 	}
 	if (hash1 != nil) != (hash2 != nil) {
-		klog.Exitf("Need both --prev_hash and --tree_hash or neither")
+		exitf(exitUsage, "Need both --prev_hash and --tree_hash or neither")
 	}
 	getConsistencyProofBetween(ctx, logClient, prevSize, treeSize, hash1, hash2)
 }
 
+// consistencyOutput is the JSON output of get-consistency-proof.
+type consistencyOutput struct {
+	LogURI      string   `json:"log_uri"`
+	FirstSize   uint64   `json:"first_size"`
+	SecondSize  uint64   `json:"second_size"`
+	Consistency [][]byte `json:"consistency"`
+	// Verified is true if the proof was verified against --prev_hash and
+	// --tree_hash.
+	Verified bool `json:"verified"`
+}
+
 func getConsistencyProofBetween(ctx context.Context, logClient client.CheckLogClient, first, second uint64, prevHash, treeHash []byte) {
 	pf, err := logClient.GetSTHConsistency(ctx, uint64(first), uint64(second))
 	if err != nil {
 		exitWithDetails(err)
 	}
-	fmt.Printf("Consistency proof from size %d to size %d:\n", first, second)
-	for _, e := range pf {
-		fmt.Printf("  %x\n", e)
+	out := consistencyOutput{LogURI: logClient.BaseURI(), FirstSize: first, SecondSize: second, Consistency: pf}
+	if !jsonOutput() {
+		fmt.Printf("Consistency proof from size %d to size %d:\n", first, second)
+		for _, e := range pf {
+			fmt.Printf("  %x\n", e)
+		}
 	}
-	if prevHash == nil || treeHash == nil {
-		return
+	if prevHash != nil && treeHash != nil {
+		// We have tree hashes so we can verify the proof.
+		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, first, second, pf, prevHash, treeHash); err != nil {
+			exitf(exitVerifyFailed, "Failed to VerifyConsistency(%x @size=%d, %x @size=%d): %v", prevHash, first, treeHash, second, err)
+		}
+		out.Verified = true
 	}
-	// We have tree hashes so we can verify the proof.
-	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, first, second, pf, prevHash, treeHash); err != nil {
-		klog.Exitf("Failed to VerifyConsistency(%x @size=%d, %x @size=%d): %v", prevHash, first, treeHash, second, err)
+	if jsonOutput() {
+		printJSON(out)
+	} else if out.Verified {
+		fmt.Printf("Verified that hash %x @%d + proof = hash %x @%d\n", prevHash, first, treeHash, second)
 	}
-	fmt.Printf("Verified that hash %x @%d + proof = hash %x @%d\n", prevHash, first, treeHash, second)
 }
 
 func hashFromString(input string) ([]byte, error) {
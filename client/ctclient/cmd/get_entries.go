@@ -55,7 +55,7 @@ func init() {
 func runGetEntries(ctx context.Context) {
 	logClient := connect(ctx)
 	if getFirst == -1 {
-		klog.Exit("No -first option supplied")
+		exitf(exitUsage, "No -first option supplied")
 	}
 	if getLast == -1 {
 		getLast = getFirst
@@ -65,19 +65,98 @@ func runGetEntries(ctx context.Context) {
 		exitWithDetails(err)
 	}
 
+	out := entriesOutput{LogURI: logClient.BaseURI(), Entries: []entryOutput{}}
 	for i, rawEntry := range rsp.Entries {
 		index := getFirst + int64(i)
 		rle, err := ct.RawLogEntryFromLeaf(index, &rawEntry)
 		if err != nil {
-			fmt.Printf("Index=%d Failed to unmarshal leaf entry: %v", index, err)
+			if jsonOutput() {
+				out.Entries = append(out.Entries, entryOutput{Index: index, Error: err.Error()})
+			} else {
+				klog.Errorf("Index=%d Failed to unmarshal leaf entry: %v", index, err)
+			}
+			continue
+		}
+		if jsonOutput() {
+			out.Entries = append(out.Entries, newEntryOutput(rle))
 			continue
 		}
 		showRawLogEntry(rle)
 	}
+	if jsonOutput() {
+		printJSON(out)
+	}
+}
+
+// entriesOutput is the JSON output of get-entries.
+type entriesOutput struct {
+	LogURI  string        `json:"log_uri"`
+	Entries []entryOutput `json:"entries"`
+}
+
+// entryOutput is the JSON form of a log entry.
+type entryOutput struct {
+	Index int64 `json:"index"`
+	// Error is set if the entry couldn't be parsed, in which case only Index
+	// is also set.
+	Error     string `json:"error,omitempty"`
+	LeafHash  []byte `json:"leaf_hash,omitempty"`
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	// EntryType is "x509" or "precert".
+	EntryType     string `json:"entry_type,omitempty"`
+	IssuerKeyHash []byte `json:"issuer_key_hash,omitempty"`
+	// Certificate is the certificate, or the precertificate as submitted.
+	Certificate *certOutput `json:"certificate,omitempty"`
+	// Chain is only set with --chain.
+	Chain []certOutput `json:"chain,omitempty"`
+}
+
+// newEntryOutput returns the JSON form of rle.
+func newEntryOutput(rle *ct.RawLogEntry) entryOutput {
+	out := entryOutput{Index: rle.Index}
+	if hash, err := ct.LeafHashForLeaf(&rle.Leaf); err == nil {
+		out.LeafHash = hash[:]
+	}
+	ts := rle.Leaf.TimestampedEntry
+	out.Timestamp = ts.Timestamp
+	switch ts.EntryType {
+	case ct.X509LogEntryType:
+		out.EntryType = "x509"
+	case ct.PrecertLogEntryType:
+		out.EntryType = "precert"
+		out.IssuerKeyHash = ts.PrecertEntry.IssuerKeyHash[:]
+	default:
+		out.EntryType = ts.EntryType.String()
+	}
+	if len(rle.Cert.Data) > 0 {
+		cert := newCertOutput(rle.Cert.Data)
+		out.Certificate = &cert
+	}
+	if chainOut {
+		out.Chain = newCertsOutput(rle.Chain)
+	}
+	return out
+}
+
+// certsAsText reports whether certificates should be printed as text rather
+// than PEM.
+func certsAsText() bool {
+	return textOut && outputFormat != outputPEM
 }
 
 func showRawLogEntry(rle *ct.RawLogEntry) {
 	ts := rle.Leaf.TimestampedEntry
+	if outputFormat == outputPEM {
+		if len(rle.Cert.Data) > 0 {
+			showPEMData(rle.Cert.Data)
+		}
+		if chainOut {
+			for _, c := range rle.Chain {
+				showPEMData(c.Data)
+			}
+		}
+		return
+	}
 	when := ct.TimestampToTime(ts.Timestamp)
 	fmt.Printf("Index=%d Timestamp=%d (%v) ", rle.Index, ts.Timestamp, when)
 
@@ -99,7 +178,7 @@ func showRawLogEntry(rle *ct.RawLogEntry) {
 }
 
 func showRawCert(cert ct.ASN1Cert) {
-	if textOut {
+	if certsAsText() {
 		c, err := x509.ParseCertificate(cert.Data)
 		if err != nil {
 			klog.Errorf("Error parsing certificate: %q", err.Error())
@@ -114,7 +193,7 @@ func showRawCert(cert ct.ASN1Cert) {
 }
 
 func showParsedCert(cert *x509.Certificate) {
-	if textOut {
+	if certsAsText() {
 		fmt.Printf("%s\n", x509util.CertificateToString(cert))
 	} else {
 		showPEMData(cert.Raw)
@@ -66,7 +66,7 @@ func runGetInclusionProof(ctx context.Context) {
 		var err error
 		hash, err = hashFromString(leafHash)
 		if err != nil {
-			klog.Exitf("Invalid --leaf_hash supplied: %v", err)
+			exitf(exitUsage, "Invalid --leaf_hash supplied: %v", err)
 		}
 	} else if len(certChain) > 0 {
 		// Build a leaf hash from the chain and a timestamp.
@@ -75,7 +75,7 @@ func runGetInclusionProof(ctx context.Context) {
 			entryTimestamp = timestamp // Use user-specified timestamp.
 		}
 		if entryTimestamp == 0 {
-			klog.Exit("No timestamp available to accompany certificate")
+			exitf(exitUsage, "No timestamp available to accompany certificate")
 		}
 
 		var leafEntry *ct.MerkleTreeLeaf
@@ -86,7 +86,7 @@ func runGetInclusionProof(ctx context.Context) {
 		} else if cert.IsPrecertificate() {
 			leafEntry, err = ct.MerkleTreeLeafFromRawChain(chain, ct.PrecertLogEntryType, uint64(entryTimestamp))
 			if err != nil {
-				klog.Exitf("Failed to build pre-certificate leaf entry: %v", err)
+				exitf(exitFailure, "Failed to build pre-certificate leaf entry: %v", err)
 			}
 		} else {
 			leafEntry = ct.CreateX509MerkleTreeLeaf(chain[0], uint64(entryTimestamp))
@@ -94,7 +94,7 @@ func runGetInclusionProof(ctx context.Context) {
 
 		leafHash, err := ct.LeafHashForLeaf(leafEntry)
 		if err != nil {
-			klog.Exitf("Failed to create hash of leaf: %v", err)
+			exitf(exitFailure, "Failed to create hash of leaf: %v", err)
 		}
 		hash = leafHash[:]
 
@@ -105,12 +105,32 @@ func runGetInclusionProof(ctx context.Context) {
 		}
 	}
 	if len(hash) != sha256.Size {
-		klog.Exit("No leaf hash available")
+		exitf(exitUsage, "No leaf hash available")
 	}
-	getInclusionProofForHash(ctx, logClient, hash)
+	out := getInclusionProofForHash(ctx, logClient, hash)
+	if jsonOutput() {
+		printJSON(out)
+		return
+	}
+	showInclusionProof(out)
+}
+
+// inclusionOutput is the JSON output of get-inclusion-proof.
+type inclusionOutput struct {
+	LogURI    string   `json:"log_uri"`
+	LeafHash  []byte   `json:"leaf_hash"`
+	LeafIndex int64    `json:"leaf_index"`
+	TreeSize  uint64   `json:"tree_size"`
+	AuditPath [][]byte `json:"audit_path"`
+	// RootHash is the root hash of the STH the proof was verified against,
+	// which is only fetched if no --size is given.
+	RootHash []byte `json:"root_hash,omitempty"`
+	Verified bool   `json:"verified"`
 }
 
-func getInclusionProofForHash(ctx context.Context, logClient client.CheckLogClient, hash []byte) {
+// getInclusionProofForHash fetches the inclusion proof for hash, and verifies
+// it if the tree size wasn't given.
+func getInclusionProofForHash(ctx context.Context, logClient client.CheckLogClient, hash []byte) *inclusionOutput {
 	var sth *ct.SignedTreeHead
 	size := treeSize
 	if size <= 0 {
@@ -121,28 +141,46 @@ func getInclusionProofForHash(ctx context.Context, logClient client.CheckLogClie
 		}
 		size = sth.TreeSize
 	}
-	// Display the inclusion proof.
 	rsp, err := logClient.GetProofByHash(ctx, hash, size)
 	if err != nil {
 		exitWithDetails(err)
 	}
-	fmt.Printf("Inclusion proof for index %d in tree of size %d:\n", rsp.LeafIndex, size)
-	for _, e := range rsp.AuditPath {
-		fmt.Printf("  %x\n", e)
+	out := &inclusionOutput{
+		LogURI:    logClient.BaseURI(),
+		LeafHash:  hash,
+		LeafIndex: rsp.LeafIndex,
+		TreeSize:  size,
+		AuditPath: rsp.AuditPath,
 	}
 	if sth != nil {
 		// If we retrieved an STH we can verify the proof.
 		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(rsp.LeafIndex), sth.TreeSize, hash, rsp.AuditPath, sth.SHA256RootHash[:]); err != nil {
-			klog.Exitf("Failed to VerifyInclusion(%d, %d)=%v", rsp.LeafIndex, sth.TreeSize, err)
+			if !jsonOutput() {
+				showInclusionProof(out)
+			}
+			exitf(exitVerifyFailed, "Failed to VerifyInclusion(%d, %d)=%v", rsp.LeafIndex, sth.TreeSize, err)
 		}
-		fmt.Printf("Verified that hash %x + proof = root hash %x\n", hash, sth.SHA256RootHash)
+		out.RootHash = sth.SHA256RootHash[:]
+		out.Verified = true
+	}
+	return out
+}
+
+// showInclusionProof displays an inclusion proof as text.
+func showInclusionProof(out *inclusionOutput) {
+	fmt.Printf("Inclusion proof for index %d in tree of size %d:\n", out.LeafIndex, out.TreeSize)
+	for _, e := range out.AuditPath {
+		fmt.Printf("  %x\n", e)
+	}
+	if out.Verified {
+		fmt.Printf("Verified that hash %x + proof = root hash %x\n", out.LeafHash, out.RootHash)
 	}
 }
 
 func chainFromFile(filename string) ([]ct.ASN1Cert, int64) {
 	contents, err := os.ReadFile(filename)
 	if err != nil {
-		klog.Exitf("Failed to read certificate file: %v", err)
+		exitf(exitFailure, "Failed to read certificate file: %v", err)
 	}
 	rest := contents
 	var chain []ct.ASN1Cert
@@ -157,7 +195,7 @@ func chainFromFile(filename string) ([]ct.ASN1Cert, int64) {
 		}
 	}
 	if len(chain) == 0 {
-		klog.Exitf("No certificates found in %s", certChain)
+		exitf(exitUsage, "No certificates found in %s", certChain)
 	}
 
 	// Also look for something like a text timestamp for convenience.
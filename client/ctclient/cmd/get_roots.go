@@ -43,7 +43,17 @@ func runGetRoots(ctx context.Context) {
 	if err != nil {
 		exitWithDetails(err)
 	}
+	if jsonOutput() {
+		printJSON(rootsOutput{LogURI: logClient.BaseURI(), Certificates: newCertsOutput(roots)})
+		return
+	}
 	for _, root := range roots {
 		showRawCert(root)
 	}
 }
+
+// rootsOutput is the JSON output of get-roots.
+type rootsOutput struct {
+	LogURI       string       `json:"log_uri"`
+	Certificates []certOutput `json:"certificates"`
+}
@@ -41,8 +41,23 @@ func runGetSTH(ctx context.Context) {
 	if err != nil {
 		exitWithDetails(err)
 	}
+	if jsonOutput() {
+		rsp, err := sthResponse(sth)
+		if err != nil {
+			exitf(exitFailure, "Failed to encode STH: %v", err)
+		}
+		printJSON(sthOutput{LogURI: logClient.BaseURI(), GetSTHResponse: *rsp})
+		return
+	}
 	// Display the STH.
 	when := ct.TimestampToTime(sth.Timestamp)
 	fmt.Printf("%v (timestamp %d): Got STH for %v log (size=%d) at %v, hash %x\n", when, sth.Timestamp, sth.Version, sth.TreeSize, logClient.BaseURI(), sth.SHA256RootHash)
 	fmt.Printf("%v\n", signatureToString(&sth.TreeHeadSignature))
 }
+
+// sthOutput is the JSON output of get-sth: the STH as in the log's get-sth
+// response.
+type sthOutput struct {
+	LogURI string `json:"log_uri"`
+	ct.GetSTHResponse
+}
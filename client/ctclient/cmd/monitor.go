@@ -0,0 +1,307 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	monitorInterval  time.Duration
+	monitorStateFile string
+	monitorMMD       time.Duration
+	monitorMaxPolls  int
+)
+
+func init() {
+	cmd := cobra.Command{
+		Use:   fmt.Sprintf("monitor %s --state_file=file [--interval=duration] [--mmd=duration]", connectionFlags),
+		Short: "Follow the log's STH, checking its signature and consistency",
+		Long: `Polls the log's STH, and checks its signature, and its consistency with the
+last good STH, which is kept in --state_file across runs. Writes one JSON
+event per line to stdout: "sth" for each good STH, "alert" for misbehaviour
+of the log (bad signatures, inconsistent or shrinking trees, timestamps going
+backwards, and STHs older than the Maximum Merge Delay), and "error" for
+failures to talk to the log.`,
+		Args: cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			runMonitor(cmd.Context())
+		},
+	}
+	cmd.Flags().DurationVar(&monitorInterval, "interval", time.Minute, "Interval between polls of the log's STH")
+	cmd.Flags().StringVar(&monitorStateFile, "state_file", "", "File holding the last good STH of the log")
+	cmd.Flags().DurationVar(&monitorMMD, "mmd", 24*time.Hour, "Maximum Merge Delay of the log; STHs older than this are reported")
+	cmd.Flags().IntVar(&monitorMaxPolls, "max_polls", 0, "Stop after this many polls, if positive")
+	rootCmd.AddCommand(&cmd)
+}
+
+// Alert types reported by the monitor.
+const (
+	alertBadSignature       = "bad_signature"
+	alertInconsistent       = "inconsistent"
+	alertSplitView          = "split_view"
+	alertTreeShrunk         = "tree_shrunk"
+	alertTimestampBackwards = "timestamp_backwards"
+	alertMMDViolation       = "mmd_violation"
+)
+
+// monitorEvent is a JSON event written by the monitor.
+type monitorEvent struct {
+	Time time.Time `json:"time"`
+	Log  string    `json:"log"`
+	// Event is "sth", "alert" or "error".
+	Event string `json:"event"`
+	// Alert is the type of alert, for "alert" events.
+	Alert   string `json:"alert,omitempty"`
+	Message string `json:"message,omitempty"`
+	// The STH that the event is about, if any.
+	TreeSize  uint64 `json:"tree_size,omitempty"`
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	RootHash  []byte `json:"root_hash,omitempty"`
+	// The last good STH, if the event compares against it.
+	PrevTreeSize  uint64 `json:"prev_tree_size,omitempty"`
+	PrevTimestamp uint64 `json:"prev_timestamp,omitempty"`
+	PrevRootHash  []byte `json:"prev_root_hash,omitempty"`
+	// STHAge is the age of the STH in seconds, for "sth" and "mmd_violation"
+	// events.
+	STHAge float64 `json:"sth_age_secs,omitempty"`
+}
+
+// monitorState is the state of the monitor, persisted across runs.
+type monitorState struct {
+	Log string             `json:"log"`
+	STH *ct.GetSTHResponse `json:"sth"`
+}
+
+// monitor follows the STH of one log.
+type monitor struct {
+	lc        *client.LogClient
+	stateFile string
+	mmd       time.Duration
+	out       *json.Encoder
+	prev      *ct.SignedTreeHead // The last good STH, or nil.
+}
+
+// runMonitor runs the monitor command.
+func runMonitor(ctx context.Context) {
+	if monitorStateFile == "" {
+		exitf(exitUsage, "No --state_file supplied")
+	}
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	m := &monitor{
+		lc:        connect(ctx),
+		stateFile: monitorStateFile,
+		mmd:       monitorMMD,
+		out:       json.NewEncoder(os.Stdout),
+	}
+	if m.lc.Verifier == nil {
+		exitf(exitUsage, "The log's public key is needed to verify its STHs: use --pub_key or --log_name")
+	}
+	if err := m.loadState(); err != nil {
+		exitf(exitFailure, "Failed to load state: %v", err)
+	}
+
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+	for polls := 1; ; polls++ {
+		m.poll(ctx)
+		if monitorMaxPolls > 0 && polls >= monitorMaxPolls {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadState loads the last good STH from the state file, if it exists.
+func (m *monitor) loadState() error {
+	sth, err := readSTHState(m.stateFile, m.lc.BaseURI())
+	if err != nil {
+		return err
+	}
+	m.prev = sth
+	return nil
+}
+
+// saveState atomically replaces the state file with the last good STH.
+func (m *monitor) saveState() error {
+	return writeSTHState(m.stateFile, m.lc.BaseURI(), m.prev)
+}
+
+// readSTHState returns the STH of the log at logURI kept in the state file
+// at path, or nil if there is no state file yet.
+func readSTHState(path, logURI string) (*ct.SignedTreeHead, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state monitorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if state.Log != logURI {
+		return nil, fmt.Errorf("%s holds the state of log %s, not %s", path, state.Log, logURI)
+	}
+	if state.STH == nil {
+		return nil, nil
+	}
+	sth, err := state.STH.ToSignedTreeHead()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return sth, nil
+}
+
+// writeSTHState atomically replaces the state file at path with sth, the STH
+// of the log at logURI.
+func writeSTHState(path, logURI string, sth *ct.SignedTreeHead) error {
+	rsp, err := sthResponse(sth)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(monitorState{Log: logURI, STH: rsp}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sth-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (m *monitor) emit(ev monitorEvent) {
+	ev.Time = time.Now().UTC()
+	ev.Log = m.lc.BaseURI()
+	if err := m.out.Encode(ev); err != nil {
+		klog.Errorf("Failed to write event: %v", err)
+	}
+}
+
+// sthEvent returns an event about sth, compared with the last good STH.
+func (m *monitor) sthEvent(event, alert string, sth *ct.SignedTreeHead, msg string) monitorEvent {
+	ev := monitorEvent{Event: event, Alert: alert, Message: msg}
+	if sth != nil {
+		ev.TreeSize, ev.Timestamp, ev.RootHash = sth.TreeSize, sth.Timestamp, sth.SHA256RootHash[:]
+	}
+	if m.prev != nil {
+		ev.PrevTreeSize, ev.PrevTimestamp, ev.PrevRootHash = m.prev.TreeSize, m.prev.Timestamp, m.prev.SHA256RootHash[:]
+	}
+	return ev
+}
+
+// poll fetches and checks the log's STH, and emits events about it. The STH
+// becomes the last good one if it is consistent with, and not older than,
+// the previous one.
+func (m *monitor) poll(ctx context.Context) {
+	sth, err := m.lc.VerifiedGetSTH(ctx)
+	var sigErr *client.SignatureError
+	switch {
+	case errors.As(err, &sigErr):
+		m.emit(m.sthEvent("alert", alertBadSignature, sigErr.STH, err.Error()))
+		return
+	case err != nil:
+		m.emit(m.sthEvent("error", "", nil, fmt.Sprintf("failed to get STH: %v", err)))
+		return
+	}
+
+	good := true
+	if m.prev != nil {
+		if sth.TreeSize < m.prev.TreeSize {
+			good = false
+			m.emit(m.sthEvent("alert", alertTreeShrunk, sth, fmt.Sprintf("tree size went down from %d to %d", m.prev.TreeSize, sth.TreeSize)))
+		}
+		if sth.Timestamp < m.prev.Timestamp {
+			good = false
+			m.emit(m.sthEvent("alert", alertTimestampBackwards, sth, fmt.Sprintf("STH timestamp went back from %d to %d", m.prev.Timestamp, sth.Timestamp)))
+		}
+		err := m.lc.VerifyConsistencyBetween(ctx, m.prev, sth)
+		var splitErr *client.SplitViewError
+		var proofErr *client.ProofError
+		switch {
+		case errors.As(err, &splitErr):
+			good = false
+			m.emit(m.sthEvent("alert", alertSplitView, sth, err.Error()))
+		case errors.As(err, &proofErr):
+			good = false
+			m.emit(m.sthEvent("alert", alertInconsistent, sth, err.Error()))
+		case err != nil:
+			// Can't tell whether the log is consistent, so try again later.
+			m.emit(m.sthEvent("error", "", sth, fmt.Sprintf("failed to check consistency: %v", err)))
+			return
+		}
+	}
+
+	age := time.Since(ct.TimestampToTime(sth.Timestamp))
+	if age > m.mmd {
+		ev := m.sthEvent("alert", alertMMDViolation, sth, fmt.Sprintf("STH is %s old, more than the MMD of %s", age.Round(time.Second), m.mmd))
+		ev.STHAge = age.Seconds()
+		m.emit(ev)
+	}
+	if !good {
+		return
+	}
+
+	ev := m.sthEvent("sth", "", sth, "")
+	ev.STHAge = age.Seconds()
+	m.emit(ev)
+	m.prev = sth
+	if err := m.saveState(); err != nil {
+		klog.Errorf("Failed to save state to %s: %v", m.stateFile, err)
+	}
+}
+
+// sthResponse returns sth in the format of a get-sth response.
+func sthResponse(sth *ct.SignedTreeHead) (*ct.GetSTHResponse, error) {
+	sig, err := tls.Marshal(sth.TreeHeadSignature)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetSTHResponse{
+		TreeSize:          sth.TreeSize,
+		Timestamp:         sth.Timestamp,
+		SHA256RootHash:    sth.SHA256RootHash[:],
+		TreeHeadSignature: sig,
+	}, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pollEvents polls the log once, and returns the events emitted as
+// "event" or "event/alert".
+func pollEvents(ctx context.Context, t *testing.T, m *monitor, out *bytes.Buffer) []string {
+	t.Helper()
+	out.Reset()
+	m.poll(ctx)
+	var got []string
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var ev monitorEvent
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if ev.Alert != "" {
+			got = append(got, ev.Event+"/"+ev.Alert)
+		} else {
+			got = append(got, ev.Event)
+		}
+	}
+	return got
+}
+
+func TestMonitorPoll(t *testing.T) {
+	ctx := context.Background()
+	l := newFakeLog(t, 30)
+	lc := newFakeLogClient(t, l)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	var out bytes.Buffer
+	m := &monitor{lc: lc, stateFile: stateFile, mmd: time.Hour, out: json.NewEncoder(&out)}
+	start := uint64(time.Now().UnixMilli())
+
+	// The polls run in order, each against the state left by the previous
+	// ones.
+	for _, tc := range []struct {
+		desc     string
+		setup    func(l *fakeLog)
+		want     []string
+		wantPrev uint64 // Tree size of the last good STH afterwards.
+	}{
+		{
+			desc:     "first",
+			setup:    func(l *fakeLog) { l.sthSize, l.sthTimestamp = 10, start },
+			want:     []string{"sth"},
+			wantPrev: 10,
+		},
+		{
+			desc:     "grown",
+			setup:    func(l *fakeLog) { l.sthSize, l.sthTimestamp = 20, start+1 },
+			want:     []string{"sth"},
+			wantPrev: 20,
+		},
+		{
+			desc:     "unchanged",
+			setup:    func(l *fakeLog) {},
+			want:     []string{"sth"},
+			wantPrev: 20,
+		},
+		{
+			desc:     "log-error",
+			setup:    func(l *fakeLog) { l.status = http.StatusServiceUnavailable },
+			want:     []string{"error"},
+			wantPrev: 20,
+		},
+		{
+			desc:     "bad-signature",
+			setup:    func(l *fakeLog) { l.status, l.badSignature, l.sthSize = 0, true, 25 },
+			want:     []string{"alert/" + alertBadSignature},
+			wantPrev: 20,
+		},
+		{
+			desc:     "shrunk",
+			setup:    func(l *fakeLog) { l.badSignature, l.sthSize, l.sthTimestamp = false, 15, start+2 },
+			want:     []string{"alert/" + alertTreeShrunk},
+			wantPrev: 20,
+		},
+		{
+			desc:     "timestamp-backwards",
+			setup:    func(l *fakeLog) { l.sthSize, l.sthTimestamp = 25, start },
+			want:     []string{"alert/" + alertTimestampBackwards},
+			wantPrev: 20,
+		},
+		{
+			desc:     "split-view",
+			setup:    func(l *fakeLog) { l.forgeRoot, l.sthSize, l.sthTimestamp = true, 20, start+2 },
+			want:     []string{"alert/" + alertSplitView},
+			wantPrev: 20,
+		},
+		{
+			desc:     "inconsistent",
+			setup:    func(l *fakeLog) { l.forgeRoot, l.badProofs, l.sthSize = false, true, 25 },
+			want:     []string{"alert/" + alertInconsistent},
+			wantPrev: 20,
+		},
+		{
+			desc:     "stale",
+			setup:    func(l *fakeLog) { l.badProofs, l.sthTimestamp = false, start-uint64(2*time.Hour.Milliseconds()) },
+			want:     []string{"alert/" + alertTimestampBackwards, "alert/" + alertMMDViolation},
+			wantPrev: 20,
+		},
+		{
+			desc:     "recovered",
+			setup:    func(l *fakeLog) { l.sthTimestamp = start + 3 },
+			want:     []string{"sth"},
+			wantPrev: 25,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l.update(tc.setup)
+			if got := pollEvents(ctx, t, m, &out); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("poll() emitted %v, want %v", got, tc.want)
+			}
+			if m.prev == nil || m.prev.TreeSize != tc.wantPrev {
+				t.Fatalf("Last good STH is %+v, want tree size %d", m.prev, tc.wantPrev)
+			}
+			// The last good STH survives a restart.
+			sth, err := readSTHState(stateFile, lc.BaseURI())
+			if err != nil {
+				t.Fatalf("readSTHState(): %v", err)
+			}
+			if sth == nil || sth.TreeSize != tc.wantPrev || sth.SHA256RootHash != m.prev.SHA256RootHash {
+				t.Errorf("readSTHState()=%+v, want the last good STH at tree size %d", sth, tc.wantPrev)
+			}
+		})
+	}
+}
+
+func TestMonitorMMDViolation(t *testing.T) {
+	ctx := context.Background()
+	l := newFakeLog(t, 10)
+	l.sthTimestamp = uint64(time.Now().Add(-2 * time.Hour).UnixMilli())
+	var out bytes.Buffer
+	m := &monitor{lc: newFakeLogClient(t, l), stateFile: filepath.Join(t.TempDir(), "state.json"), mmd: time.Hour, out: json.NewEncoder(&out)}
+
+	// An old STH is reported, but still becomes the last good one.
+	want := []string{"alert/" + alertMMDViolation, "sth"}
+	if got := pollEvents(ctx, t, m, &out); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("poll() emitted %v, want %v", got, want)
+	}
+	if m.prev == nil || m.prev.TreeSize != 10 {
+		t.Errorf("Last good STH is %+v, want tree size 10", m.prev)
+	}
+}
+
+func TestReadSTHState(t *testing.T) {
+	l := newFakeLog(t, 10)
+	sth := l.sth(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := writeSTHState(path, "https://log.example.com", sth); err != nil {
+		t.Fatalf("writeSTHState(): %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc    string
+		path    string
+		logURI  string
+		want    bool
+		wantErr string
+	}{
+		{desc: "ok", path: path, logURI: "https://log.example.com", want: true},
+		{desc: "missing", path: filepath.Join(dir, "missing.json"), logURI: "https://log.example.com"},
+		{desc: "other-log", path: path, logURI: "https://other.example.com", wantErr: "holds the state of log"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := readSTHState(tc.path, tc.logURI)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("readSTHState()=%v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readSTHState(): %v", err)
+			}
+			if (got != nil) != tc.want {
+				t.Fatalf("readSTHState()=%+v, want STH: %v", got, tc.want)
+			}
+			if got != nil && (got.TreeSize != sth.TreeSize || got.SHA256RootHash != sth.SHA256RootHash || got.TreeHeadSignature.Signature == nil) {
+				t.Errorf("readSTHState()=%+v, want %+v", got, sth)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/x509"
+	"k8s.io/klog/v2"
+)
+
+// Values of the --output flag.
+const (
+	// Human-readable text, with certificates as text unless --text=false.
+	outputText = "text"
+	// A single JSON object holding the command's result, or its error.
+	outputJSON = "json"
+	// Certificates as PEM and nothing else; commands that don't print
+	// certificates print text.
+	outputPEM = "pem"
+)
+
+// Exit codes of ctclient.
+const (
+	// The command failed, e.g. on a network or file error.
+	exitFailure = 1
+	// The command was given invalid flags or arguments.
+	exitUsage = 2
+	// The log responded with an HTTP error, or a response that couldn't be
+	// parsed.
+	exitLogError = 3
+	// Data served by the log failed verification, e.g. a bad signature or
+	// proof.
+	exitVerifyFailed = 4
+)
+
+var outputFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "Output format: text, json or pem")
+}
+
+// checkOutputFormat exits if --output has an unknown value.
+func checkOutputFormat() {
+	switch outputFormat {
+	case outputText, outputJSON, outputPEM:
+	default:
+		bad := outputFormat
+		outputFormat = outputText
+		exitf(exitUsage, "Unknown --output format %q: want text, json or pem", bad)
+	}
+}
+
+// jsonOutput reports whether the result of the command should be written as
+// JSON.
+func jsonOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		klog.Exitf("Failed to write JSON output: %v", err)
+	}
+}
+
+// showf prints a message that accompanies certificates in the output: to
+// stdout, or to the log for --output=pem so that stdout holds only PEM.
+func showf(format string, args ...interface{}) {
+	if outputFormat == outputPEM {
+		klog.InfoDepth(1, fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// errorOutput is the JSON output of a command that failed.
+type errorOutput struct {
+	Error cmdError `json:"error"`
+}
+
+// cmdError describes why a command failed.
+type cmdError struct {
+	// ExitCode is the exit code of the command.
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
+	// HTTPStatus and HTTPBody are set if the log responded with an error.
+	HTTPStatus int    `json:"http_status,omitempty"`
+	HTTPBody   string `json:"http_body,omitempty"`
+}
+
+// exitf exits with the given code, after reporting the formatted error.
+func exitf(code int, format string, args ...interface{}) {
+	exitWithError(code, fmt.Errorf(format, args...))
+}
+
+// exitWithDetails exits after reporting err, with an exit code and details
+// based on the type of err.
+func exitWithDetails(err error) {
+	exitWithError(exitCodeFor(err), err)
+}
+
+// exitCodeFor returns the exit code for a command that failed with err.
+func exitCodeFor(err error) int {
+	var rspErr client.RspError
+	var sigErr *client.SignatureError
+	var proofErr *client.ProofError
+	var splitErr *client.SplitViewError
+	switch {
+	case errors.As(err, &sigErr), errors.As(err, &proofErr), errors.As(err, &splitErr):
+		return exitVerifyFailed
+	case errors.As(err, &rspErr) && rspErr.StatusCode != 0:
+		return exitLogError
+	}
+	return exitFailure
+}
+
+// newCmdError returns the description of err, which makes the command exit
+// with the given code.
+func newCmdError(code int, err error) cmdError {
+	e := cmdError{ExitCode: code, Message: err.Error()}
+	var rspErr client.RspError
+	if errors.As(err, &rspErr) {
+		e.HTTPStatus = rspErr.StatusCode
+		e.HTTPBody = string(rspErr.Body)
+	}
+	return e
+}
+
+// exitWithError exits with the given code, after reporting err: as JSON on
+// stdout for --output=json, and in the log otherwise.
+func exitWithError(code int, err error) {
+	e := newCmdError(code, err)
+	if jsonOutput() {
+		printJSON(errorOutput{Error: e})
+	} else {
+		if e.HTTPStatus != 0 {
+			klog.Infof("HTTP details: status=%d, body:\n%s", e.HTTPStatus, e.HTTPBody)
+		}
+		klog.ErrorDepth(2, e.Message)
+	}
+	klog.Flush()
+	os.Exit(code)
+}
+
+// certOutput is the JSON form of a certificate.
+type certOutput struct {
+	// DER is the certificate as DER, base64-encoded.
+	DER []byte `json:"der"`
+	// The fields below are unset if the certificate couldn't be parsed.
+	Subject      string     `json:"subject,omitempty"`
+	Issuer       string     `json:"issuer,omitempty"`
+	SerialNumber string     `json:"serial_number,omitempty"`
+	NotBefore    *time.Time `json:"not_before,omitempty"`
+	NotAfter     *time.Time `json:"not_after,omitempty"`
+}
+
+// newCertOutput returns the JSON form of a DER certificate.
+func newCertOutput(der []byte) certOutput {
+	out := certOutput{DER: der}
+	c, err := x509.ParseCertificate(der)
+	if x509.IsFatal(err) || c == nil {
+		return out
+	}
+	out.Subject = c.Subject.String()
+	out.Issuer = c.Issuer.String()
+	if c.SerialNumber != nil {
+		out.SerialNumber = c.SerialNumber.Text(16)
+	}
+	out.NotBefore, out.NotAfter = &c.NotBefore, &c.NotAfter
+	return out
+}
+
+// newCertsOutput returns the JSON form of a list of certificates.
+func newCertsOutput(certs []ct.ASN1Cert) []certOutput {
+	out := make([]certOutput, 0, len(certs))
+	for _, c := range certs {
+		out = append(out, newCertOutput(c.Data))
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/testdata"
+)
+
+func TestCmdErrors(t *testing.T) {
+	ctx := context.Background()
+	getSTH := func(lc *client.LogClient) error {
+		_, err := lc.VerifiedGetSTH(ctx)
+		return err
+	}
+	consistency := func(l *fakeLog) func(*client.LogClient) error {
+		return func(lc *client.LogClient) error {
+			old := l.sth(t)
+			l.update(func(l *fakeLog) { l.sthSize = 20 })
+			return lc.VerifyConsistencyBetween(ctx, old, l.sth(t))
+		}
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		setup      func(l *fakeLog)
+		call       func(l *fakeLog) func(*client.LogClient) error
+		noKey      bool
+		closed     bool
+		wantCode   int
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "http-error",
+			setup:      func(l *fakeLog) { l.status = http.StatusServiceUnavailable },
+			wantCode:   exitLogError,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "fake log failure",
+		},
+		{
+			// The log's error message is not JSON.
+			desc:       "unparseable",
+			setup:      func(l *fakeLog) { l.status = http.StatusOK },
+			wantCode:   exitLogError,
+			wantStatus: http.StatusOK,
+			wantBody:   "fake log failure",
+		},
+		{
+			desc:     "bad-signature",
+			setup:    func(l *fakeLog) { l.badSignature = true },
+			wantCode: exitVerifyFailed,
+		},
+		{
+			desc:     "bad-proof",
+			setup:    func(l *fakeLog) { l.badProofs = true },
+			call:     consistency,
+			wantCode: exitVerifyFailed,
+		},
+		{
+			desc:     "split-view",
+			setup:    func(l *fakeLog) { l.forgeRoot = true },
+			call:     consistency,
+			wantCode: exitVerifyFailed,
+		},
+		{
+			desc:     "no-key",
+			noKey:    true,
+			wantCode: exitFailure,
+		},
+		{
+			desc:   "unreachable",
+			closed: true,
+			call: func(*fakeLog) func(*client.LogClient) error {
+				return func(lc *client.LogClient) error {
+					_, err := lc.GetSTH(ctx)
+					return err
+				}
+			},
+			wantCode: exitFailure,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l := newFakeLog(t, 30)
+			l.sthSize = 10
+			if tc.setup != nil {
+				l.update(tc.setup)
+			}
+			lc := newFakeLogClient(t, l)
+			uri := lc.BaseURI()
+			if tc.closed {
+				ts := httptest.NewServer(l.handler(t))
+				ts.Close()
+				uri = ts.URL
+			}
+			if tc.noKey || tc.closed {
+				var err error
+				if lc, err = client.New(uri, http.DefaultClient, jsonclient.Options{}); err != nil {
+					t.Fatalf("New(): %v", err)
+				}
+			}
+			call := getSTH
+			if tc.call != nil {
+				call = tc.call(l)
+			}
+
+			err := call(lc)
+			if err == nil {
+				t.Fatal("Call succeeded, want error")
+			}
+			if got := exitCodeFor(err); got != tc.wantCode {
+				t.Errorf("exitCodeFor(%v)=%d, want %d", err, got, tc.wantCode)
+			}
+			e := newCmdError(tc.wantCode, err)
+			if e.HTTPStatus != tc.wantStatus || !strings.Contains(e.HTTPBody, tc.wantBody) {
+				t.Errorf("newCmdError()=%+v, want HTTP status %d and body with %q", e, tc.wantStatus, tc.wantBody)
+			}
+
+			// The JSON output holds the details.
+			data, err := json.Marshal(errorOutput{Error: e})
+			if err != nil {
+				t.Fatalf("Marshal(): %v", err)
+			}
+			var out struct {
+				Error map[string]interface{} `json:"error"`
+			}
+			if err := json.Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal(): %v", err)
+			}
+			if got := out.Error["exit_code"]; got != float64(tc.wantCode) {
+				t.Errorf("JSON exit_code=%v, want %d", got, tc.wantCode)
+			}
+			if _, ok := out.Error["http_status"]; ok != (tc.wantStatus != 0) {
+				t.Errorf("JSON has http_status: %v, want %v", ok, tc.wantStatus != 0)
+			}
+		})
+	}
+}
+
+func TestNewCertOutput(t *testing.T) {
+	cert := certsOrDie(t, testdata.CACertPEM)[0]
+	for _, tc := range []struct {
+		desc        string
+		der         []byte
+		wantSubject string
+	}{
+		{desc: "cert", der: cert.Raw, wantSubject: cert.Subject.String()},
+		{desc: "garbage", der: []byte("not a certificate")},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			out := newCertOutput(tc.der)
+			if string(out.DER) != string(tc.der) {
+				t.Errorf("newCertOutput().DER=%x, want %x", out.DER, tc.der)
+			}
+			if out.Subject != tc.wantSubject {
+				t.Errorf("newCertOutput().Subject=%q, want %q", out.Subject, tc.wantSubject)
+			}
+			if parsed := tc.wantSubject != ""; (out.NotBefore != nil) != parsed || (out.NotAfter != nil) != parsed {
+				t.Errorf("newCertOutput() has validity %v-%v, want it set: %v", out.NotBefore, out.NotAfter, parsed)
+			}
+		})
+	}
+}
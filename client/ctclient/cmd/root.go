@@ -62,9 +62,21 @@ func init() {
 var rootCmd = &cobra.Command{
 	Use:   "ctclient",
 	Short: "A command line client for Certificate Transparency logs",
+	Long: `A command line client for Certificate Transparency logs.
+
+With --output=json, commands write their result to stdout as a single JSON
+object, or on failure an object with an "error" field holding the message, the
+exit code, and the HTTP status and body of any error response from the log.
+The monitor command always writes a JSON object per event, one per line.
+With --output=pem, commands that print certificates print only their PEM.
+
+The exit code is 0 on success, 1 on other failures, 2 on invalid flags or
+arguments, 3 if the log responded with an error, and 4 if data served by the log
+failed verification.`,
 
 	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
 		flag.Parse()
+		checkOutputFormat()
 	},
 }
 
@@ -72,7 +84,7 @@ var rootCmd = &cobra.Command{
 // appropriately. It needs to be called exactly once by main().
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		klog.Fatal(err)
+		exitf(exitUsage, "%v", err)
 	}
 }
 
@@ -80,20 +92,15 @@ func signatureToString(signed *ct.DigitallySigned) string {
 	return fmt.Sprintf("Signature: Hash=%v Sign=%v Value=%x", signed.Algorithm.Hash, signed.Algorithm.Signature, signed.Signature)
 }
 
-func exitWithDetails(err error) {
-	if err, ok := err.(client.RspError); ok {
-		klog.Infof("HTTP details: status=%d, body:\n%s", err.StatusCode, err.Body)
-	}
-	klog.Exit(err.Error())
-}
-
-func connect(ctx context.Context) *client.LogClient {
+// newHTTPClient returns the HTTP client used to talk to logs and fetch log
+// lists.
+func newHTTPClient() *http.Client {
 	var tlsCfg *tls.Config
 	if skipHTTPSVerify {
 		klog.Warning("Skipping HTTPS connection verification")
 		tlsCfg = &tls.Config{InsecureSkipVerify: skipHTTPSVerify}
 	}
-	httpClient := &http.Client{
+	return &http.Client{
 		Timeout: 10 * time.Second,
 		Transport: &http.Transport{
 			TLSHandshakeTimeout:   30 * time.Second,
@@ -106,11 +113,15 @@ func connect(ctx context.Context) *client.LogClient {
 			TLSClientConfig:       tlsCfg,
 		},
 	}
+}
+
+func connect(ctx context.Context) *client.LogClient {
+	httpClient := newHTTPClient()
 	opts := jsonclient.Options{UserAgent: "ct-go-ctclient/1.0"}
 	if pubKey != "" {
 		pubkey, err := os.ReadFile(pubKey)
 		if err != nil {
-			klog.Exit(err)
+			exitf(exitFailure, "Failed to read public key: %v", err)
 		}
 		opts.PublicKey = string(pubkey)
 	}
@@ -119,23 +130,23 @@ func connect(ctx context.Context) *client.LogClient {
 	if logName != "" {
 		llData, err := x509util.ReadFileOrURL(logList, httpClient)
 		if err != nil {
-			klog.Exitf("Failed to read log list: %v", err)
+			exitf(exitFailure, "Failed to read log list: %v", err)
 		}
 		ll, err := loglist3.NewFromJSON(llData)
 		if err != nil {
-			klog.Exitf("Failed to build log list: %v", err)
+			exitf(exitFailure, "Failed to build log list: %v", err)
 		}
 
 		logs := ll.FindLogByName(logName)
 		if len(logs) == 0 {
-			klog.Exitf("No log with name like %q found in loglist %q", logName, logList)
+			exitf(exitUsage, "No log with name like %q found in loglist %q", logName, logList)
 		}
 		if len(logs) > 1 {
 			logNames := make([]string, len(logs))
 			for i, log := range logs {
 				logNames[i] = fmt.Sprintf("%q", log.Description)
 			}
-			klog.Exitf("Multiple logs with name like %q found in loglist: %s", logName, strings.Join(logNames, ","))
+			exitf(exitUsage, "Multiple logs with name like %q found in loglist: %s", logName, strings.Join(logNames, ","))
 		}
 		uri = logs[0].URL
 		if opts.PublicKey == "" {
@@ -146,7 +157,7 @@ func connect(ctx context.Context) *client.LogClient {
 	klog.V(1).Infof("Use CT log at %s", uri)
 	logClient, err := client.New(uri, httpClient, opts)
 	if err != nil {
-		klog.Exit(err)
+		exitf(exitUsage, "Failed to create client for log at %s: %v", uri, err)
 	}
 
 	return logClient
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/certificate-transparency-go/client/archive"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	serveArchiveDir    string
+	serveArchiveListen string
+)
+
+func init() {
+	cmd := cobra.Command{
+		Use:   "serve-archive --archive=dir [--listen=addr]",
+		Short: "Serve an archive made by download through the RFC 6962 read API",
+		Long: `Serves the entries of an archive made by the download command through the
+read-only endpoints of the RFC 6962 API, using the archive's STH as the log's
+STH, e.g. for testing tools against a log offline. Proofs are only available if
+the archive starts at index 0.`,
+		Args: cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			runServeArchive(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVar(&serveArchiveDir, "archive", "", "Directory holding the archive")
+	cmd.Flags().StringVar(&serveArchiveListen, "listen", "localhost:6962", "Address to serve on")
+	rootCmd.AddCommand(&cmd)
+}
+
+// runServeArchive runs the serve-archive command.
+func runServeArchive(ctx context.Context) {
+	if serveArchiveDir == "" {
+		exitf(exitUsage, "No --archive directory supplied")
+	}
+	a, err := archive.Open(serveArchiveDir)
+	if err != nil {
+		exitf(exitFailure, "Failed to open archive: %v", err)
+	}
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	srv := &http.Server{Addr: serveArchiveListen, Handler: archive.NewServer(a)}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			klog.Warningf("Failed to shut down server: %v", err)
+		}
+	}()
+	m := a.Manifest()
+	klog.Infof("Serving entries [%d, %d) of %s at http://%s", m.Start, m.End, m.LogURI, serveArchiveListen)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		exitf(exitFailure, "Server failed: %v", err)
+	}
+}
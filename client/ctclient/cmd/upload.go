@@ -16,14 +16,15 @@ package cmd
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"time"
 
 	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
 	"github.com/google/certificate-transparency-go/x509"
 	"github.com/google/certificate-transparency-go/x509util"
 	"github.com/spf13/cobra"
-	"k8s.io/klog/v2"
 )
 
 var logMMD time.Duration
@@ -48,7 +49,7 @@ func init() {
 func runUpload(ctx context.Context) {
 	logClient := connect(ctx)
 	if certChain == "" {
-		klog.Exitf("No certificate chain file specified with -cert_chain")
+		exitf(exitUsage, "No certificate chain file specified with -cert_chain")
 	}
 	chain, _ := chainFromFile(certChain)
 
@@ -59,7 +60,9 @@ func runUpload(ctx context.Context) {
 		count, _ := x509util.OIDInExtensions(x509.OIDExtensionCTPoison, leaf.Extensions)
 		if count > 0 {
 			isPrecert = true
-			fmt.Print("Uploading pre-certificate to log\n")
+			if !jsonOutput() {
+				fmt.Print("Uploading pre-certificate to log\n")
+			}
 		}
 	}
 
@@ -76,19 +79,57 @@ func runUpload(ctx context.Context) {
 	leafEntry := ct.CreateX509MerkleTreeLeaf(chain[0], sct.Timestamp)
 	leafHash, err := ct.LeafHashForLeaf(leafEntry)
 	if err != nil {
-		klog.Exitf("Failed to create hash of leaf: %v", err)
+		exitf(exitFailure, "Failed to create hash of leaf: %v", err)
 	}
 
-	// Display the SCT.
 	when := ct.TimestampToTime(sct.Timestamp)
+	// If the SCT's timestamp is old enough, the certificate should be included.
+	mmdPassed := time.Since(when) > logMMD
+	if jsonOutput() {
+		sig, err := tls.Marshal(sct.Signature)
+		if err != nil {
+			exitf(exitFailure, "Failed to encode SCT signature: %v", err)
+		}
+		out := uploadOutput{
+			LogURI:         logClient.BaseURI(),
+			Precertificate: isPrecert,
+			ChainLength:    len(chain),
+			SCT: ct.AddChainResponse{
+				SCTVersion: sct.SCTVersion,
+				ID:         sct.LogID.KeyID[:],
+				Timestamp:  sct.Timestamp,
+				Extensions: base64.StdEncoding.EncodeToString(sct.Extensions),
+				Signature:  sig,
+			},
+			LeafHash: leafHash[:],
+		}
+		if mmdPassed {
+			out.Inclusion = getInclusionProofForHash(ctx, logClient, leafHash[:])
+		}
+		printJSON(out)
+		return
+	}
+
+	// Display the SCT.
 	fmt.Printf("Uploaded chain of %d certs to %v log at %v, timestamp: %d (%v)\n", len(chain), sct.SCTVersion, logClient.BaseURI(), sct.Timestamp, when)
 	fmt.Printf("LogID: %x\n", sct.LogID.KeyID[:])
 	fmt.Printf("LeafHash: %x\n", leafHash)
 	fmt.Printf("Signature: %v\n", signatureToString(&sct.Signature))
 
-	age := time.Since(when)
-	if age > logMMD {
-		// SCT's timestamp is old enough that the certificate should be included.
-		getInclusionProofForHash(ctx, logClient, leafHash[:])
+	if mmdPassed {
+		showInclusionProof(getInclusionProofForHash(ctx, logClient, leafHash[:]))
 	}
 }
+
+// uploadOutput is the JSON output of upload.
+type uploadOutput struct {
+	LogURI         string `json:"log_uri"`
+	Precertificate bool   `json:"precertificate"`
+	ChainLength    int    `json:"chain_length"`
+	// SCT is the SCT as in the log's add-chain response.
+	SCT      ct.AddChainResponse `json:"sct"`
+	LeafHash []byte              `json:"leaf_hash"`
+	// Inclusion is the inclusion proof of the leaf, which is only fetched
+	// once the log's MMD has passed since the SCT's timestamp.
+	Inclusion *inclusionOutput `json:"inclusion,omitempty"`
+}
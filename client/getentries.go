@@ -38,7 +38,7 @@ func (c *LogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.Ge
 	}
 
 	var resp ct.GetEntriesResponse
-	if _, _, err := c.GetAndParse(ctx, ct.GetEntriesPath, params, &resp); err != nil {
+	if err := c.getAndParseImmutable(ctx, ct.GetEntriesPath, params, &resp); err != nil {
 		return nil, err
 	}
 
@@ -32,6 +32,11 @@ import (
 // LogClient represents a client for a given CT Log instance
 type LogClient struct {
 	jsonclient.JSONClient
+	// Cache, if set, is consulted for, and stores, the responses to requests
+	// whose results never change: get-entries, get-sth-consistency,
+	// get-proof-by-hash and get-entry-and-proof. Responses are cached before
+	// they are verified.
+	Cache ResponseCache
 }
 
 // CheckLogClient is an interface that allows (just) checking of various log contents.
@@ -53,7 +58,7 @@ func New(uri string, hc *http.Client, opts jsonclient.Options) (*LogClient, erro
 	if err != nil {
 		return nil, err
 	}
-	return &LogClient{*logClient}, err
+	return &LogClient{JSONClient: *logClient}, err
 }
 
 // RspError represents a server error including HTTP information.
@@ -123,21 +128,30 @@ func (c *LogClient) AddPreChain(ctx context.Context, chain []ct.ASN1Cert) (*ct.S
 // Returns a populated SignedTreeHead, or a non-nil error (which may be of type
 // RspError if a raw http.Response is available).
 func (c *LogClient) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
-	var resp ct.GetSTHResponse
-	httpRsp, body, err := c.GetAndParse(ctx, ct.GetSTHPath, nil, &resp)
+	sth, httpRsp, body, err := c.getUnverifiedSTH(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.VerifySTHSignature(*sth); err != nil {
+		return nil, RspError{Err: err, StatusCode: httpRsp.StatusCode, Body: body}
+	}
+	return sth, nil
+}
 
-	sth, err := resp.ToSignedTreeHead()
+// getUnverifiedSTH retrieves the current STH from the log, without checking
+// its signature.
+func (c *LogClient) getUnverifiedSTH(ctx context.Context) (*ct.SignedTreeHead, *http.Response, []byte, error) {
+	var resp ct.GetSTHResponse
+	httpRsp, body, err := c.GetAndParse(ctx, ct.GetSTHPath, nil, &resp)
 	if err != nil {
-		return nil, RspError{Err: err, StatusCode: httpRsp.StatusCode, Body: body}
+		return nil, nil, nil, err
 	}
 
-	if err := c.VerifySTHSignature(*sth); err != nil {
-		return nil, RspError{Err: err, StatusCode: httpRsp.StatusCode, Body: body}
+	sth, err := resp.ToSignedTreeHead()
+	if err != nil {
+		return nil, nil, nil, RspError{Err: err, StatusCode: httpRsp.StatusCode, Body: body}
 	}
-	return sth, nil
+	return sth, httpRsp, body, nil
 }
 
 // VerifySTHSignature checks the signature in sth, returning any error encountered or nil if verification is
@@ -172,7 +186,7 @@ func (c *LogClient) GetSTHConsistency(ctx context.Context, first, second uint64)
 		"second": strconv.FormatUint(second, base10),
 	}
 	var resp ct.GetSTHConsistencyResponse
-	if _, _, err := c.GetAndParse(ctx, ct.GetSTHConsistencyPath, params, &resp); err != nil {
+	if err := c.getAndParseImmutable(ctx, ct.GetSTHConsistencyPath, params, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Consistency, nil
@@ -187,7 +201,7 @@ func (c *LogClient) GetProofByHash(ctx context.Context, hash []byte, treeSize ui
 		"hash":      b64Hash,
 	}
 	var resp ct.GetProofByHashResponse
-	if _, _, err := c.GetAndParse(ctx, ct.GetProofByHashPath, params, &resp); err != nil {
+	if err := c.getAndParseImmutable(ctx, ct.GetProofByHashPath, params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -219,7 +233,7 @@ func (c *LogClient) GetEntryAndProof(ctx context.Context, index, treeSize uint64
 		"tree_size":  strconv.FormatUint(treeSize, base10),
 	}
 	var resp ct.GetEntryAndProofResponse
-	if _, _, err := c.GetAndParse(ctx, ct.GetEntryAndProofPath, params, &resp); err != nil {
+	if err := c.getAndParseImmutable(ctx, ct.GetEntryAndProofPath, params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
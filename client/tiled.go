@@ -0,0 +1,538 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// tileWidth is the number of entries or hashes in a full tile.
+	tileWidth = 256
+	// tileHeight is the number of Merkle tree levels covered by a hash tile.
+	tileHeight = 8
+	// dataLevel is the level used to refer to data tiles.
+	dataLevel = -1
+	// rfc6962NoteSignatureType is the signed note signature type of checkpoint
+	// signatures holding an RFC 6962 TreeHeadSignature.
+	rfc6962NoteSignatureType = 0x05
+	// maxLeafIndexSize is the number of recently fetched leaf hashes for which
+	// GetProofByHash can find the leaf index.
+	maxLeafIndexSize = 1 << 16
+)
+
+// TiledLogClient is a client for CT logs which publish through the Static CT
+// API (https://c2sp.org/static-ct-api) as a checkpoint and tiles, rather than
+// through the RFC 6962 JSON API. It implements CheckLogClient, as well as the
+// scanner.LogClient interface, by converting the checkpoint into an STH and
+// computing entries and proofs from tiles.
+//
+// As tiled logs can't look up leaves by hash, GetProofByHash only works for
+// leaves recently returned by GetRawEntries; GetProofByIndex has no such
+// restriction.
+type TiledLogClient struct {
+	uri       string // The monitoring prefix, without a trailing slash.
+	hc        *http.Client
+	userAgent string
+	verifier  *ct.SignatureVerifier // Nil if there's no public key.
+	logID     [sha256.Size]byte     // Only set if there's a public key.
+
+	mu        sync.Mutex
+	treeSize  uint64 // The size of the latest checkpoint seen.
+	issuers   map[[sha256.Size]byte][]byte
+	leafIndex map[[sha256.Size]byte]uint64
+	leafRing  [][sha256.Size]byte // Hashes in leafIndex, in insertion order.
+	leafNext  int                 // The next position in leafRing to replace.
+}
+
+// NewTiledLogClient returns a TiledLogClient for the log with the given
+// monitoring prefix, e.g. https://example.com/2025h1. If opts has a public
+// key, checkpoint signatures are verified with it.
+func NewTiledLogClient(uri string, hc *http.Client, opts jsonclient.Options) (*TiledLogClient, error) {
+	if hc == nil {
+		hc = &http.Client{}
+	}
+	c := &TiledLogClient{
+		uri:       strings.TrimRight(uri, "/"),
+		hc:        hc,
+		userAgent: opts.UserAgent,
+		issuers:   make(map[[sha256.Size]byte][]byte),
+		leafIndex: make(map[[sha256.Size]byte]uint64),
+	}
+	pubkey, err := opts.ParsePublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	if pubkey != nil {
+		if c.verifier, err = ct.NewSignatureVerifier(pubkey); err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKIXPublicKey(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		c.logID = sha256.Sum256(der)
+	}
+	return c, nil
+}
+
+// BaseURI returns the monitoring prefix of the log.
+func (c *TiledLogClient) BaseURI() string {
+	return c.uri
+}
+
+// fetch GETs the resource at path below the monitoring prefix. Returns an
+// error of type RspError if the HTTP response was available.
+func (c *TiledLogClient) fetch(ctx context.Context, path string) ([]byte, error) {
+	fullURI := c.uri + "/" + path
+	klog.V(2).Infof("GET %s", fullURI)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.userAgent) != 0 {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	rsp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, RspError{Err: err, StatusCode: rsp.StatusCode, Body: body}
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, RspError{Err: fmt.Errorf("got HTTP Status %q", rsp.Status), StatusCode: rsp.StatusCode, Body: body}
+	}
+	return body, nil
+}
+
+// GetSTH fetches the log's checkpoint, and returns it as an STH, verifying
+// its signature if there is a public key.
+func (c *TiledLogClient) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	body, err := c.fetch(ctx, "checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	sth, err := c.parseCheckpoint(body)
+	if err != nil {
+		return nil, RspError{Err: err, StatusCode: http.StatusOK, Body: body}
+	}
+	if c.verifier != nil {
+		if err := c.verifier.VerifySTHSignature(*sth); err != nil {
+			return nil, RspError{Err: err, StatusCode: http.StatusOK, Body: body}
+		}
+	}
+	c.mu.Lock()
+	if sth.TreeSize > c.treeSize {
+		c.treeSize = sth.TreeSize
+	}
+	c.mu.Unlock()
+	return sth, nil
+}
+
+// parseCheckpoint parses a checkpoint signed note, and returns it as an STH
+// using its RFC 6962 signature.
+func (c *TiledLogClient) parseCheckpoint(data []byte) (*ct.SignedTreeHead, error) {
+	text, sigs, ok := strings.Cut(string(data), "\n\n")
+	if !ok {
+		return nil, errors.New("malformed checkpoint: no signatures")
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) < 3 {
+		return nil, errors.New("malformed checkpoint: too few lines")
+	}
+	origin := lines[0]
+	size, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed checkpoint tree size %q", lines[1])
+	}
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(root) != sha256.Size {
+		return nil, fmt.Errorf("malformed checkpoint root hash %q", lines[2])
+	}
+
+	var keyID []byte
+	if c.verifier != nil {
+		h := sha256.Sum256(append([]byte(origin+"\n"), append([]byte{rfc6962NoteSignatureType}, c.logID[:]...)...))
+		keyID = h[:4]
+	}
+	for _, line := range strings.Split(sigs, "\n") {
+		line, ok := strings.CutPrefix(line, "— ")
+		if !ok {
+			continue
+		}
+		name, sig, ok := strings.Cut(line, " ")
+		if !ok || name != origin {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil || len(raw) < 4+8 {
+			continue
+		}
+		if keyID != nil && !bytes.Equal(raw[:4], keyID) {
+			continue
+		}
+		var ds ct.DigitallySigned
+		if rest, err := tls.Unmarshal(raw[12:], &ds); err != nil || len(rest) > 0 {
+			// Probably a signature of another type.
+			continue
+		}
+		sth := &ct.SignedTreeHead{
+			Version:           ct.V1,
+			TreeSize:          size,
+			Timestamp:         binary.BigEndian.Uint64(raw[4:12]),
+			TreeHeadSignature: ds,
+			LogID:             c.logID,
+		}
+		copy(sth.SHA256RootHash[:], root)
+		return sth, nil
+	}
+	return nil, fmt.Errorf("no RFC 6962 signature from %q in checkpoint", origin)
+}
+
+// tilePath returns the path of tile n at the given level, with the given
+// width, e.g. tile/0/x001/x234/067.p/8 or tile/data/000.
+func tilePath(level int, n, width uint64) string {
+	nStr := fmt.Sprintf("%03d", n%1000)
+	for n >= 1000 {
+		n /= 1000
+		nStr = fmt.Sprintf("x%03d/%s", n%1000, nStr)
+	}
+	levelStr := "data"
+	if level != dataLevel {
+		levelStr = strconv.Itoa(level)
+	}
+	path := fmt.Sprintf("tile/%s/%s", levelStr, nStr)
+	if width < tileWidth {
+		path += fmt.Sprintf(".p/%d", width)
+	}
+	return path
+}
+
+// tileWidthAt returns the width of tile n at the given level in a tree of the
+// given size, or 0 if the tile doesn't exist.
+func tileWidthAt(level int, n, size uint64) uint64 {
+	count := size
+	if level != dataLevel {
+		count = size >> (tileHeight * uint(level))
+	}
+	switch {
+	case count <= n*tileWidth:
+		return 0
+	case count-n*tileWidth >= tileWidth:
+		return tileWidth
+	}
+	return count - n*tileWidth
+}
+
+// tileKey identifies a tile.
+type tileKey struct {
+	level int
+	n     uint64
+}
+
+// tile fetches tile n at the given level, which must be at least minWidth
+// wide. The width is derived from the latest checkpoint, which is refreshed
+// if it's too small, or if the log has grown and replaced the partial tile.
+// Tiles are cached in cache if it's not nil.
+func (c *TiledLogClient) tile(ctx context.Context, level int, n, minWidth uint64, cache map[tileKey][]byte) ([]byte, error) {
+	key := tileKey{level: level, n: n}
+	if data, ok := cache[key]; ok {
+		return data, nil
+	}
+	var size uint64
+	c.mu.Lock()
+	size = c.treeSize
+	c.mu.Unlock()
+	for refreshed := false; ; refreshed = true {
+		width := tileWidthAt(level, n, size)
+		if width < minWidth {
+			if refreshed {
+				return nil, fmt.Errorf("tile %s is beyond tree size %d", tilePath(level, n, minWidth), size)
+			}
+			sth, err := c.GetSTH(ctx)
+			if err != nil {
+				return nil, err
+			}
+			size = sth.TreeSize
+			continue
+		}
+		data, err := c.fetch(ctx, tilePath(level, n, width))
+		if rspErr, ok := err.(RspError); ok && rspErr.StatusCode == http.StatusNotFound && width < tileWidth && !refreshed {
+			sth, err := c.GetSTH(ctx)
+			if err != nil {
+				return nil, err
+			}
+			size = sth.TreeSize
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if level != dataLevel && uint64(len(data)) != width*sha256.Size {
+			return nil, fmt.Errorf("hash tile %s has %d bytes, want %d", tilePath(level, n, width), len(data), width*sha256.Size)
+		}
+		if cache != nil {
+			cache[key] = data
+		}
+		return data, nil
+	}
+}
+
+// tileLeaf is an entry of a data tile.
+type tileLeaf struct {
+	entry          ct.TimestampedEntry
+	preCertificate ct.ASN1Cert // Only for precertificate entries.
+	fingerprints   [][sha256.Size]byte
+}
+
+// parseDataTile parses the entries of a data tile.
+func parseDataTile(data []byte) ([]tileLeaf, error) {
+	var leaves []tileLeaf
+	for len(data) > 0 {
+		var leaf tileLeaf
+		rest, err := tls.Unmarshal(data, &leaf.entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TimestampedEntry %d: %v", len(leaves), err)
+		}
+		if leaf.entry.EntryType == ct.PrecertLogEntryType {
+			if rest, err = tls.Unmarshal(rest, &leaf.preCertificate); err != nil {
+				return nil, fmt.Errorf("failed to parse pre_certificate %d: %v", len(leaves), err)
+			}
+		}
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("truncated certificate_chain %d", len(leaves))
+		}
+		n := int(binary.BigEndian.Uint16(rest))
+		if rest = rest[2:]; n%sha256.Size != 0 || len(rest) < n {
+			return nil, fmt.Errorf("malformed certificate_chain %d", len(leaves))
+		}
+		for i := 0; i < n; i += sha256.Size {
+			var fp [sha256.Size]byte
+			copy(fp[:], rest[i:])
+			leaf.fingerprints = append(leaf.fingerprints, fp)
+		}
+		data = rest[n:]
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// issuer returns the issuer certificate with the given fingerprint.
+func (c *TiledLogClient) issuer(ctx context.Context, fp [sha256.Size]byte) ([]byte, error) {
+	c.mu.Lock()
+	cert, ok := c.issuers[fp]
+	c.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+	cert, err := c.fetch(ctx, "issuer/"+hex.EncodeToString(fp[:]))
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(cert) != fp {
+		return nil, fmt.Errorf("issuer %x has a different fingerprint", fp)
+	}
+	c.mu.Lock()
+	c.issuers[fp] = cert
+	c.mu.Unlock()
+	return cert, nil
+}
+
+// toLeafEntry converts a data tile entry into an RFC 6962 get-entries entry.
+func (c *TiledLogClient) toLeafEntry(ctx context.Context, leaf *tileLeaf) (ct.LeafEntry, error) {
+	leafInput, err := tls.Marshal(ct.MerkleTreeLeaf{
+		Version:          ct.V1,
+		LeafType:         ct.TimestampedEntryLeafType,
+		TimestampedEntry: &leaf.entry,
+	})
+	if err != nil {
+		return ct.LeafEntry{}, err
+	}
+	chain := make([]ct.ASN1Cert, len(leaf.fingerprints))
+	for i, fp := range leaf.fingerprints {
+		cert, err := c.issuer(ctx, fp)
+		if err != nil {
+			return ct.LeafEntry{}, fmt.Errorf("failed to get issuer %x: %w", fp, err)
+		}
+		chain[i] = ct.ASN1Cert{Data: cert}
+	}
+	var extraData []byte
+	if leaf.entry.EntryType == ct.PrecertLogEntryType {
+		extraData, err = tls.Marshal(ct.PrecertChainEntry{PreCertificate: leaf.preCertificate, CertificateChain: chain})
+	} else {
+		extraData, err = tls.Marshal(ct.CertificateChain{Entries: chain})
+	}
+	if err != nil {
+		return ct.LeafEntry{}, err
+	}
+	return ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}, nil
+}
+
+// GetRawEntries returns entries of the log, starting at start and ending at
+// end (inclusive), or earlier at the end of the data tile holding start.
+func (c *TiledLogClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid entry range [%d, %d]", start, end)
+	}
+	n := uint64(start) / tileWidth
+	first := uint64(start) % tileWidth
+	data, err := c.tile(ctx, dataLevel, n, first+1, nil)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := parseDataTile(data)
+	if err != nil {
+		return nil, fmt.Errorf("data tile %d: %v", n, err)
+	}
+	last := uint64(end) - n*tileWidth
+	if last >= uint64(len(leaves)) {
+		last = uint64(len(leaves)) - 1
+	}
+	if first > last {
+		return nil, fmt.Errorf("data tile %d has only %d entries", n, len(leaves))
+	}
+
+	rsp := &ct.GetEntriesResponse{Entries: make([]ct.LeafEntry, 0, last-first+1)}
+	for i := first; i <= last; i++ {
+		entry, err := c.toLeafEntry(ctx, &leaves[i])
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", n*tileWidth+i, err)
+		}
+		rsp.Entries = append(rsp.Entries, entry)
+		c.addLeafIndex(entry.LeafInput, n*tileWidth+i)
+	}
+	return rsp, nil
+}
+
+// addLeafIndex remembers the index of a leaf for GetProofByHash.
+func (c *TiledLogClient) addLeafIndex(leafInput []byte, index uint64) {
+	var hash [sha256.Size]byte
+	copy(hash[:], rfc6962.DefaultHasher.HashLeaf(leafInput))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.leafIndex[hash]; ok {
+		return
+	}
+	if len(c.leafRing) < maxLeafIndexSize {
+		c.leafRing = append(c.leafRing, hash)
+	} else {
+		delete(c.leafIndex, c.leafRing[c.leafNext])
+		c.leafRing[c.leafNext] = hash
+		c.leafNext = (c.leafNext + 1) % maxLeafIndexSize
+	}
+	c.leafIndex[hash] = index
+}
+
+// nodeHash returns the hash of a complete node of the Merkle tree, computing
+// it from the hashes of the lowest level of its hash tile if needed.
+func (c *TiledLogClient) nodeHash(ctx context.Context, id compact.NodeID, cache map[tileKey][]byte) ([]byte, error) {
+	level := int(id.Level / tileHeight)
+	count := uint64(1) << (id.Level % tileHeight)
+	first := id.Index * count
+	n, offset := first/tileWidth, first%tileWidth
+	data, err := c.tile(ctx, level, n, offset+count, cache)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, count)
+	for i := range hashes {
+		pos := (offset + uint64(i)) * sha256.Size
+		hashes[i] = data[pos : pos+sha256.Size]
+	}
+	for len(hashes) > 1 {
+		for i := 0; i < len(hashes)/2; i++ {
+			hashes[i] = rfc6962.DefaultHasher.HashChildren(hashes[2*i], hashes[2*i+1])
+		}
+		hashes = hashes[:len(hashes)/2]
+	}
+	return hashes[0], nil
+}
+
+// proofHashes fetches the hashes for the given proof nodes.
+func (c *TiledLogClient) proofHashes(ctx context.Context, nodes proof.Nodes) ([][]byte, error) {
+	cache := make(map[tileKey][]byte)
+	hashes := make([][]byte, len(nodes.IDs))
+	for i, id := range nodes.IDs {
+		hash, err := c.nodeHash(ctx, id, cache)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	return nodes.Rehash(hashes, rfc6962.DefaultHasher.HashChildren)
+}
+
+// GetSTHConsistency returns the consistency proof between two tree sizes,
+// computed from hash tiles.
+func (c *TiledLogClient) GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error) {
+	nodes, err := proof.Consistency(first, second)
+	if err != nil {
+		return nil, err
+	}
+	return c.proofHashes(ctx, nodes)
+}
+
+// GetProofByIndex returns the inclusion proof for the leaf with the given
+// index in the tree of the given size, computed from hash tiles.
+func (c *TiledLogClient) GetProofByIndex(ctx context.Context, index, treeSize uint64) (*ct.GetProofByHashResponse, error) {
+	nodes, err := proof.Inclusion(index, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	path, err := c.proofHashes(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetProofByHashResponse{LeafIndex: int64(index), AuditPath: path}, nil
+}
+
+// GetProofByHash returns the inclusion proof for the leaf with the given
+// hash in the tree of the given size. Only works for leaves recently returned
+// by GetRawEntries, as tiled logs have no index of leaf hashes.
+func (c *TiledLogClient) GetProofByHash(ctx context.Context, hash []byte, treeSize uint64) (*ct.GetProofByHashResponse, error) {
+	var key [sha256.Size]byte
+	copy(key[:], hash)
+	c.mu.Lock()
+	index, ok := c.leafIndex[key]
+	c.mu.Unlock()
+	if !ok || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("leaf hash %x not recently fetched; tiled logs can't look up leaves by hash, use GetProofByIndex", hash)
+	}
+	return c.GetProofByIndex(ctx, index, treeSize)
+}
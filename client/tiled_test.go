@@ -0,0 +1,360 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/testdata"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+const tiledOrigin = "example.com/tiled"
+
+// tiledLog is a static tiled log served from memory.
+type tiledLog struct {
+	files      map[string][]byte
+	leafInputs [][]byte
+	leafHashes [][]byte
+	extraData  [][]byte
+	key        *ecdsa.PrivateKey
+	keyDER     []byte
+}
+
+func mustPEMDecode(t *testing.T, data string) []byte {
+	t.Helper()
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		t.Fatal("failed to decode PEM")
+	}
+	return block.Bytes
+}
+
+// newTiledLog creates a tiled log with size entries, alternating between
+// certificates and precertificates issued by the same CA.
+func newTiledLog(t *testing.T, size int) *tiledLog {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey(): %v", err)
+	}
+	l := &tiledLog{files: make(map[string][]byte), key: key, keyDER: keyDER}
+
+	issuer := mustPEMDecode(t, testdata.CACertPEM)
+	fp := sha256.Sum256(issuer)
+	l.files["issuer/"+hex.EncodeToString(fp[:])] = issuer
+	cert := mustPEMDecode(t, testdata.TestCertPEM)
+	precert := mustPEMDecode(t, testdata.TestPreCertPEM)
+
+	var dataTile []byte
+	for i := 0; i < size; i++ {
+		te := ct.TimestampedEntry{Timestamp: uint64(1000 + i), EntryType: ct.X509LogEntryType}
+		if i%2 == 0 {
+			te.X509Entry = &ct.ASN1Cert{Data: cert}
+		} else {
+			te.EntryType = ct.PrecertLogEntryType
+			te.PrecertEntry = &ct.PreCert{IssuerKeyHash: fp, TBSCertificate: []byte(fmt.Sprintf("tbs-%d", i))}
+		}
+		data, err := tls.Marshal(te)
+		if err != nil {
+			t.Fatalf("Marshal(TimestampedEntry): %v", err)
+		}
+		chain := []ct.ASN1Cert{{Data: issuer}}
+		var extra []byte
+		if te.EntryType == ct.PrecertLogEntryType {
+			pc, err := tls.Marshal(ct.ASN1Cert{Data: precert})
+			if err != nil {
+				t.Fatalf("Marshal(ASN1Cert): %v", err)
+			}
+			data = append(data, pc...)
+			extra, err = tls.Marshal(ct.PrecertChainEntry{PreCertificate: ct.ASN1Cert{Data: precert}, CertificateChain: chain})
+			if err != nil {
+				t.Fatalf("Marshal(PrecertChainEntry): %v", err)
+			}
+		} else {
+			extra, err = tls.Marshal(ct.CertificateChain{Entries: chain})
+			if err != nil {
+				t.Fatalf("Marshal(CertificateChain): %v", err)
+			}
+		}
+		data = binary.BigEndian.AppendUint16(data, sha256.Size)
+		data = append(data, fp[:]...)
+		dataTile = append(dataTile, data...)
+
+		leafInput, err := tls.Marshal(ct.MerkleTreeLeaf{Version: ct.V1, LeafType: ct.TimestampedEntryLeafType, TimestampedEntry: &te})
+		if err != nil {
+			t.Fatalf("Marshal(MerkleTreeLeaf): %v", err)
+		}
+		l.leafInputs = append(l.leafInputs, leafInput)
+		l.leafHashes = append(l.leafHashes, rfc6962.DefaultHasher.HashLeaf(leafInput))
+		l.extraData = append(l.extraData, extra)
+
+		if (i+1)%256 == 0 || i == size-1 {
+			l.files[testTilePath("data", i/256, i%256+1)] = dataTile
+			dataTile = nil
+		}
+	}
+
+	for level, hashes := 0, l.leafHashes; len(hashes) > 0; level++ {
+		for n := 0; n*256 < len(hashes); n++ {
+			end := n*256 + 256
+			if end > len(hashes) {
+				end = len(hashes)
+			}
+			l.files[testTilePath(fmt.Sprint(level), n, end-n*256)] = bytes.Join(hashes[n*256:end], nil)
+		}
+		var next [][]byte
+		for i := 0; i+256 <= len(hashes); i += 256 {
+			next = append(next, subtreeRoot(hashes[i:i+256]))
+		}
+		hashes = next
+	}
+	return l
+}
+
+func testTilePath(level string, n, width int) string {
+	path := fmt.Sprintf("tile/%s/%03d", level, n)
+	if width < 256 {
+		path += fmt.Sprintf(".p/%d", width)
+	}
+	return path
+}
+
+func subtreeRoot(hashes [][]byte) []byte {
+	for len(hashes) > 1 {
+		var next [][]byte
+		for i := 0; i < len(hashes); i += 2 {
+			next = append(next, rfc6962.DefaultHasher.HashChildren(hashes[i], hashes[i+1]))
+		}
+		hashes = next
+	}
+	return hashes[0]
+}
+
+// root returns the root hash of the tree of the given size.
+func (l *tiledLog) root(t *testing.T, size int) []byte {
+	t.Helper()
+	rf := compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	r := rf.NewEmptyRange(0)
+	for _, h := range l.leafHashes[:size] {
+		if err := r.Append(h, nil); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+	}
+	root, err := r.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash(): %v", err)
+	}
+	return root
+}
+
+// setCheckpoint signs and publishes a checkpoint for the tree of the given
+// size.
+func (l *tiledLog) setCheckpoint(t *testing.T, size int) {
+	t.Helper()
+	sth := ct.SignedTreeHead{Version: ct.V1, TreeSize: uint64(size), Timestamp: 5000}
+	copy(sth.SHA256RootHash[:], l.root(t, size))
+	ds, err := tls.Marshal(signSTH(t, l.key, sth))
+	if err != nil {
+		t.Fatalf("Marshal(DigitallySigned): %v", err)
+	}
+	logID := sha256.Sum256(l.keyDER)
+	keyID := sha256.Sum256(append([]byte(tiledOrigin+"\n\x05"), logID[:]...))
+	payload := append(append(keyID[:4:4], make([]byte, 8)...), ds...)
+	binary.BigEndian.PutUint64(payload[4:], sth.Timestamp)
+
+	l.files["checkpoint"] = []byte(fmt.Sprintf("%s\n%d\n%s\n\n— other.example/witness AAAAAAAA\n— %s %s\n",
+		tiledOrigin, size, base64.StdEncoding.EncodeToString(sth.SHA256RootHash[:]),
+		tiledOrigin, base64.StdEncoding.EncodeToString(payload)))
+}
+
+// signSTH returns the signature of sth with key.
+func signSTH(t *testing.T, key *ecdsa.PrivateKey, sth ct.SignedTreeHead) ct.DigitallySigned {
+	t.Helper()
+	input, err := ct.SerializeSTHSignatureInput(sth)
+	if err != nil {
+		t.Fatalf("SerializeSTHSignatureInput(): %v", err)
+	}
+	digest := sha256.Sum256(input)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1(): %v", err)
+	}
+	return ct.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: tls.ECDSA},
+		Signature: sig,
+	}
+}
+
+func (l *tiledLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, ok := l.files[strings.TrimPrefix(r.URL.Path, "/log/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(data) //nolint:errcheck
+}
+
+func TestTiledLogClient(t *testing.T) {
+	ctx := context.Background()
+	l := newTiledLog(t, 300)
+	l.setCheckpoint(t, 300)
+	ts := httptest.NewServer(l)
+	defer ts.Close()
+
+	c, err := client.NewTiledLogClient(ts.URL+"/log/", ts.Client(), jsonclient.Options{PublicKeyDER: l.keyDER})
+	if err != nil {
+		t.Fatalf("NewTiledLogClient(): %v", err)
+	}
+	var _ client.CheckLogClient = c
+
+	sth, err := c.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("GetSTH(): %v", err)
+	}
+	if sth.TreeSize != 300 || sth.Timestamp != 5000 || !bytes.Equal(sth.SHA256RootHash[:], l.root(t, 300)) {
+		t.Errorf("GetSTH() = size %d, timestamp %d, root %x; want 300, 5000, %x", sth.TreeSize, sth.Timestamp, sth.SHA256RootHash, l.root(t, 300))
+	}
+
+	for _, tc := range []struct {
+		start, end int64
+		want       int
+	}{
+		{start: 0, end: 9, want: 10},
+		{start: 250, end: 260, want: 6}, // Stops at the end of the first tile.
+		{start: 256, end: 400, want: 44},
+		{start: 299, end: 299, want: 1},
+	} {
+		rsp, err := c.GetRawEntries(ctx, tc.start, tc.end)
+		if err != nil {
+			t.Fatalf("GetRawEntries(%d, %d): %v", tc.start, tc.end, err)
+		}
+		if len(rsp.Entries) != tc.want {
+			t.Fatalf("GetRawEntries(%d, %d) returned %d entries, want %d", tc.start, tc.end, len(rsp.Entries), tc.want)
+		}
+		for i, e := range rsp.Entries {
+			idx := tc.start + int64(i)
+			if !bytes.Equal(e.LeafInput, l.leafInputs[idx]) {
+				t.Errorf("entry %d: wrong LeafInput", idx)
+			}
+			if !bytes.Equal(e.ExtraData, l.extraData[idx]) {
+				t.Errorf("entry %d: wrong ExtraData", idx)
+			}
+			if _, err := ct.RawLogEntryFromLeaf(idx, &e); err != nil {
+				t.Errorf("entry %d: RawLogEntryFromLeaf(): %v", idx, err)
+			}
+		}
+	}
+	if _, err := c.GetRawEntries(ctx, 300, 300); err == nil {
+		t.Error("GetRawEntries(300, 300) succeeded beyond the tree size")
+	}
+
+	for _, size1 := range []uint64{1, 100, 256, 257, 299, 300} {
+		p, err := c.GetSTHConsistency(ctx, size1, 300)
+		if err != nil {
+			t.Fatalf("GetSTHConsistency(%d, 300): %v", size1, err)
+		}
+		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, size1, 300, p, l.root(t, int(size1)), sth.SHA256RootHash[:]); err != nil {
+			t.Errorf("GetSTHConsistency(%d, 300): %v", size1, err)
+		}
+	}
+
+	for _, index := range []uint64{0, 5, 255, 256, 299} {
+		rsp, err := c.GetProofByIndex(ctx, index, 300)
+		if err != nil {
+			t.Fatalf("GetProofByIndex(%d): %v", index, err)
+		}
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, index, 300, l.leafHashes[index], rsp.AuditPath, sth.SHA256RootHash[:]); err != nil {
+			t.Errorf("GetProofByIndex(%d): %v", index, err)
+		}
+	}
+	rsp, err := c.GetProofByHash(ctx, l.leafHashes[7], 300)
+	if err != nil {
+		t.Fatalf("GetProofByHash(): %v", err)
+	}
+	if rsp.LeafIndex != 7 {
+		t.Errorf("GetProofByHash() returned index %d, want 7", rsp.LeafIndex)
+	}
+	if _, err := c.GetProofByHash(ctx, l.leafHashes[100], 300); err == nil {
+		t.Error("GetProofByHash() succeeded for a leaf that was never fetched")
+	}
+}
+
+func TestTiledLogClientGrowth(t *testing.T) {
+	ctx := context.Background()
+	l := newTiledLog(t, 300)
+	l.setCheckpoint(t, 100)
+	ts := httptest.NewServer(l)
+	defer ts.Close()
+
+	c, err := client.NewTiledLogClient(ts.URL+"/log", ts.Client(), jsonclient.Options{PublicKeyDER: l.keyDER})
+	if err != nil {
+		t.Fatalf("NewTiledLogClient(): %v", err)
+	}
+	if _, err := c.GetSTH(ctx); err != nil {
+		t.Fatalf("GetSTH(): %v", err)
+	}
+	// The client only knows about 100 entries, so has to refresh the
+	// checkpoint to find the tile with entry 150.
+	l.setCheckpoint(t, 300)
+	rsp, err := c.GetRawEntries(ctx, 150, 150)
+	if err != nil {
+		t.Fatalf("GetRawEntries(): %v", err)
+	}
+	if len(rsp.Entries) != 1 || !bytes.Equal(rsp.Entries[0].LeafInput, l.leafInputs[150]) {
+		t.Errorf("GetRawEntries() returned the wrong entry")
+	}
+}
+
+func TestTiledLogClientBadSignature(t *testing.T) {
+	l := newTiledLog(t, 10)
+	l.setCheckpoint(t, 10)
+	ts := httptest.NewServer(l)
+	defer ts.Close()
+
+	other := newTiledLog(t, 1)
+	c, err := client.NewTiledLogClient(ts.URL+"/log", ts.Client(), jsonclient.Options{PublicKeyDER: other.keyDER})
+	if err != nil {
+		t.Fatalf("NewTiledLogClient(): %v", err)
+	}
+	if sth, err := c.GetSTH(context.Background()); err == nil {
+		t.Errorf("GetSTH() = %v, want error for a checkpoint signed by another key", sth)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// ErrNoPublicKey is returned by the verifying methods of LogClient if it was
+// created without a public key for the log.
+var ErrNoPublicKey = errors.New("no public key to verify log signatures with")
+
+// SignatureError indicates that an STH's signature doesn't verify with the
+// log's public key.
+type SignatureError struct {
+	STH *ct.SignedTreeHead
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("invalid signature on STH at tree size %d: %v", e.STH.TreeSize, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// ProofError indicates that a consistency or inclusion proof served by the
+// log doesn't verify against the STHs it was requested for.
+type ProofError struct {
+	// Kind is "consistency" or "inclusion".
+	Kind string
+	// FirstSize and SecondSize are the tree sizes of the consistency proof; for
+	// inclusion proofs, FirstSize is the leaf index and SecondSize the tree
+	// size.
+	FirstSize  uint64
+	SecondSize uint64
+	Err        error
+}
+
+func (e *ProofError) Error() string {
+	if e.Kind == "inclusion" {
+		return fmt.Sprintf("invalid inclusion proof for leaf %d at tree size %d: %v", e.FirstSize, e.SecondSize, e.Err)
+	}
+	return fmt.Sprintf("invalid %s proof between tree sizes %d and %d: %v", e.Kind, e.FirstSize, e.SecondSize, e.Err)
+}
+
+func (e *ProofError) Unwrap() error {
+	return e.Err
+}
+
+// SplitViewError indicates that the log has signed two STHs of the same tree
+// size with different root hashes, which is proof that it is presenting
+// different views of its contents to different clients.
+type SplitViewError struct {
+	First  *ct.SignedTreeHead
+	Second *ct.SignedTreeHead
+}
+
+func (e *SplitViewError) Error() string {
+	return fmt.Sprintf("log signed different root hashes %x and %x for tree size %d", e.First.SHA256RootHash[:], e.Second.SHA256RootHash[:], e.First.TreeSize)
+}
+
+// verifySTH checks the signature of sth, returning a *SignatureError if it
+// doesn't verify.
+func (c *LogClient) verifySTH(sth *ct.SignedTreeHead) error {
+	if c.Verifier == nil {
+		return ErrNoPublicKey
+	}
+	if err := c.Verifier.VerifySTHSignature(*sth); err != nil {
+		return &SignatureError{STH: sth, Err: err}
+	}
+	return nil
+}
+
+// VerifiedGetSTH retrieves the current STH from the log, and checks its
+// signature. Unlike GetSTH, it fails with ErrNoPublicKey if the client has no
+// public key, and a bad signature is reported as a *SignatureError.
+func (c *LogClient) VerifiedGetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	if c.Verifier == nil {
+		return nil, ErrNoPublicKey
+	}
+	sth, _, _, err := c.getUnverifiedSTH(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifySTH(sth); err != nil {
+		return nil, err
+	}
+	return sth, nil
+}
+
+// VerifyConsistencyBetween checks the signatures of two STHs, and that the
+// log is consistent between them, fetching a consistency proof if needed. The
+// STHs may be passed in either order. Returns a *SignatureError if either
+// signature is bad, a *SplitViewError if the STHs are for the same tree size
+// but have different root hashes, and a *ProofError if the consistency proof
+// doesn't verify.
+func (c *LogClient) VerifyConsistencyBetween(ctx context.Context, oldSTH, newSTH *ct.SignedTreeHead) error {
+	for _, sth := range []*ct.SignedTreeHead{oldSTH, newSTH} {
+		if err := c.verifySTH(sth); err != nil {
+			return err
+		}
+	}
+	if oldSTH.TreeSize > newSTH.TreeSize {
+		oldSTH, newSTH = newSTH, oldSTH
+	}
+	if oldSTH.TreeSize == newSTH.TreeSize {
+		if oldSTH.SHA256RootHash != newSTH.SHA256RootHash {
+			return &SplitViewError{First: oldSTH, Second: newSTH}
+		}
+		return nil
+	}
+	if oldSTH.TreeSize == 0 {
+		// The empty tree is consistent with every tree.
+		return nil
+	}
+
+	p, err := c.GetSTHConsistency(ctx, oldSTH.TreeSize, newSTH.TreeSize)
+	if err != nil {
+		return err
+	}
+	if err := proof.VerifyConsistency(rfc6962.DefaultHasher, oldSTH.TreeSize, newSTH.TreeSize, p, oldSTH.SHA256RootHash[:], newSTH.SHA256RootHash[:]); err != nil {
+		return &ProofError{Kind: "consistency", FirstSize: oldSTH.TreeSize, SecondSize: newSTH.TreeSize, Err: err}
+	}
+	return nil
+}
+
+// VerifyInclusionOf checks the signature of sth, and that leaf is included in
+// the tree that it describes, fetching an inclusion proof. Returns the index
+// of the leaf. Returns a *SignatureError if the signature is bad, and a
+// *ProofError if the inclusion proof doesn't verify.
+func (c *LogClient) VerifyInclusionOf(ctx context.Context, leaf *ct.MerkleTreeLeaf, sth *ct.SignedTreeHead) (int64, error) {
+	if err := c.verifySTH(sth); err != nil {
+		return -1, err
+	}
+	leafHash, err := ct.LeafHashForLeaf(leaf)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create leaf hash: %v", err)
+	}
+	rsp, err := c.GetProofByHash(ctx, leafHash[:], sth.TreeSize)
+	if err != nil {
+		return -1, err
+	}
+	if rsp.LeafIndex < 0 {
+		return -1, fmt.Errorf("log returned negative leaf index %d", rsp.LeafIndex)
+	}
+	index := uint64(rsp.LeafIndex)
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, index, sth.TreeSize, leafHash[:], rsp.AuditPath, sth.SHA256RootHash[:]); err != nil {
+		return -1, &ProofError{Kind: "inclusion", FirstSize: index, SecondSize: sth.TreeSize, Err: err}
+	}
+	return rsp.LeafIndex, nil
+}
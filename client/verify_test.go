@@ -0,0 +1,276 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+)
+
+// proofLog is an RFC 6962 log serving STHs and proofs, with knobs to make it
+// misbehave.
+type proofLog struct {
+	key       *ecdsa.PrivateKey
+	tree      *testonly.Tree
+	leaves    []ct.MerkleTreeLeaf
+	sthSigner *ecdsa.PrivateKey // Signs the served STH if set.
+	badProofs bool              // Corrupts proofs if set.
+}
+
+func newProofLog(t *testing.T, size int) *proofLog {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	l := &proofLog{key: key, tree: testonly.New(rfc6962.DefaultHasher)}
+	for i := 0; i < size; i++ {
+		leaf := ct.MerkleTreeLeaf{
+			Version:  ct.V1,
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				Timestamp: uint64(i),
+				EntryType: ct.X509LogEntryType,
+				X509Entry: &ct.ASN1Cert{Data: []byte(fmt.Sprintf("cert-%d", i))},
+			},
+		}
+		data, err := tls.Marshal(leaf)
+		if err != nil {
+			t.Fatalf("Marshal(): %v", err)
+		}
+		l.leaves = append(l.leaves, leaf)
+		l.tree.AppendData(data)
+	}
+	return l
+}
+
+// sth returns an STH for the tree of the given size, signed with key, or
+// with the log's key if key is nil.
+func (l *proofLog) sth(t *testing.T, size uint64, key *ecdsa.PrivateKey) *ct.SignedTreeHead {
+	t.Helper()
+	sth := &ct.SignedTreeHead{Version: ct.V1, TreeSize: size, Timestamp: 1000 + size}
+	copy(sth.SHA256RootHash[:], l.tree.HashAt(size))
+	if key == nil {
+		key = l.key
+	}
+	sth.TreeHeadSignature = signSTH(t, key, *sth)
+	return sth
+}
+
+func (l *proofLog) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("Encode(): %v", err)
+		}
+	}
+	corrupt := func(p [][]byte) {
+		if l.badProofs && len(p) > 0 {
+			p[0] = append([]byte{}, p[0]...)
+			p[0][0] ^= 1
+		}
+	}
+	mux.HandleFunc("/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		sth := l.sth(t, l.tree.Size(), l.sthSigner)
+		sig, err := tls.Marshal(sth.TreeHeadSignature)
+		if err != nil {
+			t.Errorf("Marshal(): %v", err)
+		}
+		writeJSON(w, ct.GetSTHResponse{TreeSize: sth.TreeSize, Timestamp: sth.Timestamp, SHA256RootHash: sth.SHA256RootHash[:], TreeHeadSignature: sig})
+	})
+	mux.HandleFunc("/ct/v1/get-sth-consistency", func(w http.ResponseWriter, r *http.Request) {
+		first, _ := strconv.ParseUint(r.FormValue("first"), 10, 64)
+		second, _ := strconv.ParseUint(r.FormValue("second"), 10, 64)
+		p, err := l.tree.ConsistencyProof(first, second)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		corrupt(p)
+		writeJSON(w, ct.GetSTHConsistencyResponse{Consistency: p})
+	})
+	mux.HandleFunc("/ct/v1/get-proof-by-hash", func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.ParseUint(r.FormValue("tree_size"), 10, 64)
+		hash, _ := base64.StdEncoding.DecodeString(r.FormValue("hash"))
+		for i := uint64(0); i < size; i++ {
+			if bytes.Equal(l.tree.LeafHash(i), hash) {
+				p, err := l.tree.InclusionProof(i, size)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				corrupt(p)
+				writeJSON(w, ct.GetProofByHashResponse{LeafIndex: int64(i), AuditPath: p})
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	return mux
+}
+
+func newProofLogClient(t *testing.T, l *proofLog, withKey bool) (*client.LogClient, func()) {
+	t.Helper()
+	ts := httptest.NewServer(l.handler(t))
+	var opts jsonclient.Options
+	if withKey {
+		der, err := x509.MarshalPKIXPublicKey(l.key.Public())
+		if err != nil {
+			t.Fatalf("MarshalPKIXPublicKey(): %v", err)
+		}
+		opts.PublicKeyDER = der
+	}
+	lc, err := client.New(ts.URL, ts.Client(), opts)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	return lc, ts.Close
+}
+
+func TestVerifiedGetSTH(t *testing.T) {
+	ctx := context.Background()
+	l := newProofLog(t, 10)
+	lc, closeFn := newProofLogClient(t, l, true)
+	defer closeFn()
+
+	sth, err := lc.VerifiedGetSTH(ctx)
+	if err != nil {
+		t.Fatalf("VerifiedGetSTH(): %v", err)
+	}
+	if sth.TreeSize != 10 {
+		t.Errorf("VerifiedGetSTH() returned tree size %d, want 10", sth.TreeSize)
+	}
+
+	l.sthSigner, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var sigErr *client.SignatureError
+	if _, err := lc.VerifiedGetSTH(ctx); !errors.As(err, &sigErr) {
+		t.Errorf("VerifiedGetSTH() with bad signature = %v, want SignatureError", err)
+	}
+
+	noKey, closeNoKey := newProofLogClient(t, l, false)
+	defer closeNoKey()
+	if _, err := noKey.VerifiedGetSTH(ctx); !errors.Is(err, client.ErrNoPublicKey) {
+		t.Errorf("VerifiedGetSTH() without key = %v, want ErrNoPublicKey", err)
+	}
+}
+
+func TestVerifyConsistencyBetween(t *testing.T) {
+	ctx := context.Background()
+	l := newProofLog(t, 30)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// forged has a different root hash, but a valid signature from the log.
+	forged := l.sth(t, 10, nil)
+	forged.SHA256RootHash[0] ^= 1
+	forged.TreeHeadSignature = signSTH(t, l.key, *forged)
+
+	for _, tc := range []struct {
+		desc      string
+		old, new  *ct.SignedTreeHead
+		badProofs bool
+		wantErr   interface{}
+	}{
+		{desc: "ok", old: l.sth(t, 10, nil), new: l.sth(t, 30, nil)},
+		{desc: "reversed", old: l.sth(t, 30, nil), new: l.sth(t, 10, nil)},
+		{desc: "same", old: l.sth(t, 10, nil), new: l.sth(t, 10, nil)},
+		{desc: "empty", old: l.sth(t, 0, nil), new: l.sth(t, 30, nil)},
+		{desc: "bad-signature", old: l.sth(t, 10, otherKey), new: l.sth(t, 30, nil), wantErr: &client.SignatureError{}},
+		{desc: "bad-proof", old: l.sth(t, 10, nil), new: l.sth(t, 30, nil), badProofs: true, wantErr: &client.ProofError{}},
+		{desc: "inconsistent", old: forged, new: l.sth(t, 30, nil), wantErr: &client.ProofError{}},
+		{desc: "inconsistent-reversed", old: l.sth(t, 30, nil), new: forged, wantErr: &client.ProofError{}},
+		{desc: "split-view", old: forged, new: l.sth(t, 10, nil), wantErr: &client.SplitViewError{}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l.badProofs = tc.badProofs
+			lc, closeFn := newProofLogClient(t, l, true)
+			defer closeFn()
+
+			err := lc.VerifyConsistencyBetween(ctx, tc.old, tc.new)
+			switch want := tc.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("VerifyConsistencyBetween() = %v, want nil", err)
+				}
+			case *client.SignatureError:
+				if !errors.As(err, &want) {
+					t.Errorf("VerifyConsistencyBetween() = %v, want SignatureError", err)
+				}
+			case *client.ProofError:
+				if !errors.As(err, &want) || want.Kind != "consistency" {
+					t.Errorf("VerifyConsistencyBetween() = %v, want consistency ProofError", err)
+				}
+				// A bad proof doesn't prove that the log forked.
+				if errors.As(err, new(*client.SplitViewError)) {
+					t.Errorf("VerifyConsistencyBetween() = %v, want no SplitViewError", err)
+				}
+			case *client.SplitViewError:
+				if !errors.As(err, &want) {
+					t.Fatalf("VerifyConsistencyBetween() = %v, want SplitViewError", err)
+				}
+				if want.First != tc.old || want.Second != tc.new {
+					t.Errorf("SplitViewError holds %v and %v, want %v and %v", want.First, want.Second, tc.old, tc.new)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyInclusionOf(t *testing.T) {
+	ctx := context.Background()
+	l := newProofLog(t, 30)
+	lc, closeFn := newProofLogClient(t, l, true)
+	defer closeFn()
+	sth := l.sth(t, 30, nil)
+
+	index, err := lc.VerifyInclusionOf(ctx, &l.leaves[17], sth)
+	if err != nil {
+		t.Fatalf("VerifyInclusionOf(): %v", err)
+	}
+	if index != 17 {
+		t.Errorf("VerifyInclusionOf() = %d, want 17", index)
+	}
+
+	l.badProofs = true
+	var proofErr *client.ProofError
+	if _, err := lc.VerifyInclusionOf(ctx, &l.leaves[17], sth); !errors.As(err, &proofErr) || proofErr.Kind != "inclusion" {
+		t.Errorf("VerifyInclusionOf() with bad proof = %v, want inclusion ProofError", err)
+	}
+	l.badProofs = false
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var sigErr *client.SignatureError
+	if _, err := lc.VerifyInclusionOf(ctx, &l.leaves[17], l.sth(t, 30, otherKey)); !errors.As(err, &sigErr) {
+		t.Errorf("VerifyInclusionOf() with bad signature = %v, want SignatureError", err)
+	}
+}
@@ -28,7 +28,6 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -62,6 +61,8 @@ type JSONClient struct {
 	logger     Logger                // interface to use for logging warnings and errors
 	backoff    backoffer             // object used to store and calculate backoff information
 	userAgent  string                // If set, this is sent as the UserAgent header.
+	hooks      []Hooks               // notified of requests, retries and backoffs
+	retry      *RetryPolicy          // nil to only retry POSTs, as PostAndParseWithRetry always did
 }
 
 // Logger is a simple logging interface used to log internal errors and warnings
@@ -81,6 +82,12 @@ type Options struct {
 	PublicKeyDER []byte
 	// UserAgent, if set, will be sent as the User-Agent header with each request.
 	UserAgent string
+	// Hooks, if set, are called in order for each request, retry and backoff.
+	Hooks []Hooks
+	// RetryPolicy, if set, is used to retry failed requests made by
+	// GetAndParse and PostAndParseWithRetry. If nil, GetAndParse makes a single
+	// attempt, and PostAndParseWithRetry retries until the context expires.
+	RetryPolicy *RetryPolicy
 }
 
 // ParsePublicKey parses and returns the public key contained in opts.
@@ -156,6 +163,8 @@ func New(uri string, hc *http.Client, opts Options) (*JSONClient, error) {
 		logger:     logger,
 		backoff:    &backoff{},
 		userAgent:  opts.UserAgent,
+		hooks:      opts.Hooks,
+		retry:      opts.RetryPolicy,
 	}, nil
 }
 
@@ -165,13 +174,26 @@ func (c *JSONClient) BaseURI() string {
 }
 
 // GetAndParse makes a HTTP GET call to the given path, and attempts to parse
-// the response as a JSON representation of the rsp structure.  Returns the
+// the response as a JSON representation of the rsp structure, retrying
+// according to the client's RetryPolicy if it has one.  Returns the
 // http.Response, the body of the response, and an error (which may be of
 // type RspError if the HTTP response was available).
 func (c *JSONClient) GetAndParse(ctx context.Context, path string, params map[string]string, rsp interface{}) (*http.Response, []byte, error) {
 	if ctx == nil {
 		return nil, nil, errors.New("context.Context required")
 	}
+	policy := c.retry
+	if policy == nil {
+		policy = noRetryPolicy
+	}
+	return c.withRetry(ctx, path, policy, func(ctx context.Context) (*http.Response, []byte, error) {
+		return c.getAndParse(ctx, path, params, rsp)
+	})
+}
+
+// getAndParse makes a single attempt of GetAndParse. Unlike GetAndParse, it
+// also returns the http.Response for errors caused by an HTTP status.
+func (c *JSONClient) getAndParse(ctx context.Context, path string, params map[string]string, rsp interface{}) (*http.Response, []byte, error) {
 	// Build a GET request with URL-encoded parameters.
 	vals := url.Values{}
 	for k, v := range params {
@@ -187,20 +209,16 @@ func (c *JSONClient) GetAndParse(ctx context.Context, path string, params map[st
 		httpReq.Header.Set("User-Agent", c.userAgent)
 	}
 
-	httpRsp, err := ctxhttp.Do(ctx, c.httpClient, httpReq)
+	httpRsp, body, err := c.do(ctx, path, httpReq)
 	if err != nil {
+		if httpRsp != nil {
+			return nil, nil, RspError{Err: fmt.Errorf("failed to read response body: %v", err), StatusCode: httpRsp.StatusCode, Body: body}
+		}
 		return nil, nil, err
 	}
 
-	// Read everything now so http.Client can reuse the connection.
-	body, err := io.ReadAll(httpRsp.Body)
-	httpRsp.Body.Close()
-	if err != nil {
-		return nil, nil, RspError{Err: fmt.Errorf("failed to read response body: %v", err), StatusCode: httpRsp.StatusCode, Body: body}
-	}
-
 	if httpRsp.StatusCode != http.StatusOK {
-		return nil, nil, RspError{Err: fmt.Errorf("got HTTP Status %q", httpRsp.Status), StatusCode: httpRsp.StatusCode, Body: body}
+		return httpRsp, nil, RspError{Err: fmt.Errorf("got HTTP Status %q", httpRsp.Status), StatusCode: httpRsp.StatusCode, Body: body}
 	}
 
 	if err := json.NewDecoder(bytes.NewReader(body)).Decode(rsp); err != nil {
@@ -234,14 +252,7 @@ func (c *JSONClient) PostAndParse(ctx context.Context, path string, req, rsp int
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	httpRsp, err := ctxhttp.Do(ctx, c.httpClient, httpReq)
-
-	// Read all of the body, if there is one, so that the http.Client can do Keep-Alive.
-	var body []byte
-	if httpRsp != nil {
-		body, err = io.ReadAll(httpRsp.Body)
-		httpRsp.Body.Close()
-	}
+	httpRsp, body, err := c.do(ctx, path, httpReq)
 	if err != nil {
 		if httpRsp != nil {
 			return nil, nil, RspError{StatusCode: httpRsp.StatusCode, Body: body, Err: err}
@@ -257,14 +268,55 @@ func (c *JSONClient) PostAndParse(ctx context.Context, path string, req, rsp int
 	return httpRsp, body, nil
 }
 
-// waitForBackoff blocks until the defined backoff interval or context has expired, if the returned
-// not before time is in the past it returns immediately.
-func (c *JSONClient) waitForBackoff(ctx context.Context) error {
-	dur := time.Until(c.backoff.until().Add(time.Millisecond * time.Duration(rand.Intn(int(maxJitter.Seconds()*1000)))))
+// do sends httpReq, which is for the given endpoint path, and reads the whole
+// response body so that the http.Client can reuse the connection. Calls the
+// hooks before sending and after reading. Returns the response if there was
+// one, even if reading its body failed.
+func (c *JSONClient) do(ctx context.Context, path string, httpReq *http.Request) (*http.Response, []byte, error) {
+	for _, h := range c.hooks {
+		if err := h.PreRequest(path, httpReq); err != nil {
+			return nil, nil, err
+		}
+	}
+	start := time.Now()
+	httpRsp, err := ctxhttp.Do(ctx, c.httpClient, httpReq)
+	var body []byte
+	if httpRsp != nil {
+		body, err = io.ReadAll(httpRsp.Body)
+		httpRsp.Body.Close()
+	}
+	latency := time.Since(start)
+	for _, h := range c.hooks {
+		h.PostResponse(path, httpReq, httpRsp, latency, err)
+	}
+	return httpRsp, body, err
+}
+
+// notifyRetry calls the hooks for a failed attempt which is going to be
+// retried, after backing off for wait if backoff is set.
+func (c *JSONClient) notifyRetry(path string, attempt int, err error, backoff bool, wait time.Duration) {
+	for _, h := range c.hooks {
+		h.Retry(path, attempt, err)
+		if backoff {
+			h.Backoff(path, wait)
+		}
+	}
+}
+
+// waitForBackoff blocks until the defined backoff interval, plus up to jitter,
+// or context has expired, if the returned not before time is in the past it
+// returns immediately.
+func (c *JSONClient) waitForBackoff(ctx context.Context, jitter time.Duration) error {
+	until := c.backoff.until()
+	if jitter > 0 {
+		until = until.Add(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	dur := time.Until(until)
 	if dur < 0 {
 		dur = 0
 	}
 	backoffTimer := time.NewTimer(dur)
+	defer backoffTimer.Stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -274,54 +326,28 @@ func (c *JSONClient) waitForBackoff(ctx context.Context) error {
 }
 
 // PostAndParseWithRetry makes a HTTP POST call, but retries (with backoff) on
-// retryable errors; the caller should set a deadline on the provided context
-// to prevent infinite retries.  Return values are as for PostAndParse.
+// retryable errors, according to the client's RetryPolicy. Without a
+// RetryPolicy, it retries on all errors apart from HTTP statuses other than
+// 408, 429 and 503, so the caller should set a deadline on the provided
+// context to prevent infinite retries.  Return values are as for
+// PostAndParse, except that HTTP statuses other than 200 OK are returned as
+// an RspError.
 func (c *JSONClient) PostAndParseWithRetry(ctx context.Context, path string, req, rsp interface{}) (*http.Response, []byte, error) {
 	if ctx == nil {
 		return nil, nil, errors.New("context.Context required")
 	}
-	for {
+	policy := c.retry
+	if policy == nil {
+		policy = defaultPostRetryPolicy
+	}
+	return c.withRetry(ctx, path, policy, func(ctx context.Context) (*http.Response, []byte, error) {
 		httpRsp, body, err := c.PostAndParse(ctx, path, req, rsp)
-		if err != nil {
-			// Don't retry context errors.
-			if err == context.Canceled || err == context.DeadlineExceeded {
-				return nil, nil, err
-			}
-			wait := c.backoff.set(nil)
-			c.logger.Printf("Request to %s failed, backing-off %s: %s", c.uri, wait, err)
-		} else {
-			switch {
-			case httpRsp.StatusCode == http.StatusOK:
-				return httpRsp, body, nil
-			case httpRsp.StatusCode == http.StatusRequestTimeout:
-				// Request timeout, retry immediately
-				c.logger.Printf("Request to %s timed out, retrying immediately", c.uri)
-			case httpRsp.StatusCode == http.StatusServiceUnavailable:
-				fallthrough
-			case httpRsp.StatusCode == http.StatusTooManyRequests:
-				var backoff *time.Duration
-				// Retry-After may be either a number of seconds as a int or a RFC 1123
-				// date string (RFC 7231 Section 7.1.3)
-				if retryAfter := httpRsp.Header.Get("Retry-After"); retryAfter != "" {
-					if seconds, err := strconv.Atoi(retryAfter); err == nil {
-						b := time.Duration(seconds) * time.Second
-						backoff = &b
-					} else if date, err := time.Parse(time.RFC1123, retryAfter); err == nil {
-						b := time.Until(date)
-						backoff = &b
-					}
-				}
-				wait := c.backoff.set(backoff)
-				c.logger.Printf("Request to %s failed, backing-off for %s: got HTTP status %s", c.uri, wait, httpRsp.Status)
-			default:
-				return nil, nil, RspError{
-					StatusCode: httpRsp.StatusCode,
-					Body:       body,
-					Err:        fmt.Errorf("got HTTP status %q", httpRsp.Status)}
-			}
-		}
-		if err := c.waitForBackoff(ctx); err != nil {
-			return nil, nil, err
+		if err == nil && httpRsp.StatusCode != http.StatusOK {
+			err = RspError{
+				StatusCode: httpRsp.StatusCode,
+				Body:       body,
+				Err:        fmt.Errorf("got HTTP status %q", httpRsp.Status)}
 		}
-	}
+		return httpRsp, body, err
+	})
 }
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Hooks is notified of the requests made by a JSONClient, and of its retry
+// and backoff decisions. It can be used to modify outgoing requests, e.g. to
+// add authentication headers, and to record metrics or traces. In all
+// methods, path is the API endpoint path, e.g. /ct/v1/get-sth, without the
+// client's base URI.
+//
+// Hooks may be called concurrently for different requests.
+type Hooks interface {
+	// PreRequest is called before each HTTP request is sent, including each
+	// attempt of a retried request. It may modify req. Returning an error
+	// aborts the request with that error.
+	PreRequest(path string, req *http.Request) error
+	// PostResponse is called when an HTTP request has completed and its
+	// response body has been read, or failed. rsp is nil if the request
+	// failed without a response. latency is the time from sending the request
+	// to reading the end of the body.
+	PostResponse(path string, req *http.Request, rsp *http.Response, latency time.Duration, err error)
+	// Retry is called when a request failed and is going to be retried. attempt
+	// is the number of the failed attempt, starting at 1, and err the failure,
+	// which is an RspError if the failure was an HTTP status.
+	Retry(path string, attempt int, err error)
+	// Backoff is called when the client backs off for wait before retrying a
+	// request.
+	Backoff(path string, wait time.Duration)
+}
+
+// NopHooks is a Hooks implementation that does nothing. It can be embedded in
+// a Hooks implementation that only needs some of the methods.
+type NopHooks struct{}
+
+// PreRequest does nothing.
+func (NopHooks) PreRequest(string, *http.Request) error { return nil }
+
+// PostResponse does nothing.
+func (NopHooks) PostResponse(string, *http.Request, *http.Response, time.Duration, error) {}
+
+// Retry does nothing.
+func (NopHooks) Retry(string, int, error) {}
+
+// Backoff does nothing.
+func (NopHooks) Backoff(string, time.Duration) {}
+
+// HeaderHooks is a Hooks implementation which sets the given headers on each
+// request, e.g. for authenticating to a private log.
+type HeaderHooks struct {
+	NopHooks
+	Header http.Header
+}
+
+// PreRequest sets the headers on req.
+func (h HeaderHooks) PreRequest(_ string, req *http.Request) error {
+	for k, vs := range h.Header {
+		req.Header.Del(k)
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHooks records the events it is called for.
+type recordingHooks struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHooks) record(format string, args ...interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, fmt.Sprintf(format, args...))
+}
+
+func (h *recordingHooks) PreRequest(path string, req *http.Request) error {
+	h.record("pre %s %s", req.Method, path)
+	return nil
+}
+
+func (h *recordingHooks) PostResponse(path string, _ *http.Request, rsp *http.Response, _ time.Duration, err error) {
+	if err != nil {
+		h.record("post %s error", path)
+		return
+	}
+	h.record("post %s %d", path, rsp.StatusCode)
+}
+
+func (h *recordingHooks) Retry(path string, attempt int, err error) {
+	var rspErr RspError
+	errors.As(err, &rspErr)
+	h.record("retry %s %d %d", path, attempt, rspErr.StatusCode)
+}
+
+func (h *recordingHooks) Backoff(path string, wait time.Duration) {
+	h.record("backoff %s", path)
+}
+
+func TestHooks(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		retryAfter int
+		want       []string
+	}{
+		{
+			desc:       "backoff",
+			retryAfter: -1,
+			want: []string{
+				"pre POST /retry", "post /retry 503", "retry /retry 1 503", "backoff /retry",
+				"pre POST /retry", "post /retry 503", "retry /retry 2 503", "backoff /retry",
+				"pre POST /retry", "post /retry 200",
+			},
+		},
+		{
+			desc:       "timeout",
+			retryAfter: 0,
+			want: []string{
+				"pre POST /retry", "post /retry 408", "retry /retry 1 408",
+				"pre POST /retry", "post /retry 408", "retry /retry 2 408",
+				"pre POST /retry", "post /retry 200",
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := MockServer(t, 2, tc.retryAfter)
+			defer ts.Close()
+
+			hooks := &recordingHooks{}
+			logClient, err := New(ts.URL, &http.Client{}, Options{Hooks: []Hooks{hooks}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			logClient.backoff = &mockBackoff{}
+
+			var got TestStruct
+			if _, _, err := logClient.PostAndParseWithRetry(context.Background(), "/retry", nil, &got); err != nil {
+				t.Fatalf("PostAndParseWithRetry()=%v; want no error", err)
+			}
+			if !reflect.DeepEqual(hooks.events, tc.want) {
+				t.Errorf("got events %q, want %q", hooks.events, tc.want)
+			}
+		})
+	}
+}
+
+func TestHeaderHooks(t *testing.T) {
+	ts := MockServer(t, -1, 0)
+	defer ts.Close()
+
+	hooks := HeaderHooks{Header: http.Header{"User-Agent": {"banana"}}}
+	logClient, err := New(ts.URL, &http.Client{}, Options{UserAgent: "apple", Hooks: []Hooks{hooks}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TestStruct
+	if _, _, err := logClient.GetAndParse(context.Background(), "/useragent/banana", nil, &got); err != nil {
+		t.Errorf("GetAndParse()=%v; want the hook to have set the header", err)
+	}
+}
+
+func TestPreRequestError(t *testing.T) {
+	ts := MockServer(t, -1, 0)
+	defer ts.Close()
+
+	wantErr := errors.New("no credentials")
+	logClient, err := New(ts.URL, &http.Client{}, Options{Hooks: []Hooks{failingHooks{err: wantErr}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TestStruct
+	if _, _, err := logClient.GetAndParse(context.Background(), "/struct/path", nil, &got); !errors.Is(err, wantErr) {
+		t.Errorf("GetAndParse()=%v; want %v", err, wantErr)
+	}
+}
+
+type failingHooks struct {
+	NopHooks
+	err error
+}
+
+func (h failingHooks) PreRequest(string, *http.Request) error {
+	return h.err
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides jsonclient.Hooks which export per-endpoint request,
+// latency, retry and backoff metrics through a Trillian monitoring
+// MetricFactory, e.g. prometheus.MetricFactory.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/trillian/monitoring"
+)
+
+// Metrics holds the metrics for JSONClients. As metric names are registered
+// globally by some MetricFactory implementations, create a single Metrics per
+// MetricFactory and prefix, and get Hooks for each log from it.
+type Metrics struct {
+	reqs    monitoring.Counter   // log, ep => value
+	rsps    monitoring.Counter   // log, ep, rc => value
+	latency monitoring.Histogram // log, ep, rc => value
+	retries monitoring.Counter   // log, ep, rc => value
+	backoff monitoring.Histogram // log, ep => value
+}
+
+// New creates the metrics with the given name prefix, or "jsonclient" if
+// prefix is empty.
+func New(mf monitoring.MetricFactory, prefix string) *Metrics {
+	if prefix == "" {
+		prefix = "jsonclient"
+	}
+	return &Metrics{
+		reqs:    mf.NewCounter(prefix+"_http_reqs", "Number of requests sent", "log", "ep"),
+		rsps:    mf.NewCounter(prefix+"_http_rsps", "Number of responses received, by HTTP status or \"error\"", "log", "ep", "rc"),
+		latency: mf.NewHistogram(prefix+"_http_latency", "Latency of requests in seconds, by HTTP status or \"error\"", "log", "ep", "rc"),
+		retries: mf.NewCounter(prefix+"_retries", "Number of retried request attempts, by HTTP status or \"error\"", "log", "ep", "rc"),
+		backoff: mf.NewHistogram(prefix+"_backoff", "Backoff before retrying requests in seconds", "log", "ep"),
+	}
+}
+
+// Hooks returns hooks which record metrics for requests to the log with the
+// given base URI, which is used as the "log" label.
+func (m *Metrics) Hooks(logURI string) jsonclient.Hooks {
+	return &hooks{m: m, log: logURI}
+}
+
+type hooks struct {
+	m   *Metrics
+	log string
+}
+
+func (h *hooks) PreRequest(path string, _ *http.Request) error {
+	h.m.reqs.Inc(h.log, path)
+	return nil
+}
+
+func (h *hooks) PostResponse(path string, _ *http.Request, rsp *http.Response, latency time.Duration, err error) {
+	rc := "error"
+	if rsp != nil && err == nil {
+		rc = strconv.Itoa(rsp.StatusCode)
+	}
+	h.m.rsps.Inc(h.log, path, rc)
+	h.m.latency.Observe(latency.Seconds(), h.log, path, rc)
+}
+
+func (h *hooks) Retry(path string, _ int, err error) {
+	rc := "error"
+	var rspErr jsonclient.RspError
+	if errors.As(err, &rspErr) && rspErr.StatusCode != 0 {
+		rc = strconv.Itoa(rspErr.StatusCode)
+	}
+	h.m.retries.Inc(h.log, path, rc)
+}
+
+func (h *hooks) Backoff(path string, wait time.Duration) {
+	h.m.backoff.Observe(wait.Seconds(), h.log, path)
+}
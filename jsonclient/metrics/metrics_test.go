@@ -0,0 +1,68 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/trillian/monitoring"
+)
+
+func TestHooks(t *testing.T) {
+	fails := 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fails > 0 {
+			fails--
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer ts.Close()
+
+	m := New(monitoring.InertMetricFactory{}, "")
+	c, err := jsonclient.New(ts.URL, ts.Client(), jsonclient.Options{Hooks: []jsonclient.Hooks{m.Hooks(ts.URL)}})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	var rsp struct{}
+	if _, _, err := c.PostAndParseWithRetry(context.Background(), "/ct/v1/add-chain", nil, &rsp); err != nil {
+		t.Fatalf("PostAndParseWithRetry(): %v", err)
+	}
+	const ep = "/ct/v1/add-chain"
+	if got := m.reqs.Value(ts.URL, ep); got != 2 {
+		t.Errorf("requests = %v, want 2", got)
+	}
+	for _, rc := range []string{"200", "503"} {
+		if got := m.rsps.Value(ts.URL, ep, rc); got != 1 {
+			t.Errorf("responses with %s = %v, want 1", rc, got)
+		}
+		if count, _ := m.latency.Info(ts.URL, ep, rc); count != 1 {
+			t.Errorf("latency observations with %s = %d, want 1", rc, count)
+		}
+	}
+	if got := m.retries.Value(ts.URL, ep, "503"); got != 1 {
+		t.Errorf("retries = %v, want 1", got)
+	}
+	if count, _ := m.backoff.Info(ts.URL, ep); count != 1 {
+		t.Errorf("backoff observations = %d, want 1", count)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRetryableStatusCodes are the HTTP statuses retried by a RetryPolicy
+// which doesn't specify any.
+var DefaultRetryableStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how a JSONClient retries failed requests. Requests
+// which fail without an HTTP response (e.g. because of a network error) are
+// retried, unless the context has expired; requests which fail with an HTTP
+// status are retried if the status is retryable. Between attempts, the client
+// backs off exponentially, or as requested by the server's Retry-After
+// header, except that 408 Request Timeout responses are retried immediately.
+// The backoff is shared by all requests made by the client.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for each request,
+	// including the first one. Zero means no limit, other than Deadline and
+	// the context.
+	MaxAttempts int
+	// RetryableStatusCodes are the HTTP statuses to retry. If nil,
+	// DefaultRetryableStatusCodes is used.
+	RetryableStatusCodes []int
+	// IgnoreRetryAfter, if set, makes the client ignore the Retry-After
+	// header of responses, and always back off exponentially.
+	IgnoreRetryAfter bool
+	// MaxRetryAfter, if positive, caps the backoff requested by a Retry-After
+	// header.
+	MaxRetryAfter time.Duration
+	// Jitter is the maximum random delay added to each backoff, to spread out
+	// retries from concurrent requests. Zero means 250ms, and a negative value
+	// disables jitter.
+	Jitter time.Duration
+	// Deadline, if positive, limits the total time spent on a request,
+	// including all of its attempts and backoffs.
+	Deadline time.Duration
+}
+
+var (
+	// noRetryPolicy is used for GetAndParse if the client has no RetryPolicy.
+	noRetryPolicy = &RetryPolicy{MaxAttempts: 1}
+	// defaultPostRetryPolicy is used for PostAndParseWithRetry if the client
+	// has no RetryPolicy.
+	defaultPostRetryPolicy = &RetryPolicy{
+		RetryableStatusCodes: []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+)
+
+// retryable returns whether a request which failed with err should be
+// retried.
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rspErr RspError
+	if !errors.As(err, &rspErr) || rspErr.StatusCode == 0 {
+		return true
+	}
+	codes := p.RetryableStatusCodes
+	if codes == nil {
+		codes = DefaultRetryableStatusCodes
+	}
+	for _, code := range codes {
+		if code == rspErr.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) jitter() time.Duration {
+	if p.Jitter == 0 {
+		return maxJitter
+	}
+	return p.Jitter
+}
+
+// retryAfter returns the backoff requested by the Retry-After header of
+// httpRsp, or nil if there is none or the policy ignores it.
+func (p *RetryPolicy) retryAfter(httpRsp *http.Response) *time.Duration {
+	if p.IgnoreRetryAfter || httpRsp == nil {
+		return nil
+	}
+	retryAfter := httpRsp.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return nil
+	}
+	// Retry-After may be either a number of seconds as a int or a RFC 1123
+	// date string (RFC 7231 Section 7.1.3)
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := time.Parse(time.RFC1123, retryAfter); err == nil {
+		wait = time.Until(date)
+	} else {
+		return nil
+	}
+	if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+		wait = p.MaxRetryAfter
+	}
+	return &wait
+}
+
+// Attempt is the outcome of one attempt of a request.
+type Attempt struct {
+	// Path is the API endpoint path of the request, e.g. /ct/v1/get-sth.
+	Path string
+	// Number is the number of the attempt, starting at 1.
+	Number int
+	// StatusCode is the HTTP status of the response, or zero if there was no
+	// response.
+	StatusCode int
+	// Err is the error the attempt failed with, or nil if it succeeded.
+	Err error
+	// Latency is the time the attempt took.
+	Latency time.Duration
+	// Retried is set if the request was retried after this attempt, after
+	// backing off for Backoff.
+	Retried bool
+	Backoff time.Duration
+}
+
+// Attempts collects the outcomes of the attempts of requests made with a
+// context returned by WithAttempts. It is safe for concurrent use.
+type Attempts struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+// List returns the attempts made so far, in order.
+func (a *Attempts) List() []Attempt {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Attempt(nil), a.attempts...)
+}
+
+func (a *Attempts) add(attempt Attempt) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.attempts = append(a.attempts, attempt)
+}
+
+type attemptsKey struct{}
+
+// WithAttempts returns a copy of ctx which makes JSONClient record the
+// outcome of each attempt of the requests made with it in a. This allows
+// callers of higher-level clients, such as client.LogClient, to find out how
+// a call was retried.
+func WithAttempts(ctx context.Context, a *Attempts) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, a)
+}
+
+// withRetry calls attempt until it succeeds, or fails with an error that the
+// policy doesn't retry. attempt must return the http.Response if it failed
+// with an HTTP status, so that its Retry-After header can be used.
+func (c *JSONClient) withRetry(ctx context.Context, path string, p *RetryPolicy, attempt func(context.Context) (*http.Response, []byte, error)) (*http.Response, []byte, error) {
+	if p.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Deadline)
+		defer cancel()
+	}
+	attempts, _ := ctx.Value(attemptsKey{}).(*Attempts)
+
+	for n := 1; ; n++ {
+		start := time.Now()
+		httpRsp, body, err := attempt(ctx)
+		a := Attempt{Path: path, Number: n, Err: err, Latency: time.Since(start)}
+		if httpRsp != nil {
+			a.StatusCode = httpRsp.StatusCode
+		}
+		if err == nil {
+			c.backoff.decreaseMultiplier()
+			attempts.add(a)
+			return httpRsp, body, nil
+		}
+		if !p.retryable(err) || (p.MaxAttempts > 0 && n >= p.MaxAttempts) {
+			attempts.add(a)
+			return nil, nil, err
+		}
+
+		if a.StatusCode == http.StatusRequestTimeout {
+			// The request timed out, so retry it immediately.
+			a.Retried = true
+			attempts.add(a)
+			c.logger.Printf("Request to %s timed out, retrying immediately", c.uri)
+			c.notifyRetry(path, n, err, false, 0)
+			continue
+		}
+		wait := c.backoff.set(p.retryAfter(httpRsp))
+		a.Retried, a.Backoff = true, wait
+		attempts.add(a)
+		c.logger.Printf("Request to %s failed, backing-off %s: %s", c.uri, wait, err)
+		c.notifyRetry(path, n, err, true, wait)
+		if err := c.waitForBackoff(ctx, p.jitter()); err != nil {
+			return nil, nil, err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first fails requests with the given status, and
+// Retry-After header if set.
+func flakyServer(fails, status int, retryAfter string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fails > 0 {
+			fails--
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprintf(w, `{"tree_size": 11, "timestamp": 99}`)
+	}))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		desc         string
+		policy       *RetryPolicy
+		post         bool
+		fails        int
+		status       int
+		retryAfter   string
+		wantErr      bool
+		wantAttempts []int // Status code of each attempt.
+		wantOverride time.Duration
+	}{
+		{desc: "no-policy", fails: 1, status: 503, wantErr: true, wantAttempts: []int{503}},
+		{desc: "no-policy-post", post: true, fails: 1, status: 503, wantAttempts: []int{503, 200}},
+		{desc: "no-policy-post-500", post: true, fails: 1, status: 500, wantErr: true, wantAttempts: []int{500}},
+		{desc: "retried", policy: &RetryPolicy{MaxAttempts: 3}, fails: 2, status: 500, wantAttempts: []int{500, 500, 200}},
+		{desc: "retried-post", policy: &RetryPolicy{MaxAttempts: 3}, post: true, fails: 2, status: 502, wantAttempts: []int{502, 502, 200}},
+		{desc: "max-attempts", policy: &RetryPolicy{MaxAttempts: 2}, fails: 3, status: 500, wantErr: true, wantAttempts: []int{500, 500}},
+		{desc: "not-retryable", policy: &RetryPolicy{MaxAttempts: 3}, fails: 1, status: 404, wantErr: true, wantAttempts: []int{404}},
+		{desc: "timeout", policy: &RetryPolicy{}, fails: 2, status: 408, wantAttempts: []int{408, 408, 200}},
+		{desc: "custom-codes", policy: &RetryPolicy{RetryableStatusCodes: []int{404}}, fails: 1, status: 404, wantAttempts: []int{404, 200}},
+		{desc: "retry-after", policy: &RetryPolicy{}, fails: 1, status: 429, retryAfter: "7", wantAttempts: []int{429, 200}, wantOverride: 7 * time.Second},
+		{desc: "max-retry-after", policy: &RetryPolicy{MaxRetryAfter: time.Second}, fails: 1, status: 429, retryAfter: "7", wantAttempts: []int{429, 200}, wantOverride: time.Second},
+		{desc: "ignore-retry-after", policy: &RetryPolicy{IgnoreRetryAfter: true}, fails: 1, status: 429, retryAfter: "7", wantAttempts: []int{429, 200}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := flakyServer(tc.fails, tc.status, tc.retryAfter)
+			defer ts.Close()
+			if tc.policy != nil {
+				tc.policy.Jitter = -1
+			}
+			c, err := New(ts.URL, ts.Client(), Options{RetryPolicy: tc.policy, Logger: nopLogger{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			mb := &mockBackoff{}
+			c.backoff = mb
+
+			attempts := &Attempts{}
+			ctx := WithAttempts(context.Background(), attempts)
+			var got TestStruct
+			if tc.post {
+				_, _, err = c.PostAndParseWithRetry(ctx, "/path", nil, &got)
+			} else {
+				_, _, err = c.GetAndParse(ctx, "/path", nil, &got)
+			}
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("got err %v, want error: %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				var rspErr RspError
+				if !errors.As(err, &rspErr) || rspErr.StatusCode != tc.status {
+					t.Errorf("got err %v, want RspError with status %d", err, tc.status)
+				}
+			}
+
+			list := attempts.List()
+			if len(list) != len(tc.wantAttempts) {
+				t.Fatalf("got %d attempts, want %d", len(list), len(tc.wantAttempts))
+			}
+			for i, a := range list {
+				if a.Number != i+1 || a.StatusCode != tc.wantAttempts[i] || a.Path != "/path" {
+					t.Errorf("attempt %d: got %+v, want status %d", i, a, tc.wantAttempts[i])
+				}
+				if wantRetried := i < len(list)-1; a.Retried != wantRetried {
+					t.Errorf("attempt %d: Retried=%v, want %v", i, a.Retried, wantRetried)
+				}
+				if gotErr := a.Err != nil; gotErr != (a.StatusCode != http.StatusOK) {
+					t.Errorf("attempt %d: Err=%v with status %d", i, a.Err, a.StatusCode)
+				}
+			}
+			if !fuzzyDurationEquals(tc.wantOverride, mb.override, 100*time.Millisecond) {
+				t.Errorf("got backoff override %s, want %s", mb.override, tc.wantOverride)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDeadline(t *testing.T) {
+	ts := flakyServer(10, http.StatusServiceUnavailable, "10")
+	defer ts.Close()
+	c, err := New(ts.URL, ts.Client(), Options{RetryPolicy: &RetryPolicy{Deadline: 100 * time.Millisecond}, Logger: nopLogger{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	var got TestStruct
+	if _, _, err := c.GetAndParse(context.Background(), "/path", nil, &got); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetAndParse()=%v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetAndParse() took %s, want it bounded by the deadline", elapsed)
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
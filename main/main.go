@@ -1,52 +1,83 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The main binary dumps the DNS names of the [pre-]certificates in a CT log
+// to CSV, newline-delimited JSON or SQLite files, one row per name. Output can
+// be sharded by entry index range, and an interrupted dump resumes from an
+// on-disk checkpoint.
 package main
 
 import (
-	"bufio"
 	"context"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
 	ct "github.com/google/certificate-transparency-go"
 	"github.com/google/certificate-transparency-go/client"
 	"github.com/google/certificate-transparency-go/jsonclient"
 	"github.com/google/certificate-transparency-go/scanner"
 	"github.com/google/certificate-transparency-go/x509"
-	"github.com/klauspost/compress/zstd"
+	"github.com/transparency-dev/merkle/rfc6962"
 	"golang.org/x/sync/errgroup"
-	"io"
-	"log"
-	"net/http"
-	"os"
-	"runtime"
-	"time"
+	"k8s.io/klog/v2"
 )
 
 var (
-	out       = flag.String("out", "", "")
-	from      = flag.Int64("from", 0, "")
-	to        = flag.Int64("to", 0, "")
-	url       = flag.String("url", "", "")
-	noPrecert = flag.Bool("no_precert", false, "")
+	out            = flag.String("out", "", "Output file; compressed with zstd if it ends in .zst")
+	format         = flag.String("format", "csv", "Output format: csv, json (one object per line) or sqlite")
+	from           = flag.Int64("from", 0, "Index of the first entry to dump")
+	to             = flag.Int64("to", 0, "Index after the last entry to dump, or 0 for the tree size")
+	url            = flag.String("url", "", "CT log base URL")
+	noPrecert      = flag.Bool("no_precert", false, "Skip precertificates")
+	includeExpired = flag.Bool("include_expired", false, "Also dump certificates that have expired")
+	shardSize      = flag.Int64("shard_size", 0, "If positive, write entries to one file per aligned index range of this size")
+	checkpointFile = flag.String("checkpoint_file", "", "File for resuming an interrupted dump; defaults to <out>.checkpoint")
+	batchSize      = flag.Int("batch_size", 1000, "Max number of entries to request per call to get-entries")
+	parallelFetch  = flag.Int("parallel_fetch", 10, "Number of concurrent get-entries fetches")
+	numWorkers     = flag.Int("num_workers", 10, "Number of concurrent entry parsers")
 )
 
 func main() {
-	runtime.GOMAXPROCS(20)
+	klog.InitFlags(nil)
 	flag.Parse()
 
-	if out == nil || *out == "" {
-		panic("Please provide 'out'")
+	if *out == "" {
+		klog.Exit("Please provide --out")
 	}
-	if url == nil || *url == "" {
-		panic("Please provide 'url'")
+	if *url == "" {
+		klog.Exit("Please provide --url")
 	}
-
-	fmt.Println("out: ", *out)
-	fmt.Println("url: ", *url)
-
-	out, closeFunc, err := getFileWriter(*out, zstd.SpeedBetterCompression)
-	if err != nil {
-		panic(err)
+	openSink, ok := sinkFormats[*format]
+	if !ok {
+		klog.Exitf("Unknown --format %q", *format)
 	}
-	defer closeFunc()
+	cpPath := *checkpointFile
+	if cpPath == "" {
+		cpPath = *out + ".checkpoint"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	hc := &http.Client{
 		Timeout: 30 * time.Second,
@@ -60,155 +91,299 @@ func main() {
 			ExpectContinueTimeout: 1 * time.Second,
 		},
 	}
-
 	c, err := client.New(*url, hc, jsonclient.Options{UserAgent: "ct-go-sctscan/1.0"})
 	if err != nil {
-		panic(err)
+		klog.Exitf("Failed to create log client: %v", err)
 	}
-	opts := *scanner.DefaultScannerOptions()
-	opts.NumWorkers = 10
-	opts.ParallelFetch = 10
-	opts.EndIndex = *to
-	opts.StartIndex = *from
-
-	s := scanner.NewScanner(c, opts)
-
-	ctx := context.Background()
 
-	type resultEntry struct {
-		index     int64
-		name      string
-		isPrecert int
-		validTo   int64
-		validFrom int64
+	store := scanner.NewFileCheckpointStore(cpPath)
+	resumeFrom, err := prepareResume(ctx, store, *shardSize)
+	if err != nil {
+		klog.Exitf("Failed to prepare resumption: %v", err)
 	}
 
-	nameChan := make(chan resultEntry, 1000)
-	done := make(chan bool)
-	go func() {
-		for entry := range nameChan {
-			if _, err := out.Write([]byte(fmt.Sprintf("%v,%v,%v,%v,%v\n", entry.index, entry.name, entry.isPrecert, entry.validFrom, entry.validTo))); err != nil {
-				panic(err)
-			}
-		}
-		done <- true
-	}()
-
-	now := time.Now()
-
-	f := scanner.NewFetcher(c, &opts.FetcherOptions)
+	opts := scanner.DefaultFetcherOptions()
+	opts.BatchSize = *batchSize
+	opts.ParallelFetch = *parallelFetch
+	opts.StartIndex = *from
+	opts.EndIndex = *to
+	opts.Ordered = true
+	opts.Checkpoints = store
+	f := scanner.NewFetcher(c, opts)
 	sth, err := f.Prepare(ctx)
 	if err != nil {
-		panic(err)
+		klog.Exitf("Failed to get STH: %v", err)
 	}
-	log.Printf("Got STH: %v", sth)
-
-	g := errgroup.Group{}
-	g.SetLimit(2000)
+	klog.Infof("Dumping entries [%d, %d) of tree size %d", opts.StartIndex, opts.EndIndex, sth.TreeSize)
 
-	err = s.Scan(ctx, func(entry *ct.RawLogEntry) {
-		g.Go(func() error {
-			if entry.Index%10000 == 0 {
-				log.Printf("At %v", entry.Index)
-			}
+	o := &output{path: *out, open: openSink, shardSize: *shardSize}
+	if resumeFrom < 0 || resumeFrom != opts.StartIndex {
+		// The checkpoint is not used, e.g. because --from is beyond it.
+		resumeFrom = -1
+	}
+	if err := o.start(opts.StartIndex, resumeFrom); err != nil {
+		klog.Exitf("Failed to open output: %v", err)
+	}
 
-			parsedEntry, err := entry.ToLogEntry()
-			if x509.IsFatal(err) || parsedEntry.X509Cert == nil {
-				log.Printf("Process cert at index %d: <unparsed: %v>", entry.Index, err)
-				return nil
-			}
+	d := &dumper{logURL: c.BaseURI(), now: time.Now(), workers: *numWorkers}
+	started := time.Now()
+	err = f.Run(ctx, func(b scanner.EntryBatch) {
+		// Exit on any output error, so that the checkpoint is not moved past
+		// entries which haven't been written.
+		if err := o.writeBatch(b, d.records(b)); err != nil {
+			klog.Exitf("Failed to write entries [%d, %d): %v", b.Start, b.Start+int64(len(b.Entries)), err)
+		}
+		if end := b.Start + int64(len(b.Entries)); b.Start/10000 != end/10000 {
+			klog.Infof("At %d", end)
+		}
+	})
+	if err != nil {
+		klog.Exitf("Fetching failed: %v", err)
+	}
+	complete := ctx.Err() == nil && o.next == opts.EndIndex
+	if err := o.finish(complete); err != nil {
+		klog.Exitf("Failed to close output: %v", err)
+	}
+	if !complete {
+		klog.Exitf("Interrupted at %d, run again to resume", o.next)
+	}
+	klog.Infof("Dumped entries [%d, %d) in %v", *from, o.next, time.Since(started))
+}
 
-			if now.After(parsedEntry.X509Cert.NotAfter) {
-				return nil
+// prepareResume returns the index at which the output should be resumed from
+// the checkpoint in store, or -1 if there is none. When sharding, the
+// checkpoint is rewound to the start of its shard, so that the partially
+// written shard is recreated from scratch.
+func prepareResume(ctx context.Context, store scanner.CheckpointStore, shardSize int64) (int64, error) {
+	cp, err := store.Load(ctx)
+	if err != nil || cp == nil {
+		return -1, err
+	}
+	if shardSize > 0 {
+		if start := cp.NextIndex - cp.NextIndex%shardSize; start != cp.NextIndex {
+			cp.NextIndex = start
+			// The compact range is only valid at the saved index.
+			cp.CompactRange = nil
+			if err := store.Save(ctx, cp); err != nil {
+				return -1, err
 			}
+		}
+	}
+	return cp.NextIndex, nil
+}
 
-			for _, dn := range getDomainNames(parsedEntry) {
-				nameChan <- resultEntry{name: dn, index: entry.Index, isPrecert: 0, validFrom: parsedEntry.X509Cert.NotBefore.Unix(), validTo: parsedEntry.X509Cert.NotAfter.Unix()}
-			}
+// dumper turns log entries into output records.
+type dumper struct {
+	logURL  string
+	now     time.Time
+	workers int
+}
 
-			return nil
-		})
-	}, func(entry *ct.RawLogEntry) {
+// records returns the output records for the entries in b, in index order.
+func (d *dumper) records(b scanner.EntryBatch) []record {
+	perEntry := make([][]record, len(b.Entries))
+	var g errgroup.Group
+	g.SetLimit(d.workers)
+	for i := range b.Entries {
+		i := i
 		g.Go(func() error {
-			if entry.Index%10000 == 0 {
-				log.Printf("At %v", entry.Index)
-			}
-
-			if *noPrecert {
-				return nil
-			}
-
-			parsedEntry, err := entry.ToLogEntry()
-
-			if x509.IsFatal(err) || parsedEntry.Precert == nil || parsedEntry.Precert.TBSCertificate == nil {
-				log.Printf("Process precert at index %d: <unparsed: %v>", entry.Index, err)
-				return nil
-			}
-
-			if now.After(parsedEntry.Precert.TBSCertificate.NotAfter) {
-				return nil
-			}
-
-			for _, dn := range getDomainNames(parsedEntry) {
-				nameChan <- resultEntry{name: dn, index: entry.Index, isPrecert: 1, validFrom: parsedEntry.Precert.TBSCertificate.NotBefore.Unix(), validTo: parsedEntry.Precert.TBSCertificate.NotAfter.Unix()}
-			}
-
+			perEntry[i] = d.entryRecords(b.Start+int64(i), &b.Entries[i])
 			return nil
 		})
-	})
-
-	log.Println("Took ", time.Since(now))
-
-	g.Wait()
-	close(nameChan)
-	<-done
-	if err != nil {
-		panic(err)
 	}
+	_ = g.Wait() // Never fails.
 
+	var recs []record
+	for _, r := range perEntry {
+		recs = append(recs, r...)
+	}
+	return recs
 }
 
-// Prints out a short bit of info about |cert|, found at |index| in the
-// specified log
-func getDomainNames(entry *ct.LogEntry) []string {
-	nameMap := make(map[string]any)
-
-	if entry.X509Cert != nil {
-		for _, name := range entry.X509Cert.DNSNames {
-			nameMap[name] = nil
+// entryRecords returns one record for each DNS name in the given entry, or
+// none if it is filtered out or can't be parsed.
+func (d *dumper) entryRecords(index int64, leaf *ct.LeafEntry) []record {
+	rawEntry, err := ct.RawLogEntryFromLeaf(index, leaf)
+	if err != nil {
+		klog.Warningf("Failed to parse leaf at index %d: %v", index, err)
+		return nil
+	}
+	precert := rawEntry.Leaf.TimestampedEntry.EntryType == ct.PrecertLogEntryType
+	if precert && *noPrecert {
+		return nil
+	}
+	if !*includeExpired {
+		// Skip most expired certificates without a full parse.
+		if _, notAfter, err := scanner.LeafValidity(leaf); err == nil && d.now.After(notAfter) {
+			return nil
 		}
 	}
+	entry, err := rawEntry.ToLogEntry()
+	if x509.IsFatal(err) {
+		klog.Warningf("Failed to parse [pre-]certificate at index %d: %v", index, err)
+		return nil
+	}
+	var cert *x509.Certificate
+	switch {
+	case entry.X509Cert != nil:
+		cert = entry.X509Cert
+	case entry.Precert != nil && entry.Precert.TBSCertificate != nil:
+		cert = entry.Precert.TBSCertificate
+	default:
+		klog.Warningf("No [pre-]certificate at index %d", index)
+		return nil
+	}
+	if !*includeExpired && d.now.After(cert.NotAfter) {
+		return nil
+	}
 
-	if entry.Precert != nil && entry.Precert.TBSCertificate != nil {
-		for _, name := range entry.Precert.TBSCertificate.DNSNames {
-			nameMap[name] = nil
-		}
+	names := getDomainNames(cert)
+	if len(names) == 0 {
+		return nil
 	}
+	template := record{
+		LogURL:    d.logURL,
+		Index:     index,
+		LeafHash:  hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(leaf.LeafInput)),
+		Precert:   precert,
+		Issuer:    cert.Issuer.String(),
+		Timestamp: rawEntry.Leaf.TimestampedEntry.Timestamp,
+		NotBefore: cert.NotBefore.Unix(),
+		NotAfter:  cert.NotAfter.Unix(),
+	}
+	if cert.SerialNumber != nil {
+		template.Serial = cert.SerialNumber.Text(16)
+	}
+	recs := make([]record, len(names))
+	for i, name := range names {
+		recs[i] = template
+		recs[i].Name = name
+	}
+	return recs
+}
 
+// getDomainNames returns the distinct DNS names in cert, sorted.
+func getDomainNames(cert *x509.Certificate) []string {
+	nameMap := make(map[string]bool)
+	for _, name := range cert.DNSNames {
+		nameMap[name] = true
+	}
 	names := make([]string, 0, len(nameMap))
 	for name := range nameMap {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
-func getFileWriter(path string, level zstd.EncoderLevel) (io.Writer, func() error, error) {
-	outFile, err := os.Create(path)
-	if err != nil {
-		return nil, nil, err
+// output writes records to a single file, or to one file per shard of
+// shardSize entries. Shards are aligned to multiples of shardSize, and are
+// written to a ".partial" file which is renamed once the shard is complete.
+type output struct {
+	path      string
+	open      openSinkFunc
+	shardSize int64
+
+	sink       sink
+	shardStart int64 // The shard range, only used when sharding.
+	shardEnd   int64
+	shardFirst int64 // The first entry written to the shard.
+	next       int64 // The index after the last written entry.
+}
+
+// start opens the output at entry index start. If resumeFrom is non-negative,
+// an existing unsharded output is appended to.
+func (o *output) start(start, resumeFrom int64) error {
+	o.next = start
+	if o.shardSize <= 0 {
+		var err error
+		o.sink, err = o.open(o.path, resumeFrom)
+		return err
 	}
+	return o.openShard(start)
+}
 
-	bufWriter := bufio.NewWriter(outFile)
+func (o *output) openShard(index int64) error {
+	o.shardStart = index - index%o.shardSize
+	o.shardEnd = o.shardStart + o.shardSize
+	o.shardFirst = index
+	var err error
+	o.sink, err = o.open(o.partialPath(), -1)
+	return err
+}
 
-	zw, err := zstd.NewWriter(bufWriter, zstd.WithEncoderLevel(level))
-	if err != nil {
-		outFile.Close()
-		return nil, nil, err
+// shardPath returns the path of the shard for entries [start, end), which
+// has the range inserted before the extensions of the output path, e.g.
+// names.000000001000-000000002000.csv.zst.
+func (o *output) shardPath(start, end int64) string {
+	dir, base := filepath.Split(o.path)
+	ext := ""
+	if i := strings.Index(base, "."); i > 0 {
+		base, ext = base[:i], base[i:]
 	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%012d-%012d%s", base, start, end, ext))
+}
 
-	return zw, func() error {
-		zw.Close()
-		bufWriter.Flush()
-		return outFile.Close()
-	}, nil
+func (o *output) partialPath() string {
+	return o.shardPath(o.shardStart, o.shardEnd) + ".partial"
+}
+
+// closeShard closes the current shard, and renames it to cover the entries
+// written to it, up to end, if complete.
+func (o *output) closeShard(end int64, complete bool) error {
+	if err := o.sink.Close(); err != nil {
+		return err
+	}
+	o.sink = nil
+	if !complete {
+		return nil
+	}
+	return os.Rename(o.partialPath(), o.shardPath(o.shardFirst, end))
+}
+
+// writeBatch writes the records of batch b, which must directly follow the
+// previous batch, and makes them durable.
+func (o *output) writeBatch(b scanner.EntryBatch, recs []record) error {
+	if b.Start != o.next {
+		return fmt.Errorf("got batch at %d, want %d", b.Start, o.next)
+	}
+	end := b.Start + int64(len(b.Entries))
+	for len(recs) > 0 || (o.shardSize > 0 && end >= o.shardEnd) {
+		n := len(recs)
+		if o.shardSize > 0 {
+			n = sort.Search(len(recs), func(i int) bool { return recs[i].Index >= o.shardEnd })
+		}
+		if n > 0 {
+			if err := o.sink.Write(recs[:n]); err != nil {
+				return err
+			}
+			recs = recs[n:]
+		}
+		if o.shardSize > 0 && end >= o.shardEnd {
+			if err := o.closeShard(o.shardEnd, true); err != nil {
+				return err
+			}
+			if err := o.openShard(o.shardEnd); err != nil {
+				return err
+			}
+		}
+	}
+	o.next = end
+	return o.sink.Flush()
+}
+
+// finish closes the output. If complete, the last shard is renamed to cover
+// the entries written so far, otherwise it's left to be resumed.
+func (o *output) finish(complete bool) error {
+	if o.shardSize <= 0 {
+		return o.sink.Close()
+	}
+	if o.next == o.shardFirst {
+		// The shard is empty, e.g. as the dump ended on a shard boundary.
+		if err := o.sink.Close(); err != nil {
+			return err
+		}
+		return os.Remove(o.partialPath())
+	}
+	return o.closeShard(o.next, complete)
 }
@@ -0,0 +1,530 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	_ "github.com/mattn/go-sqlite3" // Load drivers for sqlite3
+)
+
+// record is a single output row, describing one DNS name found in one log
+// entry.
+type record struct {
+	LogURL    string `json:"log_url"`
+	Index     int64  `json:"index"`
+	LeafHash  string `json:"leaf_hash"` // Hex-encoded RFC 6962 leaf hash.
+	Name      string `json:"name"`
+	Precert   bool   `json:"precert"`
+	Issuer    string `json:"issuer"`
+	Serial    string `json:"serial"`        // Hex-encoded serial number.
+	Timestamp uint64 `json:"sct_timestamp"` // Milliseconds since the epoch.
+	NotBefore int64  `json:"not_before"`    // Seconds since the epoch.
+	NotAfter  int64  `json:"not_after"`     // Seconds since the epoch.
+}
+
+var csvHeader = []string{"log_url", "index", "leaf_hash", "name", "precert", "issuer", "serial", "sct_timestamp", "not_before", "not_after"}
+
+func (r *record) csvFields() []string {
+	return []string{
+		r.LogURL,
+		strconv.FormatInt(r.Index, 10),
+		r.LeafHash,
+		r.Name,
+		strconv.FormatBool(r.Precert),
+		r.Issuer,
+		r.Serial,
+		strconv.FormatUint(r.Timestamp, 10),
+		strconv.FormatInt(r.NotBefore, 10),
+		strconv.FormatInt(r.NotAfter, 10),
+	}
+}
+
+// sink is a destination for records.
+type sink interface {
+	// Write stores the given records, which are ordered by index.
+	Write(recs []record) error
+	// Flush makes all written records durable.
+	Flush() error
+	// Close flushes and releases the sink.
+	Close() error
+}
+
+// openSinkFunc opens a sink writing to the file at path. If resumeFrom is
+// non-negative, the file is appended to rather than replaced, once records
+// with index resumeFrom or above, and any incomplete record left by a crash,
+// are discarded.
+type openSinkFunc func(path string, resumeFrom int64) (sink, error)
+
+// sinkFormats holds the supported output formats, keyed by name.
+var sinkFormats = map[string]openSinkFunc{
+	"csv":    openCSVSink,
+	"json":   openJSONSink,
+	"sqlite": openSQLiteSink,
+}
+
+// validPrefixFunc returns the length of the longest prefix of the data read
+// from r that holds complete records with an index below resumeFrom, and any
+// header. Data that can't be parsed ends the prefix, rather than being an
+// error.
+type validPrefixFunc func(r io.Reader, resumeFrom int64) (int64, error)
+
+// fileWriter is a buffered, optionally zstd-compressed, output file. When
+// compressing, each Flush ends a zstd frame, so that the file can be resumed
+// after the last complete frame.
+type fileWriter struct {
+	f  *os.File
+	bw *bufio.Writer
+	zw *zstd.Encoder // Nil if not compressing.
+	w  io.Writer
+	// Whether the file had no content when opened.
+	empty bool
+}
+
+// openFileWriter opens the file at path for writing. Output is
+// zstd-compressed if the path ends in ".zst". If resumeFrom is non-negative,
+// the file is truncated to the prefix that valid accepts, and appended to;
+// when compressing, it is truncated to a complete zstd frame, and the valid
+// records of the frame after it are written again.
+func openFileWriter(path string, resumeFrom int64, valid validPrefixFunc) (*fileWriter, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resumeFrom >= 0 {
+		flags = os.O_RDWR | os.O_CREATE
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	compressed := strings.HasSuffix(path, ".zst")
+	var size int64
+	var carry []byte
+	if resumeFrom >= 0 {
+		if compressed {
+			size, carry, err = truncateZstd(f, resumeFrom, valid)
+		} else {
+			size, err = truncatePlain(f, resumeFrom, valid)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to truncate %s for resuming: %v", path, err)
+		}
+	}
+	fw := &fileWriter{f: f, bw: bufio.NewWriter(f), empty: size == 0 && len(carry) == 0}
+	fw.w = fw.bw
+	if compressed {
+		if fw.zw, err = zstd.NewWriter(fw.bw, zstd.WithEncoderLevel(zstd.SpeedBetterCompression)); err != nil {
+			f.Close()
+			return nil, err
+		}
+		fw.w = fw.zw
+	}
+	if _, err := fw.w.Write(carry); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	return fw, nil
+}
+
+// truncatePlain truncates the uncompressed file f to its valid prefix, and
+// returns its new size.
+func truncatePlain(f *os.File, resumeFrom int64, valid validPrefixFunc) (int64, error) {
+	n, err := valid(bufio.NewReader(f), resumeFrom)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Truncate(n); err != nil {
+		return 0, err
+	}
+	_, err = f.Seek(n, io.SeekStart)
+	return n, err
+}
+
+// truncateZstd truncates the zstd-compressed file f after its last complete
+// frame whose records are all valid, and returns its new size, along with
+// the valid records of the next frame. Frames are checked from the end, as
+// only the last few can hold records beyond the checkpoint.
+func truncateZstd(f *os.File, resumeFrom int64, valid validPrefixFunc) (int64, []byte, error) {
+	frames, err := zstdFrames(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer dec.Close()
+	var size int64
+	var carry []byte
+	for i := len(frames) - 1; i >= 0; i-- {
+		frame := make([]byte, frames[i].end-frames[i].start)
+		if _, err := f.ReadAt(frame, frames[i].start); err != nil {
+			return 0, nil, err
+		}
+		content, err := dec.DecodeAll(frame, nil)
+		if err != nil {
+			continue
+		}
+		n, err := valid(bytes.NewReader(content), resumeFrom)
+		if err != nil {
+			return 0, nil, err
+		}
+		if n == int64(len(content)) {
+			size = frames[i].end
+			break
+		}
+		if n > 0 {
+			size, carry = frames[i].start, content[:n]
+			break
+		}
+	}
+	if err := f.Truncate(size); err != nil {
+		return 0, nil, err
+	}
+	_, err = f.Seek(size, io.SeekStart)
+	return size, carry, err
+}
+
+// zstdFrame is the byte range of a zstd frame in a file.
+type zstdFrame struct {
+	start, end int64
+}
+
+// zstdFrames returns the complete zstd frames at the start of f, by walking
+// their block headers. It stops at the end of the file, or at the first frame
+// that is incomplete or can't be parsed.
+func zstdFrames(f *os.File) ([]zstdFrame, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	// readAt reads up to len(buf) bytes at off, and returns the number read.
+	readAt := func(buf []byte, off int64) (int, error) {
+		n, err := f.ReadAt(buf, off)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return n, err
+	}
+	var frames []zstdFrame
+	buf := make([]byte, zstd.HeaderMaxSize)
+	for start := int64(0); start < size; {
+		n, err := readAt(buf, start)
+		if err != nil {
+			return nil, err
+		}
+		var h zstd.Header
+		if h.Decode(buf[:n]) != nil {
+			break
+		}
+		pos := start + int64(h.HeaderSize)
+		if h.Skippable {
+			pos += int64(h.SkippableSize)
+		} else {
+			for last := false; !last; {
+				if n, err := readAt(buf[:3], pos); err != nil {
+					return nil, err
+				} else if n < 3 {
+					return frames, nil
+				}
+				bh := uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16
+				last = bh&1 == 1
+				blockSize := int64(bh >> 3)
+				switch (bh >> 1) & 3 {
+				case 1: // RLE blocks hold a single byte.
+					blockSize = 1
+				case 3: // Reserved.
+					return frames, nil
+				}
+				pos += 3 + blockSize
+			}
+			if h.HasCheckSum {
+				pos += 4
+			}
+		}
+		if pos > size {
+			break
+		}
+		frames = append(frames, zstdFrame{start: start, end: pos})
+		start = pos
+	}
+	return frames, nil
+}
+
+func (fw *fileWriter) Write(p []byte) (int, error) {
+	return fw.w.Write(p)
+}
+
+// Flush pushes all written data to the file, ending the zstd frame if
+// compressing, and syncs it to disk.
+func (fw *fileWriter) Flush() error {
+	if fw.zw != nil {
+		if err := fw.zw.Close(); err != nil {
+			return err
+		}
+		fw.zw.Reset(fw.bw)
+	}
+	if err := fw.bw.Flush(); err != nil {
+		return err
+	}
+	return fw.f.Sync()
+}
+
+func (fw *fileWriter) Close() error {
+	if fw.zw != nil {
+		if err := fw.zw.Close(); err != nil {
+			fw.f.Close()
+			return err
+		}
+	}
+	if err := fw.bw.Flush(); err != nil {
+		fw.f.Close()
+		return err
+	}
+	return fw.f.Close()
+}
+
+// csvSink writes records as CSV with a header line.
+type csvSink struct {
+	fw *fileWriter
+	cw *csv.Writer
+}
+
+func openCSVSink(path string, resumeFrom int64) (sink, error) {
+	fw, err := openFileWriter(path, resumeFrom, validCSVPrefix)
+	if err != nil {
+		return nil, err
+	}
+	s := &csvSink{fw: fw, cw: csv.NewWriter(fw)}
+	if fw.empty {
+		if err := s.cw.Write(csvHeader); err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// validCSVPrefix is the validPrefixFunc of CSV output.
+func validCSVPrefix(r io.Reader, resumeFrom int64) (int64, error) {
+	lr := &lastByteReader{r: r}
+	cr := csv.NewReader(lr)
+	cr.FieldsPerRecord = len(csvHeader)
+	var n, prev int64
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 || fields[0] != csvHeader[0] {
+			index, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil || index >= resumeFrom {
+				break
+			}
+		}
+		prev, n = n, cr.InputOffset()
+	}
+	// A crash can leave the last record without its newline, and possibly
+	// truncated.
+	if n == lr.n && lr.last != '\n' {
+		n = prev
+	}
+	return n, nil
+}
+
+// lastByteReader counts the bytes read from r, and keeps the last one.
+type lastByteReader struct {
+	r    io.Reader
+	n    int64
+	last byte
+}
+
+func (r *lastByteReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		r.last = p[n-1]
+	}
+	return n, err
+}
+
+func (s *csvSink) Write(recs []record) error {
+	for i := range recs {
+		if err := s.cw.Write(recs[i].csvFields()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *csvSink) Flush() error {
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		return err
+	}
+	return s.fw.Flush()
+}
+
+func (s *csvSink) Close() error {
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		s.fw.Close()
+		return err
+	}
+	return s.fw.Close()
+}
+
+// jsonSink writes records as newline-delimited JSON.
+type jsonSink struct {
+	fw  *fileWriter
+	enc *json.Encoder
+}
+
+func openJSONSink(path string, resumeFrom int64) (sink, error) {
+	fw, err := openFileWriter(path, resumeFrom, validJSONPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSink{fw: fw, enc: json.NewEncoder(fw)}, nil
+}
+
+// validJSONPrefix is the validPrefixFunc of NDJSON output.
+func validJSONPrefix(r io.Reader, resumeFrom int64) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// Drop the last line, which a crash left without its newline.
+			return n, nil
+		} else if err != nil {
+			return 0, err
+		}
+		var rec struct {
+			Index *int64 `json:"index"`
+		}
+		if json.Unmarshal(line, &rec) != nil || rec.Index == nil || *rec.Index >= resumeFrom {
+			return n, nil
+		}
+		n += int64(len(line))
+	}
+}
+
+func (s *jsonSink) Write(recs []record) error {
+	for i := range recs {
+		if err := s.enc.Encode(&recs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSink) Flush() error {
+	return s.fw.Flush()
+}
+
+func (s *jsonSink) Close() error {
+	return s.fw.Close()
+}
+
+// sqliteSink writes records to a "names" table in an SQLite database. Each
+// Write is a single transaction.
+type sqliteSink struct {
+	db *sql.DB
+}
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS names (
+	log_url TEXT NOT NULL,
+	idx INTEGER NOT NULL,
+	leaf_hash TEXT NOT NULL,
+	name TEXT NOT NULL,
+	precert BOOLEAN NOT NULL,
+	issuer TEXT NOT NULL,
+	serial TEXT NOT NULL,
+	sct_timestamp INTEGER NOT NULL,
+	not_before INTEGER NOT NULL,
+	not_after INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS names_idx ON names (idx);`
+
+func openSQLiteSink(path string, resumeFrom int64) (sink, error) {
+	if resumeFrom < 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %v", err)
+	}
+	if resumeFrom >= 0 {
+		// Drop rows that were written after the last checkpoint.
+		if _, err := db.Exec("DELETE FROM names WHERE idx >= ?", resumeFrom); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &sqliteSink{db: db}, nil
+}
+
+func (s *sqliteSink) Write(recs []record) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO names VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range recs {
+		if _, err := stmt.Exec(r.LogURL, r.Index, r.LeafHash, r.Name, r.Precert, r.Issuer, r.Serial, int64(r.Timestamp), r.NotBefore, r.NotAfter); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Flush does nothing, as every Write is committed.
+func (s *sqliteSink) Flush() error {
+	return nil
+}
+
+func (s *sqliteSink) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,393 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/scanner"
+	"github.com/klauspost/compress/zstd"
+)
+
+func testRecords(indices ...int64) []record {
+	var recs []record
+	for _, idx := range indices {
+		recs = append(recs, record{LogURL: "https://log.example.com", Index: idx, Name: "example.com", Serial: "1f"})
+	}
+	return recs
+}
+
+func testBatch(start, size int64) scanner.EntryBatch {
+	return scanner.EntryBatch{Start: start, Entries: make([]ct.LeafEntry, size)}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", path, err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if filepath.Ext(path) == ".zst" {
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatalf("zstd.NewReader(): %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Reading %q: %v", path, err)
+	}
+	return lines
+}
+
+func TestCSVSinkResume(t *testing.T) {
+	for _, name := range []string{"out.csv", "out.csv.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			s, err := openCSVSink(path, -1)
+			if err != nil {
+				t.Fatalf("openCSVSink(): %v", err)
+			}
+			if err := s.Write(testRecords(0, 1)); err != nil {
+				t.Fatalf("Write(): %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close(): %v", err)
+			}
+
+			// Resuming appends without repeating the header.
+			if s, err = openCSVSink(path, 2); err != nil {
+				t.Fatalf("openCSVSink(resume): %v", err)
+			}
+			if err := s.Write(testRecords(2)); err != nil {
+				t.Fatalf("Write(): %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close(): %v", err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Open(): %v", err)
+			}
+			defer f.Close()
+			var r io.Reader = f
+			if filepath.Ext(path) == ".zst" {
+				zr, err := zstd.NewReader(f)
+				if err != nil {
+					t.Fatalf("zstd.NewReader(): %v", err)
+				}
+				defer zr.Close()
+				r = zr
+			}
+			rows, err := csv.NewReader(r).ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll(): %v", err)
+			}
+			if got, want := len(rows), 4; got != want {
+				t.Fatalf("got %d rows, want %d: %v", got, want, rows)
+			}
+			if got, want := rows[0][1], "index"; got != want {
+				t.Errorf("header column 1 = %q, want %q", got, want)
+			}
+			for i, row := range rows[1:] {
+				if got, want := row[1], []string{"0", "1", "2"}[i]; got != want {
+					t.Errorf("row %d index = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// readIndices returns the indices of the records in the CSV or NDJSON file at
+// path.
+func readIndices(t *testing.T, path string) []int64 {
+	t.Helper()
+	lines := readLines(t, path)
+	var indices []int64
+	if strings.Contains(path, ".csv") {
+		if len(lines) == 0 || lines[0] != strings.Join(csvHeader, ",") {
+			t.Fatalf("%s has no header: %v", path, lines)
+		}
+		for _, line := range lines[1:] {
+			index, err := strconv.ParseInt(strings.Split(line, ",")[1], 10, 64)
+			if err != nil {
+				t.Fatalf("Bad row %q: %v", line, err)
+			}
+			indices = append(indices, index)
+		}
+		return indices
+	}
+	for _, line := range lines {
+		var rec record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("Unmarshal(%q): %v", line, err)
+		}
+		indices = append(indices, rec.Index)
+	}
+	return indices
+}
+
+func TestSinkResumeAfterCrash(t *testing.T) {
+	for _, name := range []string{"out.csv", "out.csv.zst", "out.json", "out.json.zst"} {
+		open := openCSVSink
+		if strings.Contains(name, ".json") {
+			open = openJSONSink
+		}
+		for _, resumeFrom := range []int64{2, 3} {
+			t.Run(fmt.Sprintf("%s@%d", name, resumeFrom), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), name)
+				s, err := open(path, -1)
+				if err != nil {
+					t.Fatalf("open(): %v", err)
+				}
+				// The checkpoint is at resumeFrom, but more entries were
+				// written, and the last ones only partially.
+				for _, recs := range [][]record{testRecords(0, 1), testRecords(2, 3), testRecords(4, 5)} {
+					if err := s.Write(recs); err != nil {
+						t.Fatalf("Write(): %v", err)
+					}
+					if err := s.Flush(); err != nil {
+						t.Fatalf("Flush(): %v", err)
+					}
+				}
+				var f *os.File
+				switch s := s.(type) {
+				case *csvSink:
+					f = s.fw.f
+				case *jsonSink:
+					f = s.fw.f
+				}
+				info, err := f.Stat()
+				if err != nil {
+					t.Fatalf("Stat(): %v", err)
+				}
+				if err := f.Truncate(info.Size() - 3); err != nil {
+					t.Fatalf("Truncate(): %v", err)
+				}
+				f.Close()
+
+				if s, err = open(path, resumeFrom); err != nil {
+					t.Fatalf("open(resume): %v", err)
+				}
+				var next []int64
+				for i := resumeFrom; i < 6; i++ {
+					next = append(next, i)
+				}
+				if err := s.Write(testRecords(next...)); err != nil {
+					t.Fatalf("Write(): %v", err)
+				}
+				if err := s.Close(); err != nil {
+					t.Fatalf("Close(): %v", err)
+				}
+
+				want := []int64{0, 1, 2, 3, 4, 5}
+				if got := readIndices(t, path); !reflect.DeepEqual(got, want) {
+					t.Errorf("Got indices %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestValidCSVPrefix(t *testing.T) {
+	header := strings.Join(csvHeader, ",") + "\n"
+	row := func(index int) string {
+		return strings.Join(testRecords(int64(index))[0].csvFields(), ",") + "\n"
+	}
+	for _, tc := range []struct {
+		desc string
+		data string
+		want int
+	}{
+		{desc: "empty", data: "", want: 0},
+		{desc: "header", data: header, want: len(header)},
+		{desc: "partial-header", data: header[:10], want: 0},
+		{desc: "rows", data: header + row(0) + row(1), want: len(header + row(0) + row(1))},
+		{desc: "beyond-checkpoint", data: header + row(0) + row(2) + row(3), want: len(header + row(0))},
+		{desc: "no-newline", data: header + row(0) + strings.TrimSuffix(row(1), "\n"), want: len(header + row(0))},
+		{desc: "truncated", data: header + row(0) + row(1)[:20], want: len(header + row(0))},
+		{desc: "no-header", data: row(0) + row(1), want: len(row(0) + row(1))},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := validCSVPrefix(strings.NewReader(tc.data), 2)
+			if err != nil {
+				t.Fatalf("validCSVPrefix(): %v", err)
+			}
+			if got != int64(tc.want) {
+				t.Errorf("validCSVPrefix()=%d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	s, err := openJSONSink(path, -1)
+	if err != nil {
+		t.Fatalf("openJSONSink(): %v", err)
+	}
+	want := testRecords(3, 4)
+	if err := s.Write(want); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	lines := readLines(t, path)
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		var got record
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("Unmarshal(%q): %v", line, err)
+		}
+		if got != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestSQLiteSinkResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.db")
+	s, err := openSQLiteSink(path, -1)
+	if err != nil {
+		t.Fatalf("openSQLiteSink(): %v", err)
+	}
+	if err := s.Write(testRecords(0, 1, 2, 3)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Resuming drops the rows written beyond the checkpoint.
+	if s, err = openSQLiteSink(path, 2); err != nil {
+		t.Fatalf("openSQLiteSink(resume): %v", err)
+	}
+	if err := s.Write(testRecords(2)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open(): %v", err)
+	}
+	defer db.Close()
+	var count, max int64
+	if err := db.QueryRow("SELECT COUNT(*), MAX(idx) FROM names").Scan(&count, &max); err != nil {
+		t.Fatalf("QueryRow(): %v", err)
+	}
+	if count != 3 || max != 2 {
+		t.Errorf("got %d rows with max index %d, want 3 rows with max index 2", count, max)
+	}
+}
+
+func TestOutputShards(t *testing.T) {
+	dir := t.TempDir()
+	o := &output{path: filepath.Join(dir, "names.json"), open: openJSONSink, shardSize: 10}
+	if err := o.start(5, -1); err != nil {
+		t.Fatalf("start(): %v", err)
+	}
+	for _, b := range []struct {
+		batch scanner.EntryBatch
+		recs  []record
+	}{
+		{batch: testBatch(5, 4), recs: testRecords(5, 8)},
+		{batch: testBatch(9, 12), recs: testRecords(9, 10, 20)},
+		{batch: testBatch(21, 4), recs: testRecords(24)},
+	} {
+		if err := o.writeBatch(b.batch, b.recs); err != nil {
+			t.Fatalf("writeBatch(%d): %v", b.batch.Start, err)
+		}
+	}
+	if err := o.writeBatch(testBatch(30, 1), nil); err == nil {
+		t.Error("writeBatch() with a gap succeeded, want error")
+	}
+	if err := o.finish(true); err != nil {
+		t.Fatalf("finish(): %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := map[string]int{
+		"names.000000000005-000000000010.json": 3,
+		"names.000000000010-000000000020.json": 1,
+		"names.000000000020-000000000025.json": 2,
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got files %v, want %d shards", files, len(want))
+	}
+	for _, f := range files {
+		n, ok := want[filepath.Base(f)]
+		if !ok {
+			t.Errorf("unexpected file %q", f)
+			continue
+		}
+		if got := len(readLines(t, f)); got != n {
+			t.Errorf("%s has %d lines, want %d", filepath.Base(f), got, n)
+		}
+	}
+}
+
+func TestPrepareResumeRewindsToShard(t *testing.T) {
+	ctx := context.Background()
+	store := &scanner.MemoryCheckpointStore{}
+	if got, err := prepareResume(ctx, store, 10); err != nil || got != -1 {
+		t.Fatalf("prepareResume(no checkpoint) = %d, %v; want -1, nil", got, err)
+	}
+	if err := store.Save(ctx, &scanner.Checkpoint{NextIndex: 27}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := prepareResume(ctx, store, 10); err != nil || got != 20 {
+		t.Fatalf("prepareResume() = %d, %v; want 20, nil", got, err)
+	}
+	cp, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.NextIndex != 20 {
+		t.Errorf("saved checkpoint at %d, want 20", cp.NextIndex)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// Checkpoint records the progress of a scan, so that it can be resumed.
+type Checkpoint struct {
+	// NextIndex is the index of the first entry that has not been fully
+	// processed. All entries in [StartIndex, NextIndex) have been processed.
+	NextIndex int64 `json:"next_index"`
+	// STH is the tree head that the scan was working towards when the
+	// checkpoint was taken. NextIndex never exceeds STH.TreeSize.
+	STH *ct.SignedTreeHead `json:"sth,omitempty"`
+	// CompactRange holds the hashes of the compact Merkle tree range covering
+	// entries [0, NextIndex). It is only populated when the Fetcher verifies
+	// entries, and lets a verifying scan resume from NextIndex.
+	CompactRange [][]byte `json:"compact_range,omitempty"`
+}
+
+// CheckpointStore persists scan Checkpoints. Implementations must be safe for
+// concurrent use.
+type CheckpointStore interface {
+	// Load returns the most recently saved Checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*Checkpoint, error)
+	// Save replaces the stored Checkpoint with cp.
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore that keeps the Checkpoint in
+// memory. It is mostly useful for tests and for sharing progress between
+// Scanner runs within a single process.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp *Checkpoint
+}
+
+// Load returns a copy of the stored Checkpoint, or nil if there is none.
+func (m *MemoryCheckpointStore) Load(_ context.Context) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cp == nil {
+		return nil, nil
+	}
+	cp := *m.cp
+	return &cp, nil
+}
+
+// Save stores a copy of cp.
+func (m *MemoryCheckpointStore) Save(_ context.Context, cp *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *cp
+	m.cp = &saved
+	return nil
+}
+
+// FileCheckpointStore is a CheckpointStore that keeps the Checkpoint as JSON
+// in a file. Updates are written to a temporary file which is then renamed
+// over the original, so a crash never leaves a partially written checkpoint.
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointStore returns a FileCheckpointStore that uses the file at
+// path. The file does not need to exist yet.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load reads the Checkpoint from the file, returning nil if the file does not
+// exist.
+func (f *FileCheckpointStore) Load(_ context.Context) (*Checkpoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %q: %v", f.path, err)
+	}
+	return &cp, nil
+}
+
+// Save atomically replaces the contents of the file with cp.
+func (f *FileCheckpointStore) Save(_ context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Does nothing after a successful rename.
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
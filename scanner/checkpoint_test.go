@@ -0,0 +1,44 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	if cp, err := store.Load(ctx); err != nil || cp != nil {
+		t.Fatalf("Load()=%v, %v; want nil, nil", cp, err)
+	}
+	want := &Checkpoint{NextIndex: 42, STH: &ct.SignedTreeHead{TreeSize: 100, Timestamp: 12345}}
+	want.STH.SHA256RootHash[0] = 0xAB
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save()=%v", err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load()=%v", err)
+	}
+	if got.NextIndex != want.NextIndex || got.STH.TreeSize != want.STH.TreeSize || got.STH.SHA256RootHash != want.STH.SHA256RootHash {
+		t.Errorf("Load()=%+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"fmt"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+// leafInput is a log entry being matched, which is parsed lazily and at most
+// once however many matchers look at it.
+type leafInput struct {
+	leaf *ct.LeafEntry
+
+	leafParsed bool
+	mtl        *ct.MerkleTreeLeaf // Nil if the leaf failed to parse.
+
+	entryParsed bool
+	entry       *ct.LogEntry // Nil if the entry failed to parse.
+}
+
+// merkleTreeLeaf returns the parsed MerkleTreeLeaf, which is cheap to get as
+// it doesn't involve parsing the [pre-]certificate.
+func (in *leafInput) merkleTreeLeaf() *ct.MerkleTreeLeaf {
+	if !in.leafParsed {
+		in.leafParsed = true
+		var mtl ct.MerkleTreeLeaf
+		if rest, err := tls.Unmarshal(in.leaf.LeafInput, &mtl); err == nil && len(rest) == 0 {
+			in.mtl = &mtl
+		}
+	}
+	return in.mtl
+}
+
+// logEntry returns the fully parsed entry.
+func (in *leafInput) logEntry() *ct.LogEntry {
+	if !in.entryParsed {
+		in.entryParsed = true
+		in.entry, _ = ct.LogEntryFromLeaf(1, in.leaf)
+	}
+	return in.entry
+}
+
+// inputMatcher is implemented by matchers which can share a leafInput with
+// other matchers, e.g. the boolean combinators.
+type inputMatcher interface {
+	matchInput(in *leafInput) bool
+}
+
+// matchInput applies m, which must be a Matcher or LeafMatcher, to in. Entries
+// that fail to parse never match a Matcher.
+func matchInput(m interface{}, in *leafInput) bool {
+	switch m := m.(type) {
+	case inputMatcher:
+		return m.matchInput(in)
+	case Matcher:
+		entry := in.logEntry()
+		switch {
+		case entry == nil:
+			return false
+		case entry.X509Cert != nil:
+			return m.CertificateMatches(entry.X509Cert)
+		case entry.Precert != nil:
+			return m.PrecertificateMatches(entry.Precert)
+		}
+		return false
+	case LeafMatcher:
+		return m.Matches(in.leaf)
+	}
+	return false
+}
+
+// checkMatchers panics if any of ms is neither a Matcher nor a LeafMatcher,
+// as that's a programming error.
+func checkMatchers(ms []interface{}) {
+	for i, m := range ms {
+		switch m.(type) {
+		case Matcher, LeafMatcher:
+		default:
+			panic(fmt.Sprintf("matcher %d has unexpected type %T", i, m))
+		}
+	}
+}
+
+// AndMatcher is a LeafMatcher which matches entries that match all of its
+// sub-matchers. Sub-matchers are evaluated left to right, stopping at the
+// first one that doesn't match, so cheap ones should come first.
+type AndMatcher struct {
+	matchers []interface{}
+}
+
+// And returns a matcher for entries that match all of ms, each of which must
+// be a Matcher or a LeafMatcher. An empty And matches everything.
+func And(ms ...interface{}) *AndMatcher {
+	checkMatchers(ms)
+	return &AndMatcher{matchers: ms}
+}
+
+// Matches returns true if leaf matches all the sub-matchers.
+func (m *AndMatcher) Matches(leaf *ct.LeafEntry) bool {
+	return m.matchInput(&leafInput{leaf: leaf})
+}
+
+func (m *AndMatcher) matchInput(in *leafInput) bool {
+	for _, sub := range m.matchers {
+		if !matchInput(sub, in) {
+			return false
+		}
+	}
+	return true
+}
+
+// OrMatcher is a LeafMatcher which matches entries that match any of its
+// sub-matchers. Sub-matchers are evaluated left to right, stopping at the
+// first one that matches.
+type OrMatcher struct {
+	matchers []interface{}
+}
+
+// Or returns a matcher for entries that match any of ms, each of which must
+// be a Matcher or a LeafMatcher. An empty Or matches nothing.
+func Or(ms ...interface{}) *OrMatcher {
+	checkMatchers(ms)
+	return &OrMatcher{matchers: ms}
+}
+
+// Matches returns true if leaf matches any of the sub-matchers.
+func (m *OrMatcher) Matches(leaf *ct.LeafEntry) bool {
+	return m.matchInput(&leafInput{leaf: leaf})
+}
+
+func (m *OrMatcher) matchInput(in *leafInput) bool {
+	for _, sub := range m.matchers {
+		if matchInput(sub, in) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotMatcher is a LeafMatcher which matches entries that its sub-matcher
+// doesn't match.
+type NotMatcher struct {
+	matcher interface{}
+}
+
+// Not returns a matcher for entries that don't match m, which must be a
+// Matcher or a LeafMatcher. Note that entries which fail to parse never match
+// a Matcher, so they do match its negation.
+func Not(m interface{}) *NotMatcher {
+	checkMatchers([]interface{}{m})
+	return &NotMatcher{matcher: m}
+}
+
+// Matches returns true if leaf doesn't match the sub-matcher.
+func (m *NotMatcher) Matches(leaf *ct.LeafEntry) bool {
+	return m.matchInput(&leafInput{leaf: leaf})
+}
+
+func (m *NotMatcher) matchInput(in *leafInput) bool {
+	return !matchInput(m.matcher, in)
+}
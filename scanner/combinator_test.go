@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// testEntries returns the entries of FourEntries, which are all certificates:
+//
+//	0: mail.google.com, issued by Google Internet Authority, expires 2013-06-07
+//	1: www.struleartscentre.purchase-tickets-online.co.uk, expires 2013-11-22
+//	2: www.netkeiba.com, expires 2015-03-31
+//	3: www.oxfordplayhouse.com, expires 2013-10-10
+func testEntries(t *testing.T) []ct.LeafEntry {
+	t.Helper()
+	var resp ct.GetEntriesResponse
+	if err := json.Unmarshal([]byte(FourEntries), &resp); err != nil {
+		t.Fatalf("Failed to parse entries: %v", err)
+	}
+	return resp.Entries
+}
+
+// matchingIndices returns the indices of entries that m matches.
+func matchingIndices(m LeafMatcher, entries []ct.LeafEntry) string {
+	var got []int
+	for i := range entries {
+		if m.Matches(&entries[i]) {
+			got = append(got, i)
+		}
+	}
+	return fmt.Sprint(got)
+}
+
+func TestCombinators(t *testing.T) {
+	entries := testEntries(t)
+	google := MatchSubjectRegex{regexp.MustCompile(`google\.com$`), regexp.MustCompile(`google\.com$`)}
+	dotCom := MatchSubjectRegex{regexp.MustCompile(`\.com$`), regexp.MustCompile(`\.com$`)}
+	ts := MatchSCTTimestamp{Timestamp: 1364288407155}
+
+	for _, tc := range []struct {
+		desc string
+		m    LeafMatcher
+		want string
+	}{
+		{desc: "and-empty", m: And(), want: "[0 1 2 3]"},
+		{desc: "or-empty", m: Or(), want: "[]"},
+		{desc: "and", m: And(dotCom, Not(google)), want: "[2 3]"},
+		{desc: "and-mixed", m: And(dotCom, ts), want: "[2]"},
+		{desc: "or-mixed", m: Or(google, ts), want: "[0 1 2]"},
+		{desc: "not-leaf", m: Not(ts), want: "[0 3]"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := matchingIndices(tc.m, entries); got != tc.want {
+				t.Errorf("matched %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("And() with bad matcher didn't panic")
+		}
+	}()
+	And(google, "not a matcher")
+}
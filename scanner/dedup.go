@@ -0,0 +1,378 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"bufio"
+	"container/list"
+	"crypto/sha256"
+	stdasn1 "encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/asn1"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// CertKey identifies a logical certificate: the SHA-256 hash of its
+// TBSCertificate without the CT poison and SCT list extensions, which is the
+// same for a precertificate and the corresponding final certificate.
+type CertKey [sha256.Size]byte
+
+// LeafCertKey returns the CertKey of the [pre-]certificate in leaf.
+func LeafCertKey(leaf *ct.MerkleTreeLeaf) (CertKey, error) {
+	if leaf.TimestampedEntry == nil {
+		return CertKey{}, errors.New("no TimestampedEntry")
+	}
+	var tbs []byte
+	switch entry := leaf.TimestampedEntry; entry.EntryType {
+	case ct.X509LogEntryType:
+		if entry.X509Entry == nil {
+			return CertKey{}, errors.New("no certificate")
+		}
+		var cert, rawTBS cryptobyte.String
+		input := cryptobyte.String(entry.X509Entry.Data)
+		if !input.ReadASN1(&cert, cryptobyte_asn1.SEQUENCE) || !cert.ReadASN1Element(&rawTBS, cryptobyte_asn1.SEQUENCE) {
+			return CertKey{}, errors.New("malformed certificate")
+		}
+		tbs = rawTBS
+	case ct.PrecertLogEntryType:
+		if entry.PrecertEntry == nil {
+			return CertKey{}, errors.New("no precertificate")
+		}
+		// The log has already removed the poison extension, and replaced any
+		// precertificate signing certificate issuer with the final issuer.
+		tbs = entry.PrecertEntry.TBSCertificate
+	default:
+		return CertKey{}, fmt.Errorf("unknown entry type %v", entry.EntryType)
+	}
+
+	hasPoison, hasSCTs, err := tbsCTExtensions(tbs)
+	if err != nil {
+		return CertKey{}, err
+	}
+	if hasPoison {
+		// A precertificate logged as a certificate.
+		if tbs, err = x509.RemoveCTPoison(tbs); err != nil {
+			return CertKey{}, err
+		}
+	}
+	if hasSCTs {
+		if tbs, err = x509.RemoveSCTList(tbs); err != nil {
+			return CertKey{}, err
+		}
+	}
+	return sha256.Sum256(tbs), nil
+}
+
+// tbsCTExtensions returns whether the DER-encoded TBSCertificate has the CT
+// poison and SCT list extensions, without fully parsing it.
+func tbsCTExtensions(der []byte) (hasPoison, hasSCTs bool, err error) {
+	var tbs cryptobyte.String
+	input := cryptobyte.String(der)
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) || // version
+		!tbs.SkipASN1(cryptobyte_asn1.INTEGER) || // serialNumber
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // signature
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // issuer
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // validity
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // subject
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // subjectPublicKeyInfo
+		!tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(1).ContextSpecific()) || // issuerUniqueID
+		!tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(2).ContextSpecific()) { // subjectUniqueID
+		return false, false, errors.New("malformed TBSCertificate")
+	}
+	var exts cryptobyte.String
+	var present bool
+	if !tbs.ReadOptionalASN1(&exts, &present, cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()) {
+		return false, false, errors.New("malformed extensions")
+	}
+	if !present {
+		return false, false, nil
+	}
+	if !exts.ReadASN1(&exts, cryptobyte_asn1.SEQUENCE) {
+		return false, false, errors.New("malformed extensions")
+	}
+	for !exts.Empty() {
+		var ext cryptobyte.String
+		var oid stdasn1.ObjectIdentifier
+		if !exts.ReadASN1(&ext, cryptobyte_asn1.SEQUENCE) || !ext.ReadASN1ObjectIdentifier(&oid) {
+			return false, false, errors.New("malformed extension")
+		}
+		switch oid := asn1.ObjectIdentifier(oid); {
+		case oid.Equal(x509.OIDExtensionCTPoison):
+			hasPoison = true
+		case oid.Equal(x509.OIDExtensionCTSCT):
+			hasSCTs = true
+		}
+	}
+	return hasPoison, hasSCTs, nil
+}
+
+// Sighting is a log entry at which a certificate was seen.
+type Sighting struct {
+	LogURL  string   `json:"log_url"`
+	LogID   ct.LogID `json:"log_id"`
+	Index   int64    `json:"index"`
+	Precert bool     `json:"precert"`
+}
+
+// DedupEvent describes a logical certificate, and all the places it was seen.
+type DedupEvent struct {
+	Key CertKey
+	// Leaf is the MerkleTreeLeaf of one of the sightings, preferring a final
+	// certificate over a precertificate.
+	Leaf *ct.MerkleTreeLeaf
+	// Sightings lists the log entries at which the certificate was seen, in
+	// the order that they were added.
+	Sightings []Sighting
+}
+
+// DedupOptions holds configuration options for the Deduper.
+type DedupOptions struct {
+	// MaxCerts is the maximum number of certificates to keep in memory. When
+	// it's exceeded, the least recently seen certificate is evicted. Defaults
+	// to 1,000,000.
+	MaxCerts int
+
+	// SpillDir, if set, is a directory in which evicted certificates are
+	// stored until Flush, so that there is exactly one event per certificate
+	// at the cost of disk space. Otherwise, evicted certificates are emitted
+	// straight away, and a certificate seen again after its eviction results
+	// in another event.
+	SpillDir string
+}
+
+// Deduper merges the sightings of the same logical certificate, e.g. as a
+// precertificate in one log and a final certificate in others, into a single
+// DedupEvent. Feed it with Add, e.g. from the MultiScanner callback, and call
+// Flush at the end of the scan.
+type Deduper struct {
+	opts DedupOptions
+	emit func(*DedupEvent)
+
+	mu     sync.Mutex
+	lru    *list.List // Of *DedupEvent, most recently seen first.
+	byKey  map[CertKey]*list.Element
+	spills [256]*os.File // Spill files, by the first byte of the key.
+
+	emitMu sync.Mutex
+}
+
+// NewDeduper returns a Deduper which passes events to emit. Calls to emit are
+// serialized.
+func NewDeduper(opts DedupOptions, emit func(*DedupEvent)) (*Deduper, error) {
+	if opts.MaxCerts <= 0 {
+		opts.MaxCerts = 1000000
+	}
+	if opts.SpillDir != "" {
+		if err := os.MkdirAll(opts.SpillDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Deduper{opts: opts, emit: emit, lru: list.New(), byKey: make(map[CertKey]*list.Element)}, nil
+}
+
+// Add records that the entry in m was seen.
+func (d *Deduper) Add(m *LogMatch) error {
+	// Copy the leaf, so as not to keep the rest of the entry in memory.
+	leaf := m.Entry.Leaf
+	key, err := LeafCertKey(&leaf)
+	if err != nil {
+		return fmt.Errorf("%s: entry %d: %v", m.Log.URL, m.Entry.Index, err)
+	}
+	s := Sighting{
+		LogURL:  m.Log.URL,
+		LogID:   m.LogID,
+		Index:   m.Entry.Index,
+		Precert: leaf.TimestampedEntry.EntryType == ct.PrecertLogEntryType,
+	}
+
+	d.mu.Lock()
+	if el, ok := d.byKey[key]; ok {
+		ev := el.Value.(*DedupEvent)
+		ev.Sightings = append(ev.Sightings, s)
+		if ev.Leaf.TimestampedEntry.EntryType == ct.PrecertLogEntryType && !s.Precert {
+			ev.Leaf = &leaf
+		}
+		d.lru.MoveToFront(el)
+		d.mu.Unlock()
+		return nil
+	}
+	d.byKey[key] = d.lru.PushFront(&DedupEvent{Key: key, Leaf: &leaf, Sightings: []Sighting{s}})
+	var evicted *DedupEvent
+	if d.lru.Len() > d.opts.MaxCerts {
+		el := d.lru.Back()
+		d.lru.Remove(el)
+		evicted = el.Value.(*DedupEvent)
+		delete(d.byKey, evicted.Key)
+		if d.opts.SpillDir != "" {
+			err := d.spill(evicted)
+			d.mu.Unlock()
+			return err
+		}
+	}
+	d.mu.Unlock()
+
+	if evicted != nil {
+		d.emitEvent(evicted)
+	}
+	return nil
+}
+
+func (d *Deduper) emitEvent(ev *DedupEvent) {
+	d.emitMu.Lock()
+	defer d.emitMu.Unlock()
+	d.emit(ev)
+}
+
+// spillRecord is the on-disk form of a DedupEvent.
+type spillRecord struct {
+	Key       []byte     `json:"key"`
+	LeafInput []byte     `json:"leaf_input"`
+	Sightings []Sighting `json:"sightings"`
+}
+
+// spill appends ev to its spill file. Must be called with d.mu held.
+func (d *Deduper) spill(ev *DedupEvent) error {
+	leafInput, err := tls.Marshal(*ev.Leaf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal leaf: %v", err)
+	}
+	data, err := json.Marshal(spillRecord{Key: ev.Key[:], LeafInput: leafInput, Sightings: ev.Sightings})
+	if err != nil {
+		return err
+	}
+	f := d.spills[ev.Key[0]]
+	if f == nil {
+		if f, err = os.Create(d.spillPath(ev.Key[0])); err != nil {
+			return err
+		}
+		d.spills[ev.Key[0]] = f
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (d *Deduper) spillPath(bucket byte) string {
+	return filepath.Join(d.opts.SpillDir, fmt.Sprintf("dedup-%02x.json", bucket))
+}
+
+// Flush emits the events for all the certificates seen so far, and resets the
+// Deduper. Spilled certificates are merged back one spill file at a time, so
+// the memory use is bounded by the largest spill file.
+func (d *Deduper) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Group the in-memory events by spill file.
+	var buckets [256][]*DedupEvent
+	for el := d.lru.Back(); el != nil; el = el.Prev() {
+		ev := el.Value.(*DedupEvent)
+		buckets[ev.Key[0]] = append(buckets[ev.Key[0]], ev)
+	}
+	d.lru.Init()
+	d.byKey = make(map[CertKey]*list.Element)
+
+	for b := range buckets {
+		events := buckets[b]
+		if f := d.spills[b]; f != nil {
+			d.spills[b] = nil
+			spilled, err := readSpill(f)
+			if err != nil {
+				return err
+			}
+			events = mergeEvents(spilled, events)
+		}
+		for _, ev := range events {
+			d.emitEvent(ev)
+		}
+	}
+	return nil
+}
+
+// readSpill reads the events from a spill file, and removes it.
+func readSpill(f *os.File) ([]*DedupEvent, error) {
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	var events []*DedupEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var rec spillRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse spill file %s: %v", f.Name(), err)
+		}
+		var leaf ct.MerkleTreeLeaf
+		if _, err := tls.Unmarshal(rec.LeafInput, &leaf); err != nil {
+			return nil, fmt.Errorf("failed to parse leaf in spill file %s: %v", f.Name(), err)
+		}
+		ev := &DedupEvent{Leaf: &leaf, Sightings: rec.Sightings}
+		copy(ev.Key[:], rec.Key)
+		events = append(events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spill file %s: %v", f.Name(), err)
+	}
+	return events, nil
+}
+
+// mergeEvents merges events for the same key, keeping the order in which the
+// keys first appear.
+func mergeEvents(lists ...[]*DedupEvent) []*DedupEvent {
+	var merged []*DedupEvent
+	byKey := make(map[CertKey]*DedupEvent)
+	for _, events := range lists {
+		for _, ev := range events {
+			prev, ok := byKey[ev.Key]
+			if !ok {
+				byKey[ev.Key] = ev
+				merged = append(merged, ev)
+				continue
+			}
+			prev.Sightings = append(prev.Sightings, ev.Sightings...)
+			if prev.Leaf.TimestampedEntry.EntryType == ct.PrecertLogEntryType && ev.Leaf.TimestampedEntry.EntryType != ct.PrecertLogEntryType {
+				prev.Leaf = ev.Leaf
+			}
+		}
+	}
+	return merged
+}
+
+// Close releases the spill files, without emitting the remaining events.
+func (d *Deduper) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var errs []error
+	for b, f := range d.spills {
+		if f == nil {
+			continue
+		}
+		d.spills[b] = nil
+		errs = append(errs, f.Close(), os.Remove(f.Name()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"encoding/pem"
+	"os"
+	"sort"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/loglist3"
+	"github.com/google/certificate-transparency-go/testdata"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+func mustDER(t *testing.T, pemData string) []byte {
+	t.Helper()
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		t.Fatal("Failed to decode PEM")
+	}
+	return block.Bytes
+}
+
+// dedupTestLeaves returns leaves for the same logical certificate: the final
+// certificate with embedded SCTs, the precertificate, and the precertificate
+// logged as a certificate; and a leaf for another certificate.
+func dedupTestLeaves(t *testing.T) []ct.MerkleTreeLeaf {
+	t.Helper()
+	precertDER := mustDER(t, testdata.TestPreCertPEM)
+	precert, err := x509.ParseCertificate(precertDER)
+	if err != nil {
+		t.Fatalf("ParseCertificate(precert): %v", err)
+	}
+	tbs, err := x509.RemoveCTPoison(precert.RawTBSCertificate)
+	if err != nil {
+		t.Fatalf("RemoveCTPoison(): %v", err)
+	}
+	certLeaf := func(der []byte) ct.MerkleTreeLeaf {
+		return ct.MerkleTreeLeaf{TimestampedEntry: &ct.TimestampedEntry{
+			EntryType: ct.X509LogEntryType,
+			X509Entry: &ct.ASN1Cert{Data: der},
+		}}
+	}
+	return []ct.MerkleTreeLeaf{
+		certLeaf(mustDER(t, testdata.TestEmbeddedCertPEM)),
+		{TimestampedEntry: &ct.TimestampedEntry{
+			EntryType:    ct.PrecertLogEntryType,
+			PrecertEntry: &ct.PreCert{TBSCertificate: tbs},
+		}},
+		certLeaf(precertDER),
+		certLeaf(mustDER(t, testdata.TestCertPEM)),
+	}
+}
+
+func TestLeafCertKey(t *testing.T) {
+	leaves := dedupTestLeaves(t)
+	var keys []CertKey
+	for i := range leaves {
+		key, err := LeafCertKey(&leaves[i])
+		if err != nil {
+			t.Fatalf("LeafCertKey(%d): %v", i, err)
+		}
+		keys = append(keys, key)
+	}
+	if keys[0] != keys[1] || keys[0] != keys[2] {
+		t.Errorf("LeafCertKey() differs between certificate and precertificates: %x", keys[:3])
+	}
+	if keys[0] == keys[3] {
+		t.Error("LeafCertKey() is the same for different certificates")
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	leaves := dedupTestLeaves(t)
+	logs := []*loglist3.Log{{URL: "https://a"}, {URL: "https://b"}}
+	// Sightings of leaves by (log, leaf, index), interleaved so that the
+	// duplicates get evicted in between with MaxCerts 1.
+	adds := []struct{ log, leaf, index int }{
+		{0, 1, 10}, // precert
+		{1, 3, 20}, // another cert
+		{1, 0, 21}, // final cert
+		{0, 3, 11},
+		{1, 2, 22}, // precert as a cert
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		maxCerts int
+		spill    bool
+		want     int // Number of events.
+	}{
+		{desc: "in-memory", maxCerts: 10, want: 2},
+		{desc: "evict", maxCerts: 1, want: 5},
+		{desc: "spill", maxCerts: 1, spill: true, want: 2},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts := DedupOptions{MaxCerts: tc.maxCerts}
+			if tc.spill {
+				opts.SpillDir = t.TempDir()
+			}
+			var events []*DedupEvent
+			d, err := NewDeduper(opts, func(ev *DedupEvent) { events = append(events, ev) })
+			if err != nil {
+				t.Fatalf("NewDeduper(): %v", err)
+			}
+			defer d.Close()
+			for _, a := range adds {
+				m := &LogMatch{Log: logs[a.log], Entry: &ct.RawLogEntry{Index: int64(a.index), Leaf: leaves[a.leaf]}}
+				if err := d.Add(m); err != nil {
+					t.Fatalf("Add(): %v", err)
+				}
+			}
+			if err := d.Flush(); err != nil {
+				t.Fatalf("Flush(): %v", err)
+			}
+			if len(events) != tc.want {
+				t.Fatalf("got %d events, want %d", len(events), tc.want)
+			}
+			if tc.want != 2 {
+				return
+			}
+
+			sort.Slice(events, func(i, j int) bool { return len(events[i].Sightings) > len(events[j].Sightings) })
+			var indices []int64
+			for _, s := range events[0].Sightings {
+				indices = append(indices, s.Index)
+			}
+			sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+			if len(indices) != 3 || indices[0] != 10 || indices[1] != 21 || indices[2] != 22 {
+				t.Errorf("got sightings at %v, want [10 21 22]", indices)
+			}
+			if got := events[0].Leaf.TimestampedEntry.EntryType; got != ct.X509LogEntryType {
+				t.Errorf("representative leaf has type %v, want a certificate", got)
+			}
+			if got := len(events[1].Sightings); got != 2 {
+				t.Errorf("got %d sightings of the other certificate, want 2", got)
+			}
+			if tc.spill {
+				if files, _ := os.ReadDir(opts.SpillDir); len(files) != 0 {
+					t.Errorf("spill files left behind after Flush: %v", files)
+				}
+			}
+		})
+	}
+}
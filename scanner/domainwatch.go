@@ -0,0 +1,250 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+// DomainHit describes a name in a [pre-]certificate which matched the watch
+// list of a DomainWatchMatcher.
+type DomainHit struct {
+	// Watched is the watch list entry that matched, as given.
+	Watched string
+	// Name is the DNS name, CN or IP address from the [pre-]certificate.
+	Name string
+}
+
+// DomainWatchMatcher is a Matcher for [pre-]certificates that contain any of a
+// (possibly large) list of watched domains in their DNS SANs, IP SANs or
+// subject CN. Watch list entries can be:
+//
+//	example.com     matches the name example.com only
+//	*.example.com   matches any name below example.com, at any depth
+//	192.0.2.1       matches the IP address
+//	192.0.2.0/24    matches any IP address in the network
+//
+// A wildcard name in a certificate such as *.example.com also matches the
+// watched names that it covers, e.g. www.example.com.
+//
+// Domain names are kept in a trie of their reversed labels, so matching takes
+// time proportional to the number of labels rather than the watch list size.
+// The watch list can be replaced at any time with Reload.
+type DomainWatchMatcher struct {
+	list atomic.Pointer[watchList]
+}
+
+// watchList is an immutable, compiled watch list.
+type watchList struct {
+	root  *domainNode
+	ips   map[string]string // Normalised IP to watch list entry.
+	nets  []*net.IPNet
+	names []string // Watch list entries for nets, by index.
+}
+
+// domainNode is a trie node for a domain name, reached from the root through
+// its labels in reverse order, e.g. com, example, www.
+type domainNode struct {
+	children map[string]*domainNode
+	exact    string // The watch list entry matching this name, if any.
+	subtree  string // The watch list entry matching names below, if any.
+}
+
+// NewDomainWatchMatcher returns a DomainWatchMatcher for the given watch list.
+func NewDomainWatchMatcher(watched []string) (*DomainWatchMatcher, error) {
+	m := &DomainWatchMatcher{}
+	if err := m.Reload(watched); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload atomically replaces the watch list. Concurrent matches use either
+// the old or the new list in full. On error, the old list stays in place.
+func (m *DomainWatchMatcher) Reload(watched []string) error {
+	list, err := compileWatchList(watched)
+	if err != nil {
+		return err
+	}
+	m.list.Store(list)
+	return nil
+}
+
+// ReadWatchList reads a watch list from a file with one entry per line. Empty
+// lines and lines starting with # are ignored.
+func ReadWatchList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var watched []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		watched = append(watched, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read watch list %q: %v", path, err)
+	}
+	return watched, nil
+}
+
+func normaliseName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+func compileWatchList(watched []string) (*watchList, error) {
+	list := &watchList{root: &domainNode{}, ips: make(map[string]string)}
+	for _, w := range watched {
+		entry := strings.TrimSpace(w)
+		if entry == "" || strings.ContainsAny(entry, " \t") {
+			return nil, fmt.Errorf("invalid watch list entry %q", w)
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			list.ips[ip.String()] = w
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			list.nets = append(list.nets, ipNet)
+			list.names = append(list.names, w)
+			continue
+		}
+
+		name := normaliseName(entry)
+		subtree := strings.HasPrefix(name, "*.")
+		name = strings.TrimPrefix(name, "*.")
+		labels := strings.Split(name, ".")
+		node := list.root
+		for i := len(labels) - 1; i >= 0; i-- {
+			if labels[i] == "" || labels[i] == "*" {
+				return nil, fmt.Errorf("invalid watch list entry %q", w)
+			}
+			child := node.children[labels[i]]
+			if child == nil {
+				if node.children == nil {
+					node.children = make(map[string]*domainNode)
+				}
+				child = &domainNode{}
+				node.children[labels[i]] = child
+			}
+			node = child
+		}
+		if subtree {
+			node.subtree = w
+		} else {
+			node.exact = w
+		}
+	}
+	return list, nil
+}
+
+// matchName appends the hits for a DNS name to hits.
+func (l *watchList) matchName(name string, hits []DomainHit) []DomainHit {
+	labels := strings.Split(normaliseName(name), ".")
+	node := l.root
+	for i := len(labels) - 1; i >= 0; i-- {
+		if node.subtree != "" {
+			hits = append(hits, DomainHit{Watched: node.subtree, Name: name})
+		}
+		if i == 0 && labels[0] == "*" {
+			// A wildcard certificate name covers the watched names one level down.
+			for _, child := range node.children {
+				if child.exact != "" {
+					hits = append(hits, DomainHit{Watched: child.exact, Name: name})
+				}
+			}
+			return hits
+		}
+		if node = node.children[labels[i]]; node == nil {
+			return hits
+		}
+	}
+	if node.exact != "" {
+		hits = append(hits, DomainHit{Watched: node.exact, Name: name})
+	}
+	return hits
+}
+
+// matchIP appends the hits for an IP address to hits.
+func (l *watchList) matchIP(ip net.IP, hits []DomainHit) []DomainHit {
+	if w, ok := l.ips[ip.String()]; ok {
+		hits = append(hits, DomainHit{Watched: w, Name: ip.String()})
+	}
+	for i, ipNet := range l.nets {
+		if ipNet.Contains(ip) {
+			hits = append(hits, DomainHit{Watched: l.names[i], Name: ip.String()})
+		}
+	}
+	return hits
+}
+
+// Hits returns all the watch list hits for the names in c, or nil if none.
+func (m *DomainWatchMatcher) Hits(c *x509.Certificate) []DomainHit {
+	list := m.list.Load()
+	var hits []DomainHit
+	if cn := c.Subject.CommonName; cn != "" {
+		if ip := net.ParseIP(cn); ip != nil {
+			hits = list.matchIP(ip, hits)
+		} else {
+			hits = list.matchName(cn, hits)
+		}
+	}
+	for _, name := range c.DNSNames {
+		hits = list.matchName(name, hits)
+	}
+	for _, ip := range c.IPAddresses {
+		hits = list.matchIP(ip, hits)
+	}
+	return hits
+}
+
+// EntryHits parses the [pre-]certificate in entry, and returns the watch list
+// hits for it. This is useful for finding out why an entry matched in the
+// Scanner callbacks.
+func (m *DomainWatchMatcher) EntryHits(entry *ct.RawLogEntry) ([]DomainHit, error) {
+	logEntry, err := entry.ToLogEntry()
+	if x509.IsFatal(err) {
+		return nil, err
+	}
+	switch {
+	case logEntry.X509Cert != nil:
+		return m.Hits(logEntry.X509Cert), nil
+	case logEntry.Precert != nil:
+		return m.Hits(logEntry.Precert.TBSCertificate), nil
+	}
+	return nil, fmt.Errorf("no [pre-]certificate in entry %d", entry.Index)
+}
+
+// CertificateMatches returns true if any name in c is watched.
+func (m *DomainWatchMatcher) CertificateMatches(c *x509.Certificate) bool {
+	return len(m.Hits(c)) > 0
+}
+
+// PrecertificateMatches returns true if any name in p is watched.
+func (m *DomainWatchMatcher) PrecertificateMatches(p *ct.Precertificate) bool {
+	return len(m.Hits(p.TBSCertificate)) > 0
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509/pkix"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDomainWatchMatcher(t *testing.T) {
+	m, err := NewDomainWatchMatcher([]string{
+		"example.com",
+		"*.example.org",
+		"www.example.net",
+		"Mail.Example.COM.",
+		"192.0.2.1",
+		"198.51.100.0/24",
+	})
+	if err != nil {
+		t.Fatalf("NewDomainWatchMatcher()=%v", err)
+	}
+
+	for _, tc := range []struct {
+		desc string
+		cert x509.Certificate
+		want []DomainHit
+	}{
+		{
+			desc: "exact-cn",
+			cert: x509.Certificate{Subject: pkix.Name{CommonName: "EXAMPLE.com"}},
+			want: []DomainHit{{Watched: "example.com", Name: "EXAMPLE.com"}},
+		},
+		{
+			desc: "exact-not-subdomain",
+			cert: x509.Certificate{DNSNames: []string{"www.example.com", "example.co"}},
+		},
+		{
+			desc: "normalised-entry",
+			cert: x509.Certificate{DNSNames: []string{"mail.example.com"}},
+			want: []DomainHit{{Watched: "Mail.Example.COM.", Name: "mail.example.com"}},
+		},
+		{
+			desc: "subtree",
+			cert: x509.Certificate{DNSNames: []string{"example.org", "a.b.example.org"}},
+			want: []DomainHit{{Watched: "*.example.org", Name: "a.b.example.org"}},
+		},
+		{
+			desc: "wildcard-cert",
+			cert: x509.Certificate{DNSNames: []string{"*.example.net", "*.example.org"}},
+			want: []DomainHit{
+				{Watched: "www.example.net", Name: "*.example.net"},
+				{Watched: "*.example.org", Name: "*.example.org"},
+			},
+		},
+		{
+			desc: "ips",
+			cert: x509.Certificate{
+				Subject:     pkix.Name{CommonName: "192.0.2.1"},
+				IPAddresses: []net.IP{net.ParseIP("198.51.100.7"), net.ParseIP("203.0.113.1")},
+			},
+			want: []DomainHit{
+				{Watched: "192.0.2.1", Name: "192.0.2.1"},
+				{Watched: "198.51.100.0/24", Name: "198.51.100.7"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := m.Hits(&tc.cert)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Hits() diff (-want +got):\n%s", diff)
+			}
+			if got, want := m.CertificateMatches(&tc.cert), len(tc.want) > 0; got != want {
+				t.Errorf("CertificateMatches()=%v, want %v", got, want)
+			}
+			precert := ct.Precertificate{TBSCertificate: &tc.cert}
+			if got, want := m.PrecertificateMatches(&precert), len(tc.want) > 0; got != want {
+				t.Errorf("PrecertificateMatches()=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDomainWatchMatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.txt")
+	if err := os.WriteFile(path, []byte("# Customers\nexample.com\n\n  *.example.org  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watched, err := ReadWatchList(path)
+	if err != nil {
+		t.Fatalf("ReadWatchList()=%v", err)
+	}
+	if want := []string{"example.com", "*.example.org"}; !cmp.Equal(watched, want) {
+		t.Fatalf("ReadWatchList()=%v, want %v", watched, want)
+	}
+	m, err := NewDomainWatchMatcher(watched)
+	if err != nil {
+		t.Fatalf("NewDomainWatchMatcher()=%v", err)
+	}
+	cert := &x509.Certificate{DNSNames: []string{"example.com"}}
+	if !m.CertificateMatches(cert) {
+		t.Error("CertificateMatches()=false before reload, want true")
+	}
+
+	for _, bad := range []string{"", "a..b", "foo.*.com", "a b"} {
+		if err := m.Reload([]string{bad}); err == nil {
+			t.Errorf("Reload(%q)=nil, want error", bad)
+		}
+	}
+	if !m.CertificateMatches(cert) {
+		t.Error("CertificateMatches()=false after failed reload, want true")
+	}
+
+	if err := m.Reload([]string{"example.net"}); err != nil {
+		t.Fatalf("Reload()=%v", err)
+	}
+	if m.CertificateMatches(cert) {
+		t.Error("CertificateMatches()=true after reload, want false")
+	}
+}
+
+func TestDomainWatchMatcherEntryHits(t *testing.T) {
+	m, err := NewDomainWatchMatcher([]string{"*.google.com", "www.netkeiba.com"})
+	if err != nil {
+		t.Fatalf("NewDomainWatchMatcher()=%v", err)
+	}
+	var got []string
+	for i, leaf := range testEntries(t) {
+		entry, err := ct.RawLogEntryFromLeaf(int64(i), &leaf)
+		if err != nil {
+			t.Fatalf("RawLogEntryFromLeaf(%d)=%v", i, err)
+		}
+		hits, err := m.EntryHits(entry)
+		if err != nil {
+			t.Fatalf("EntryHits(%d)=%v", i, err)
+		}
+		for _, hit := range hits {
+			got = append(got, hit.Watched)
+		}
+	}
+	sort.Strings(got)
+	// mail.google.com matches in both CN and SAN.
+	if want := []string{"*.google.com", "*.google.com", "www.netkeiba.com", "www.netkeiba.com"}; !cmp.Equal(got, want) {
+		t.Errorf("EntryHits() watched=%v, want %v", got, want)
+	}
+}
@@ -16,6 +16,7 @@ package scanner
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
@@ -49,6 +50,39 @@ type FetcherOptions struct {
 	// Continuous determines whether Fetcher should run indefinitely after
 	// reaching EndIndex.
 	Continuous bool
+
+	// Checkpoints, if not nil, is used to resume fetching from the last saved
+	// position, and is updated with the highest contiguous index for which the
+	// callback has returned. When resuming, the STH stored in the checkpoint is
+	// used in place of fetching a new one.
+	Checkpoints CheckpointStore
+
+	// RequestsPerSecond, if positive, limits the rate of get-entries requests
+	// sent by all workers together. RequestBurst is the size of the token
+	// bucket, i.e. the number of requests that can be sent at once.
+	RequestsPerSecond float64
+	RequestBurst      int
+
+	// AdaptiveBatch makes the Fetcher learn the Log's real page size from short
+	// get-entries responses, and align fetched ranges to it. In this mode, all
+	// workers back off together when the Log responds with HTTP 429 or 503.
+	AdaptiveBatch bool
+
+	// Ordered makes the Fetcher pass batches to the callback in index order,
+	// while still fetching in parallel. Batches that arrive early are held in
+	// a reorder window of up to ReorderWindow batches (ParallelFetch if zero).
+	// Workers that fetched a batch which doesn't fit the window block until it
+	// drains.
+	Ordered       bool
+	ReorderWindow int
+
+	// Verify makes the Fetcher compute the RFC 6962 Merkle tree over the
+	// fetched entries and check it against the root hash of the STH, and in
+	// Continuous mode against every later STH, returning a *RootMismatchError
+	// from Run if they diverge. Batches are passed to the callback before they
+	// can be verified. The fetched range must start at index 0, or at a
+	// checkpoint saved by a verifying Fetcher, and must end at the STH size.
+	Verify bool
 }
 
 // DefaultFetcherOptions returns new FetcherOptions with sensible defaults.
@@ -75,8 +109,14 @@ type Fetcher struct {
 	sth *ct.SignedTreeHead
 	// The STH retrieval backoff state. Used only in Continuous fetch mode.
 	sthBackoff *backoff.Backoff
+	// Tracks processed entries for checkpointing and verification. Nil if
+	// neither is enabled.
+	progress *progressTracker
+	// Rate limiting and throttling state shared between the workers.
+	pacer *pacer
 
 	// Stops range generator, which causes the Fetcher to terminate gracefully.
+	// Also guards sth once Run has started.
 	mu     sync.Mutex
 	cancel context.CancelFunc
 }
@@ -101,42 +141,103 @@ func NewFetcher(client LogClient, opts *FetcherOptions) *Fetcher {
 		uri:    client.BaseURI(),
 		client: client,
 		opts:   opts,
+		pacer:  newPacer(client.BaseURI(), opts),
 		cancel: cancel,
 	}
 }
 
 // Prepare caches the latest Log's STH if not present and returns it. It also
-// adjusts the entry range to fit the size of the tree.
+// adjusts the entry range to fit the size of the tree. If a checkpoint is
+// available, the range starts from it, and the checkpointed STH is used.
 func (f *Fetcher) Prepare(ctx context.Context) (*ct.SignedTreeHead, error) {
 	if f.sth != nil {
 		return f.sth, nil
 	}
 
-	sth, err := f.client.GetSTH(ctx)
-	if err != nil {
-		klog.Errorf("%s: GetSTH() failed: %v", f.uri, err)
-		return nil, err
+	var sth *ct.SignedTreeHead
+	var cp *Checkpoint
+	if f.opts.Checkpoints != nil {
+		var err error
+		if cp, err = f.opts.Checkpoints.Load(ctx); err != nil {
+			klog.Errorf("%s: Failed to load checkpoint: %v", f.uri, err)
+			return nil, err
+		}
+		if cp != nil {
+			klog.Infof("%s: Resuming from checkpoint at index %d", f.uri, cp.NextIndex)
+			if cp.NextIndex > f.opts.StartIndex {
+				f.opts.StartIndex = cp.NextIndex
+			}
+			sth = cp.STH
+		}
+	}
+
+	if sth == nil {
+		var err error
+		if sth, err = f.client.GetSTH(ctx); err != nil {
+			klog.Errorf("%s: GetSTH() failed: %v", f.uri, err)
+			return nil, err
+		}
+		klog.V(1).Infof("%s: Got STH with %d certs", f.uri, sth.TreeSize)
 	}
-	klog.V(1).Infof("%s: Got STH with %d certs", f.uri, sth.TreeSize)
 
 	if size := int64(sth.TreeSize); f.opts.EndIndex == 0 || f.opts.EndIndex > size {
 		klog.V(1).Infof("%s: Reset EndIndex from %d to %d", f.uri, f.opts.EndIndex, size)
 		f.opts.EndIndex = size
 	}
+	if f.opts.StartIndex > f.opts.EndIndex {
+		// Only possible when resuming beyond the requested EndIndex.
+		f.opts.StartIndex = f.opts.EndIndex
+	}
+	if f.opts.Checkpoints != nil || f.opts.Verify {
+		f.progress = newProgressTracker(f.opts.Checkpoints, f.opts.StartIndex)
+	}
+	if f.opts.Verify {
+		if err := f.prepareVerification(cp, sth); err != nil {
+			klog.Errorf("%s: Can't verify entries: %v", f.uri, err)
+			return nil, err
+		}
+	}
 	f.sth = sth
 	return sth, nil
 }
 
+// prepareVerification sets up the verification of fetched entries against
+// sth, starting from the compact range stored in cp if it's not nil.
+func (f *Fetcher) prepareVerification(cp *Checkpoint, sth *ct.SignedTreeHead) error {
+	if f.opts.EndIndex != int64(sth.TreeSize) {
+		return fmt.Errorf("EndIndex %d is not the STH tree size %d", f.opts.EndIndex, sth.TreeSize)
+	}
+	var hashes [][]byte
+	if cp != nil {
+		if cp.NextIndex != f.opts.StartIndex {
+			return fmt.Errorf("StartIndex %d is not the checkpoint index %d", f.opts.StartIndex, cp.NextIndex)
+		}
+		hashes = cp.CompactRange
+	} else if f.opts.StartIndex != 0 {
+		return fmt.Errorf("StartIndex %d is not 0, and there is no checkpoint", f.opts.StartIndex)
+	}
+	if err := f.progress.enableVerification(hashes); err != nil {
+		return err
+	}
+	f.progress.addSTH(sth)
+	return nil
+}
+
 // Run performs fetching of the Log. Blocks until scanning is complete, the
 // passed in context is canceled, or Stop is called (and pending work is
-// finished). For each successfully fetched batch, runs the fn callback.
+// finished). For each successfully fetched batch, runs the fn callback. The
+// callback is run concurrently and in arbitrary order, unless opts.Ordered is
+// set, in which case it is run sequentially in index order.
 func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 	klog.V(1).Infof("%s: Starting up Fetcher...", f.uri)
 	if _, err := f.Prepare(ctx); err != nil {
 		return err
 	}
 
-	cctx, cancel := context.WithCancel(ctx)
+	// Workers are stopped early only if verification fails.
+	fctx, abort := context.WithCancel(ctx)
+	defer abort()
+	cctx, cancel := context.WithCancel(fctx)
 	defer cancel()
 
 	f.mu.Lock()
@@ -148,6 +249,42 @@ func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 	// completion.
 	ranges := f.genRanges(cctx)
 
+	var (
+		errMu  sync.Mutex
+		runErr error
+	)
+	deliver := fn
+	if f.progress != nil {
+		deliver = func(b EntryBatch) {
+			var hashes [][]byte
+			if f.progress.verifying() {
+				hashes = hashLeaves(b)
+			}
+			fn(b)
+			end := b.Start + int64(len(b.Entries))
+			if err := f.progress.markDone(ctx, b.Start, end, hashes, f.currentSTH()); err != nil {
+				klog.Errorf("%s: Failed to process entries [%d, %d): %v", f.uri, b.Start, end, err)
+				errMu.Lock()
+				if runErr == nil {
+					runErr = err
+				}
+				errMu.Unlock()
+				abort()
+			}
+		}
+	}
+	if f.opts.Ordered {
+		window := f.opts.ReorderWindow
+		if window <= 0 {
+			window = f.opts.ParallelFetch
+		}
+		rb := newReorderBuffer(f.opts.StartIndex, window, deliver)
+		deliver = func(b EntryBatch) {
+			// An error means the context is done, and the worker will exit.
+			_ = rb.add(fctx, b)
+		}
+	}
+
 	// Run fetcher workers.
 	var wg sync.WaitGroup
 	for w, cnt := 0, f.opts.ParallelFetch; w < cnt; w++ {
@@ -155,14 +292,14 @@ func (f *Fetcher) Run(ctx context.Context, fn func(EntryBatch)) error {
 		go func(idx int) {
 			defer wg.Done()
 			klog.V(1).Infof("%s: Fetcher worker %d starting...", f.uri, idx)
-			f.runWorker(ctx, ranges, fn)
+			f.runWorker(fctx, ranges, deliver)
 			klog.V(1).Infof("%s: Fetcher worker %d finished", f.uri, idx)
 		}(w)
 	}
 	wg.Wait()
 
 	klog.V(1).Infof("%s: Fetcher terminated", f.uri)
-	return nil
+	return runErr
 }
 
 // Stop causes the Fetcher to terminate gracefully. After this call Run will
@@ -178,7 +315,6 @@ func (f *Fetcher) Stop() {
 // sends things down this channel. The goroutine terminates when all ranges
 // have been generated, or if context is cancelled.
 func (f *Fetcher) genRanges(ctx context.Context) <-chan fetchRange {
-	batch := int64(f.opts.BatchSize)
 	ranges := make(chan fetchRange)
 
 	go func() {
@@ -198,7 +334,13 @@ func (f *Fetcher) genRanges(ctx context.Context) <-chan fetchRange {
 				end = f.opts.EndIndex
 			}
 
+			batch := f.pacer.batchSize(int64(f.opts.BatchSize))
 			batchEnd := start + min(end-start, batch)
+			if f.opts.AdaptiveBatch {
+				// Don't cross page boundaries, so that the Log can serve the whole
+				// range in one response.
+				batchEnd = min(batchEnd, (start/batch+1)*batch)
+			}
 			next := fetchRange{start, batchEnd - 1}
 			select {
 			case <-ctx.Done():
@@ -250,7 +392,12 @@ func (f *Fetcher) updateSTH(ctx context.Context) error {
 		if quick {
 			f.sthBackoff.Reset() // Growth is presumably fast, set next pause to Min.
 		}
+		f.mu.Lock()
 		f.sth = sth
+		f.mu.Unlock()
+		if f.progress != nil {
+			f.progress.addSTH(sth)
+		}
 		f.opts.EndIndex = int64(sth.TreeSize)
 		return nil
 	})
@@ -282,8 +429,13 @@ func (f *Fetcher) runWorker(ctx context.Context, ranges <-chan fetchRange, fn fu
 			var resp *ct.GetEntriesResponse
 			// TODO(pavelkalinnikov): Report errors in a LogClient decorator on failure.
 			if err := bo.Retry(ctx, func() error {
+				if err := f.pacer.wait(ctx); err != nil {
+					return err
+				}
 				var err error
-				resp, err = f.client.GetRawEntries(ctx, r.start, r.end)
+				if resp, err = f.client.GetRawEntries(ctx, r.start, r.end); err != nil {
+					f.pacer.onError(err)
+				}
 				return err
 			}); err != nil {
 				if rspErr, isRspErr := err.(jsonclient.RspError); isRspErr && rspErr.StatusCode == http.StatusTooManyRequests {
@@ -294,12 +446,27 @@ func (f *Fetcher) runWorker(ctx context.Context, ranges <-chan fetchRange, fn fu
 				// There is no error reporting yet for this worker, so just retry again.
 				continue
 			}
+			requested := r.end - r.start + 1
+			if got := int64(len(resp.Entries)); got > requested {
+				// Entries beyond the range may be past the STH, or overlap
+				// another range, so drop them.
+				klog.Warningf("%s: GetRawEntries(%d, %d) returned %d entries, dropping the extra ones", f.uri, r.start, r.end, got)
+				resp.Entries = resp.Entries[:requested]
+			}
+			f.pacer.onSuccess(requested, int64(len(resp.Entries)))
 			fn(EntryBatch{Start: r.start, Entries: resp.Entries})
 			r.start += int64(len(resp.Entries))
 		}
 	}
 }
 
+// currentSTH returns the STH that the Fetcher is currently working towards.
+func (f *Fetcher) currentSTH() *ct.SignedTreeHead {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sth
+}
+
 func min(a, b int64) int64 {
 	if a < b {
 		return a
@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/transparency-dev/merkle/compact"
+)
+
+// fakeLogClient serves entries from memory, returning at most pageSize
+// entries per GetRawEntries call if pageSize is positive.
+type fakeLogClient struct {
+	sth      *ct.SignedTreeHead
+	entries  []ct.LeafEntry
+	pageSize int64
+	overrun  bool // Return all entries from start, whatever the end.
+}
+
+func newFakeLogClient(t *testing.T) *fakeLogClient {
+	t.Helper()
+	var sth ct.SignedTreeHead
+	if err := json.Unmarshal([]byte(FourEntrySTH), &sth); err != nil {
+		t.Fatalf("Failed to parse STH: %v", err)
+	}
+	var resp ct.GetEntriesResponse
+	if err := json.Unmarshal([]byte(FourEntries), &resp); err != nil {
+		t.Fatalf("Failed to parse entries: %v", err)
+	}
+	// The test STH's root hash doesn't match the entries, so fix it up.
+	root, err := compactRangeOf(t, resp.Entries).GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash(): %v", err)
+	}
+	copy(sth.SHA256RootHash[:], root)
+	return &fakeLogClient{sth: &sth, entries: resp.Entries}
+}
+
+// compactRangeOf returns the compact range covering the given entries.
+func compactRangeOf(t *testing.T, entries []ct.LeafEntry) *compact.Range {
+	t.Helper()
+	rng := rangeFactory.NewEmptyRange(0)
+	for _, hash := range hashLeaves(EntryBatch{Entries: entries}) {
+		if err := rng.Append(hash, nil); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+	}
+	return rng
+}
+
+func (c *fakeLogClient) BaseURI() string { return "fake" }
+
+func (c *fakeLogClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return c.sth, nil
+}
+
+func (c *fakeLogClient) GetRawEntries(_ context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if start < 0 || start > end || end >= int64(len(c.entries)) {
+		return nil, fmt.Errorf("bad range [%d, %d]", start, end)
+	}
+	if c.overrun {
+		end = int64(len(c.entries)) - 1
+	}
+	if c.pageSize > 0 && end-start+1 > c.pageSize {
+		end = start + c.pageSize - 1
+	}
+	return &ct.GetEntriesResponse{Entries: c.entries[start : end+1]}, nil
+}
+
+// fetchIndices runs the Fetcher, and returns the sorted indices of all the
+// fetched entries.
+func fetchIndices(ctx context.Context, t *testing.T, f *Fetcher) []int64 {
+	t.Helper()
+	var mu sync.Mutex
+	var got []int64
+	if err := f.Run(ctx, func(b EntryBatch) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := range b.Entries {
+			got = append(got, b.Start+int64(i))
+		}
+	}); err != nil {
+		t.Fatalf("Run()=%v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	return got
+}
+
+func TestFetcherResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	store := &MemoryCheckpointStore{}
+
+	// Only fetch part of the log the first time around.
+	opts := FetcherOptions{BatchSize: 1, ParallelFetch: 2, EndIndex: 2, Checkpoints: store}
+	if got := fetchIndices(ctx, t, NewFetcher(cl, &opts)); len(got) != 2 {
+		t.Fatalf("Fetched %v, want 2 entries", got)
+	}
+	cp, err := store.Load(ctx)
+	if err != nil || cp == nil {
+		t.Fatalf("Load()=%v, %v; want checkpoint", cp, err)
+	}
+	if cp.NextIndex != 2 {
+		t.Errorf("Checkpoint NextIndex=%d, want 2", cp.NextIndex)
+	}
+	if cp.STH == nil || cp.STH.TreeSize != 4 {
+		t.Errorf("Checkpoint STH=%v, want tree size 4", cp.STH)
+	}
+
+	// A new Fetcher with the same store continues where the first one stopped.
+	opts = FetcherOptions{BatchSize: 1, ParallelFetch: 2, Checkpoints: store}
+	got := fetchIndices(ctx, t, NewFetcher(cl, &opts))
+	if want := []int64{2, 3}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Fetched %v after resume, want %v", got, want)
+	}
+	if cp, _ := store.Load(ctx); cp.NextIndex != 4 {
+		t.Errorf("Checkpoint NextIndex=%d, want 4", cp.NextIndex)
+	}
+}
+
+func TestFetcherAdaptiveBatch(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	cl.pageSize = 2
+
+	opts := FetcherOptions{BatchSize: 3, ParallelFetch: 2, AdaptiveBatch: true, RequestsPerSecond: 100}
+	f := NewFetcher(cl, &opts)
+	got := fetchIndices(ctx, t, f)
+	if want := []int64{0, 1, 2, 3}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Fetched %v, want %v", got, want)
+	}
+	if got, want := f.pacer.batchSize(3), int64(2); got != want {
+		t.Errorf("Learned batch size %d, want %d", got, want)
+	}
+}
+
+func TestFetcherOrdered(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	opts := FetcherOptions{BatchSize: 1, ParallelFetch: 4, Ordered: true, ReorderWindow: 1}
+
+	var got []int64
+	if err := NewFetcher(cl, &opts).Run(ctx, func(b EntryBatch) {
+		got = append(got, b.Start) // Ordered mode runs the callback sequentially.
+	}); err != nil {
+		t.Fatalf("Run()=%v", err)
+	}
+	if want := []int64{0, 1, 2, 3}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Delivered %v, want %v", got, want)
+	}
+}
+
+func TestFetcherClampsOverrun(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	cl.overrun = true
+	opts := FetcherOptions{BatchSize: 1, ParallelFetch: 2, Verify: true}
+	if got := fetchIndices(ctx, t, NewFetcher(cl, &opts)); fmt.Sprint(got) != "[0 1 2 3]" {
+		t.Errorf("Fetched %v, want [0 1 2 3]", got)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/loglist3"
+	"k8s.io/klog/v2"
+)
+
+// MultiScannerOptions holds configuration options for the MultiScanner.
+type MultiScannerOptions struct {
+	// ScannerOptions are applied to the scan of each log, except that:
+	//  - NumWorkers is the number of matchers shared by all logs.
+	//  - ParallelFetch is the number of concurrent fetches per log.
+	//  - StartIndex, EndIndex and Checkpoints are ignored, and each log is
+	//    scanned in full, or from the checkpoint in LogCheckpoints.
+	ScannerOptions
+
+	// Statuses, if not empty, restricts the scan to logs in one of the given
+	// states, as with LogList.SelectByStatus.
+	Statuses []loglist3.LogStatus
+
+	// MaxParallelFetch, if positive, limits the number of concurrent
+	// get-entries requests across all logs.
+	MaxParallelFetch int
+
+	// NewClient returns the LogClient for talking to a log. If nil, a
+	// client.LogClient using http.DefaultClient is created, which verifies STH
+	// signatures with the log's key.
+	NewClient func(*loglist3.Log) (LogClient, error)
+
+	// LogCheckpoints, if not nil, returns the CheckpointStore to resume the
+	// scan of a log from, as described for FetcherOptions.Checkpoints.
+	LogCheckpoints func(*loglist3.Log) CheckpointStore
+}
+
+// LogMatch is an entry which matched in one of the logs scanned by a
+// MultiScanner.
+type LogMatch struct {
+	// Log is the log list entry of the log that the entry is in.
+	Log *loglist3.Log
+	// LogID is the ID of the log, i.e. the SHA-256 hash of its public key.
+	LogID ct.LogID
+	// Entry is the matching [pre-]certificate entry.
+	Entry *ct.RawLogEntry
+}
+
+// LogProgress holds the progress and throughput of the scan of one log.
+type LogProgress struct {
+	URL   string
+	LogID ct.LogID
+	// [StartIndex, EndIndex) is the entry range being scanned. Both are zero
+	// until the log's STH has been fetched.
+	StartIndex int64
+	EndIndex   int64
+	// Processed and Matched are the numbers of entries processed and matched.
+	Processed int64
+	Matched   int64
+	// Throughput is the average number of entries processed per second since
+	// the scan of the log started.
+	Throughput float64
+	// Done is set once the scan of the log has finished, with Err holding the
+	// error that it failed with, if any.
+	Done bool
+	Err  error
+}
+
+// MultiScanner scans all the logs in a log list concurrently, sharing a
+// budget of fetchers and matchers between them.
+type MultiScanner struct {
+	opts MultiScannerOptions
+	logs []*logScan
+}
+
+// logScan is the state of the scan of one log.
+type logScan struct {
+	log *loglist3.Log
+	id  ct.LogID
+	sc  *Scanner
+
+	mu      sync.Mutex
+	start   int64
+	end     int64
+	started time.Time
+	done    bool
+	err     error
+}
+
+// NewMultiScanner creates a MultiScanner for the logs in ll, filtered by
+// status if opts.Statuses is set.
+func NewMultiScanner(ll *loglist3.LogList, opts MultiScannerOptions) (*MultiScanner, error) {
+	if len(opts.Statuses) > 0 {
+		selected := ll.SelectByStatus(opts.Statuses)
+		ll = &selected
+	}
+	if opts.Matcher == nil {
+		opts.Matcher = &MatchAll{}
+	}
+	if opts.NumWorkers <= 0 {
+		opts.NumWorkers = 1
+	}
+	newClient := opts.NewClient
+	if newClient == nil {
+		newClient = newLogListClient
+	}
+	var sem chan struct{}
+	if opts.MaxParallelFetch > 0 {
+		sem = make(chan struct{}, opts.MaxParallelFetch)
+	}
+
+	m := &MultiScanner{opts: opts}
+	for _, op := range ll.Operators {
+		for _, l := range op.Logs {
+			if len(l.LogID) != sha256.Size {
+				return nil, fmt.Errorf("log %s: LogID has length %d, want %d", l.URL, len(l.LogID), sha256.Size)
+			}
+			lc, err := newClient(l)
+			if err != nil {
+				return nil, fmt.Errorf("log %s: failed to create client: %v", l.URL, err)
+			}
+			if sem != nil {
+				lc = &budgetClient{LogClient: lc, sem: sem}
+			}
+			scanOpts := opts.ScannerOptions
+			scanOpts.StartIndex, scanOpts.EndIndex = 0, 0
+			scanOpts.Checkpoints = nil
+			if opts.LogCheckpoints != nil {
+				scanOpts.Checkpoints = opts.LogCheckpoints(l)
+			}
+			ls := &logScan{log: l, sc: NewScanner(lc, scanOpts)}
+			copy(ls.id.KeyID[:], l.LogID)
+			m.logs = append(m.logs, ls)
+		}
+	}
+	return m, nil
+}
+
+func newLogListClient(l *loglist3.Log) (LogClient, error) {
+	return client.New(l.URL, http.DefaultClient, jsonclient.Options{PublicKeyDER: l.Key, UserAgent: "ct-go-multiscanner/1.0"})
+}
+
+// budgetClient is a LogClient which shares a limit on concurrent get-entries
+// requests with other budgetClients.
+type budgetClient struct {
+	LogClient
+	sem chan struct{}
+}
+
+func (c *budgetClient) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.sem }()
+	return c.LogClient.GetRawEntries(ctx, start, end)
+}
+
+// Scan scans all the logs, and calls found for each matching entry. Blocks
+// until the scans of all logs are complete, or ctx is canceled. found is
+// called concurrently. A failure to scan one log doesn't stop the others,
+// and is included in the returned error.
+func (m *MultiScanner) Scan(ctx context.Context, found func(*LogMatch)) error {
+	type job struct {
+		ls   *logScan
+		info entryInfo
+	}
+	jobs := make(chan job, m.opts.BufferSize)
+
+	var matchers sync.WaitGroup
+	for w := 0; w < m.opts.NumWorkers; w++ {
+		matchers.Add(1)
+		go func() {
+			defer matchers.Done()
+			for j := range jobs {
+				j.ls.match(j.info, found)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	go m.logThroughput(stop)
+	defer close(stop)
+
+	var fetchers sync.WaitGroup
+	for _, ls := range m.logs {
+		fetchers.Add(1)
+		go func(ls *logScan) {
+			defer fetchers.Done()
+			ls.run(ctx, func(e entryInfo) { jobs <- job{ls: ls, info: e} })
+		}(ls)
+	}
+	fetchers.Wait()
+	close(jobs)
+	matchers.Wait()
+
+	var errs []error
+	for _, ls := range m.logs {
+		if err := ls.result(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ls.log.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run scans the log, passing its entries to send.
+func (ls *logScan) run(ctx context.Context, send func(entryInfo)) {
+	ls.mu.Lock()
+	ls.started = time.Now()
+	ls.mu.Unlock()
+
+	var err error
+	if _, err = ls.sc.fetcher.Prepare(ctx); err == nil {
+		ls.mu.Lock()
+		ls.start, ls.end = ls.sc.opts.StartIndex, ls.sc.opts.EndIndex
+		ls.mu.Unlock()
+		err = ls.sc.fetcher.Run(ctx, ls.sc.feed(send))
+	}
+	if err != nil {
+		klog.Errorf("%s: Scan failed: %v", ls.log.URL, err)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.done, ls.err = true, err
+}
+
+func (ls *logScan) match(e entryInfo, found func(*LogMatch)) {
+	report := func(entry *ct.RawLogEntry) {
+		found(&LogMatch{Log: ls.log, LogID: ls.id, Entry: entry})
+	}
+	ls.sc.matchEntry(e, report, report)
+}
+
+func (ls *logScan) result() error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.err
+}
+
+func (ls *logScan) progress() LogProgress {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	p := LogProgress{
+		URL:        ls.log.URL,
+		LogID:      ls.id,
+		StartIndex: ls.start,
+		EndIndex:   ls.end,
+		Processed:  atomic.LoadInt64(&ls.sc.certsProcessed),
+		Matched:    atomic.LoadInt64(&ls.sc.certsMatched),
+		Done:       ls.done,
+		Err:        ls.err,
+	}
+	if !ls.started.IsZero() {
+		if secs := time.Since(ls.started).Seconds(); secs > 0 {
+			p.Throughput = float64(p.Processed) / secs
+		}
+	}
+	return p
+}
+
+// Progress returns the progress of the scan of each log, in log list order.
+// It is safe to call concurrently with Scan.
+func (m *MultiScanner) Progress() []LogProgress {
+	progress := make([]LogProgress, len(m.logs))
+	for i, ls := range m.logs {
+		progress[i] = ls.progress()
+	}
+	return progress
+}
+
+func (m *MultiScanner) logThroughput(stop <-chan struct{}) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, p := range m.Progress() {
+				if p.Done {
+					continue
+				}
+				klog.V(1).Infof("%s: Processed %d of [%d, %d), matched %d. Throughput: %3.2f",
+					p.URL, p.Processed, p.StartIndex, p.EndIndex, p.Matched, p.Throughput)
+			}
+		}
+	}
+}
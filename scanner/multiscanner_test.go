@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/loglist3"
+)
+
+func testLogList() *loglist3.LogList {
+	newLog := func(url string, state *loglist3.LogStates) *loglist3.Log {
+		id := sha256.Sum256([]byte(url))
+		return &loglist3.Log{URL: url, LogID: id[:], State: state}
+	}
+	usable := &loglist3.LogStates{Usable: &loglist3.LogState{}}
+	retired := &loglist3.LogStates{Retired: &loglist3.LogState{}}
+	return &loglist3.LogList{Operators: []*loglist3.Operator{
+		{Name: "A", Logs: []*loglist3.Log{newLog("https://a1", usable), newLog("https://a2", retired)}},
+		{Name: "B", Logs: []*loglist3.Log{newLog("https://b1", usable)}},
+	}}
+}
+
+// failingClient is a LogClient which can't get an STH.
+type failingClient struct {
+	LogClient
+}
+
+func (failingClient) GetSTH(context.Context) (*ct.SignedTreeHead, error) {
+	return nil, errors.New("log is down")
+}
+
+func TestMultiScanner(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		statuses []loglist3.LogStatus
+		broken   string
+		wantLogs []string
+	}{
+		{desc: "all", wantLogs: []string{"https://a1", "https://a2", "https://b1"}},
+		{desc: "usable", statuses: []loglist3.LogStatus{loglist3.UsableLogStatus}, wantLogs: []string{"https://a1", "https://b1"}},
+		{desc: "one-fails", broken: "https://a2", wantLogs: []string{"https://a1", "https://a2", "https://b1"}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts := MultiScannerOptions{
+				ScannerOptions:   *DefaultScannerOptions(),
+				Statuses:         tc.statuses,
+				MaxParallelFetch: 1,
+				NewClient: func(l *loglist3.Log) (LogClient, error) {
+					fc := newFakeLogClient(t)
+					fc.pageSize = 3
+					if l.URL == tc.broken {
+						return failingClient{LogClient: fc}, nil
+					}
+					return fc, nil
+				},
+			}
+			opts.NumWorkers = 2
+			opts.ParallelFetch = 2
+			opts.BatchSize = 2
+			m, err := NewMultiScanner(testLogList(), opts)
+			if err != nil {
+				t.Fatalf("NewMultiScanner(): %v", err)
+			}
+
+			var mu sync.Mutex
+			matches := make(map[ct.LogID]int)
+			err = m.Scan(context.Background(), func(lm *LogMatch) {
+				mu.Lock()
+				defer mu.Unlock()
+				if want := sha256.Sum256([]byte(lm.Log.URL)); lm.LogID.KeyID != want {
+					t.Errorf("match for %s has LogID %x, want %x", lm.Log.URL, lm.LogID.KeyID, want)
+				}
+				matches[lm.LogID]++
+			})
+			if gotErr, wantErr := err != nil, tc.broken != ""; gotErr != wantErr {
+				t.Fatalf("Scan() = %v, want error: %v", err, wantErr)
+			}
+
+			progress := m.Progress()
+			if len(progress) != len(tc.wantLogs) {
+				t.Fatalf("got progress for %d logs, want %d", len(progress), len(tc.wantLogs))
+			}
+			for i, p := range progress {
+				if p.URL != tc.wantLogs[i] {
+					t.Errorf("progress[%d] is for %s, want %s", i, p.URL, tc.wantLogs[i])
+				}
+				if !p.Done {
+					t.Errorf("%s: not done", p.URL)
+				}
+				wantCount := 4
+				if p.URL == tc.broken {
+					wantCount = 0
+					if p.Err == nil {
+						t.Errorf("%s: no error", p.URL)
+					}
+				}
+				if p.Processed != int64(wantCount) || p.Matched != int64(wantCount) || matches[p.LogID] != wantCount {
+					t.Errorf("%s: processed %d, matched %d, reported %d; want %d", p.URL, p.Processed, p.Matched, matches[p.LogID], wantCount)
+				}
+				if wantCount > 0 && (p.EndIndex != 4 || p.Throughput <= 0) {
+					t.Errorf("%s: EndIndex %d, Throughput %f; want 4 and positive", p.URL, p.EndIndex, p.Throughput)
+				}
+			}
+		})
+	}
+}
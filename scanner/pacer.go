@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/trillian/client/backoff"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+// pacer coordinates the get-entries requests of all the Fetcher workers. It
+// applies the optional request rate limit, and in adaptive mode learns the
+// Log's page size and makes all workers back off together when the Log
+// throttles them.
+type pacer struct {
+	uri      string
+	limiter  *rate.Limiter // Nil if the request rate is not limited.
+	adaptive bool
+
+	mu        sync.Mutex
+	pageSize  int64           // The latest short response size, or 0.
+	notBefore time.Time       // No requests are sent before this time.
+	bo        backoff.Backoff // Shared backoff for throttling responses.
+}
+
+func newPacer(uri string, opts *FetcherOptions) *pacer {
+	p := &pacer{
+		uri:      uri,
+		adaptive: opts.AdaptiveBatch,
+		bo: backoff.Backoff{
+			Min:    1 * time.Second,
+			Max:    30 * time.Second,
+			Factor: 2,
+			Jitter: true,
+		},
+	}
+	if opts.RequestsPerSecond > 0 {
+		burst := opts.RequestBurst
+		if burst < 1 {
+			burst = 1
+		}
+		p.limiter = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), burst)
+	}
+	return p
+}
+
+// wait blocks until a request can be sent, or the context is done.
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	notBefore := p.notBefore
+	p.mu.Unlock()
+	if dur := time.Until(notBefore); dur > 0 {
+		timer := time.NewTimer(dur)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if p.limiter != nil {
+		return p.limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// onSuccess records that a request for the given number of entries returned
+// got entries.
+func (p *pacer) onSuccess(requested, got int64) {
+	if !p.adaptive {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bo.Reset()
+	switch {
+	case got > 0 && got < requested:
+		// Logs return short responses when the request crosses their page
+		// size, so the latest short response is the best estimate of it.
+		if got != p.pageSize {
+			klog.V(1).Infof("%s: Learned page size %d", p.uri, got)
+			p.pageSize = got
+		}
+	case p.pageSize > 0 && got > p.pageSize:
+		// The Log served more than the learned page size in full, so it may
+		// serve larger pages now. Full responses of the learned page size
+		// tell nothing new, as all requests are aligned to it.
+		klog.V(1).Infof("%s: Got %d entries, more than page size %d, forgetting it", p.uri, got, p.pageSize)
+		p.pageSize = 0
+	}
+}
+
+// onError records a failed request. In adaptive mode, if the Log indicated
+// that it is overloaded, all workers are paused.
+func (p *pacer) onError(err error) {
+	if !p.adaptive {
+		return
+	}
+	var rspErr jsonclient.RspError
+	if !errors.As(err, &rspErr) {
+		return
+	}
+	if rspErr.StatusCode != http.StatusTooManyRequests && rspErr.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if notBefore := time.Now().Add(p.bo.Duration()); notBefore.After(p.notBefore) {
+		klog.V(1).Infof("%s: Throttled (HTTP %d), pausing all workers until %v", p.uri, rspErr.StatusCode, notBefore)
+		p.notBefore = notBefore
+	}
+}
+
+// batchSize returns the number of entries to request at once, which is the
+// learned page size if it is known and smaller than def.
+func (p *pacer) batchSize(def int64) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pageSize > 0 && p.pageSize < def {
+		return p.pageSize
+	}
+	return def
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/certificate-transparency-go/jsonclient"
+)
+
+func TestPacerLearnsPageSize(t *testing.T) {
+	p := newPacer("test", &FetcherOptions{AdaptiveBatch: true})
+	for _, tc := range []struct {
+		requested, got int64
+		want           int64
+	}{
+		{requested: 1000, got: 1000, want: 1000},
+		{requested: 1000, got: 100, want: 100},
+		{requested: 1000, got: 256, want: 256},
+		{requested: 1000, got: 10, want: 10},
+		{requested: 10, got: 10, want: 10},
+		{requested: 10, got: 10, want: 10},
+		{requested: 50, got: 50, want: 1000},
+		{requested: 50, got: 20, want: 20},
+		{requested: 5, got: 5, want: 20},
+		{requested: 20, got: 20, want: 20},
+		{requested: 20, got: 0, want: 20},
+	} {
+		p.onSuccess(tc.requested, tc.got)
+		if got := p.batchSize(1000); got != tc.want {
+			t.Errorf("after onSuccess(%d, %d): batchSize()=%d, want %d", tc.requested, tc.got, got, tc.want)
+		}
+	}
+
+	p = newPacer("test", &FetcherOptions{})
+	p.onSuccess(1000, 10)
+	if got := p.batchSize(1000); got != 1000 {
+		t.Errorf("non-adaptive batchSize()=%d, want 1000", got)
+	}
+}
+
+func TestPacerBacksOffOnThrottling(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		adaptive bool
+		err      error
+		want     bool
+	}{
+		{desc: "429", adaptive: true, err: jsonclient.RspError{StatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")}, want: true},
+		{desc: "503-wrapped", adaptive: true, err: fmt.Errorf("get-entries: %w", jsonclient.RspError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("busy")}), want: true},
+		{desc: "500", adaptive: true, err: jsonclient.RspError{StatusCode: http.StatusInternalServerError, Err: errors.New("oops")}},
+		{desc: "other", adaptive: true, err: errors.New("network")},
+		{desc: "non-adaptive", err: jsonclient.RspError{StatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := newPacer("test", &FetcherOptions{AdaptiveBatch: tc.adaptive})
+			p.onError(tc.err)
+			if got := time.Until(p.notBefore) > 0; got != tc.want {
+				t.Errorf("paused=%v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPacerWaitCancelled(t *testing.T) {
+	p := newPacer("test", &FetcherOptions{AdaptiveBatch: true})
+	p.onError(jsonclient.RspError{StatusCode: http.StatusTooManyRequests, Err: errors.New("slow down")})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.wait(ctx); err != context.Canceled {
+		t.Errorf("wait()=%v, want %v", err, context.Canceled)
+	}
+}
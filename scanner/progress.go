@@ -0,0 +1,204 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"k8s.io/klog/v2"
+)
+
+var rangeFactory = &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+
+// RootMismatchError is returned by the Fetcher in verifying mode when the
+// fetched entries don't hash to the root hash of the Log's STH, i.e. the Log
+// (or something between it and the Fetcher) served inconsistent data.
+type RootMismatchError struct {
+	// STH is the tree head that the entries were checked against.
+	STH *ct.SignedTreeHead
+	// Computed is the root hash of the fetched entries [0, STH.TreeSize).
+	Computed []byte
+}
+
+func (e *RootMismatchError) Error() string {
+	return fmt.Sprintf("fetched entries hash to root %x at tree size %d, but STH has %x", e.Computed, e.STH.TreeSize, e.STH.SHA256RootHash[:])
+}
+
+// doneRange is a processed entry range which is not yet contiguous with the
+// ranges before it.
+type doneRange struct {
+	end    int64
+	hashes [][]byte // Leaf hashes, only populated when verifying.
+}
+
+// progressTracker keeps track of the processed entry ranges, which can
+// complete out of order. It saves the highest contiguous processed index to
+// a CheckpointStore, and, when verifying, extends a compact Merkle range over
+// the contiguous prefix and checks it against the STHs that it crosses.
+type progressTracker struct {
+	store CheckpointStore // Nil if checkpoints are not saved.
+
+	mu   sync.Mutex
+	next int64                // The first entry index that is not processed yet.
+	done map[int64]doneRange  // Processed ranges beyond next, keyed by start.
+	rng  *compact.Range       // Covers [0, next). Nil if not verifying.
+	sths []*ct.SignedTreeHead // STHs to verify against, ordered by size.
+}
+
+func newProgressTracker(store CheckpointStore, next int64) *progressTracker {
+	return &progressTracker{store: store, next: next, done: make(map[int64]doneRange)}
+}
+
+// enableVerification makes the tracker verify entries, starting from the
+// given compact range hashes covering [0, next).
+func (p *progressTracker) enableVerification(hashes [][]byte) error {
+	rng, err := rangeFactory.NewRange(0, uint64(p.next), hashes)
+	if err != nil {
+		return fmt.Errorf("bad compact range for [0, %d): %v", p.next, err)
+	}
+	p.rng = rng
+	return nil
+}
+
+// verifying returns whether the tracker checks entries against STHs.
+func (p *progressTracker) verifying() bool {
+	return p.rng != nil
+}
+
+// addSTH registers an STH to verify against once the processed prefix reaches
+// its tree size. STHs must be added in increasing tree size order.
+func (p *progressTracker) addSTH(sth *ct.SignedTreeHead) {
+	if !p.verifying() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sths = append(p.sths, sth)
+}
+
+// markDone records that entries in [start, end) have been processed, with the
+// given leaf hashes if verifying. If this extends the contiguous processed
+// prefix, any STHs that it reaches are verified, and a new Checkpoint saved.
+// Returns a *RootMismatchError if verification fails, and an error if the
+// range overlaps one that was already processed, as the prefix could then
+// never be extended past it.
+func (p *progressTracker) markDone(ctx context.Context, start, end int64, hashes [][]byte, sth *ct.SignedTreeHead) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if end < start {
+		return fmt.Errorf("invalid processed range [%d, %d)", start, end)
+	}
+	if start == end {
+		return nil
+	}
+	if start < p.next {
+		return fmt.Errorf("processed range [%d, %d) overlaps the processed prefix [0, %d)", start, end, p.next)
+	}
+	for s, r := range p.done {
+		if start < r.end && s < end {
+			return fmt.Errorf("processed range [%d, %d) overlaps processed range [%d, %d)", start, end, s, r.end)
+		}
+	}
+	if start != p.next {
+		p.done[start] = doneRange{end: end, hashes: hashes}
+		return nil
+	}
+	for r, ok := (doneRange{end: end, hashes: hashes}), true; ok; r, ok = p.done[p.next] {
+		delete(p.done, p.next)
+		if err := p.extend(r); err != nil {
+			return err
+		}
+	}
+
+	if p.store == nil {
+		return nil
+	}
+	cp := &Checkpoint{NextIndex: p.next, STH: sth}
+	if p.verifying() {
+		cp.CompactRange = p.rng.Hashes()
+	}
+	klog.V(2).Infof("Saving checkpoint at index %d", p.next)
+	// Save while holding the lock so that checkpoints never go backwards.
+	if err := p.store.Save(ctx, cp); err != nil {
+		klog.Errorf("Failed to save checkpoint at index %d: %v", p.next, err)
+	}
+	return nil
+}
+
+// extend appends the range r starting at p.next to the processed prefix. When
+// verifying, the leaves are appended one at a time, so that the STHs whose
+// tree size falls within r are checked too.
+func (p *progressTracker) extend(r doneRange) error {
+	if !p.verifying() {
+		p.next = r.end
+		return nil
+	}
+	if int64(len(r.hashes)) != r.end-p.next {
+		return fmt.Errorf("got %d leaf hashes for entries [%d, %d)", len(r.hashes), p.next, r.end)
+	}
+	// An STH may have been added at the size of the prefix already.
+	if err := p.verifySTHs(); err != nil {
+		return err
+	}
+	for _, hash := range r.hashes {
+		if err := p.rng.Append(hash, nil); err != nil {
+			return err
+		}
+		p.next++
+		if err := p.verifySTHs(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySTHs checks the STHs at the tree size of the processed prefix against
+// its root hash.
+func (p *progressTracker) verifySTHs() error {
+	for len(p.sths) > 0 && int64(p.sths[0].TreeSize) <= p.next {
+		sth := p.sths[0]
+		p.sths = p.sths[1:]
+		if int64(sth.TreeSize) != p.next {
+			return fmt.Errorf("STH at tree size %d is behind the processed prefix [0, %d)", sth.TreeSize, p.next)
+		}
+		root, err := p.rng.GetRootHash(nil)
+		if err != nil {
+			return err
+		}
+		if root == nil {
+			root = rfc6962.DefaultHasher.EmptyRoot()
+		}
+		if !bytes.Equal(root, sth.SHA256RootHash[:]) {
+			return &RootMismatchError{STH: sth, Computed: root}
+		}
+		klog.V(1).Infof("Verified entries against STH at tree size %d", sth.TreeSize)
+	}
+	return nil
+}
+
+// hashLeaves returns the RFC 6962 leaf hashes of the entries in b.
+func hashLeaves(b EntryBatch) [][]byte {
+	hashes := make([][]byte, len(b.Entries))
+	for i, e := range b.Entries {
+		hashes[i] = rfc6962.DefaultHasher.HashLeaf(e.LeafInput)
+	}
+	return hashes
+}
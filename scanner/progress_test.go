@@ -0,0 +1,170 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+func TestProgressTrackerOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	store := &MemoryCheckpointStore{}
+	p := newProgressTracker(store, 10)
+
+	for _, tc := range []struct {
+		start, end int64
+		wantNext   int64 // -1 means no checkpoint saved yet.
+	}{
+		{start: 20, end: 30, wantNext: -1},
+		{start: 30, end: 35, wantNext: -1},
+		{start: 10, end: 20, wantNext: 35},
+		{start: 40, end: 50, wantNext: 35},
+		{start: 35, end: 40, wantNext: 50},
+	} {
+		if err := p.markDone(ctx, tc.start, tc.end, nil, nil); err != nil {
+			t.Fatalf("markDone(%d, %d)=%v", tc.start, tc.end, err)
+		}
+		cp, _ := store.Load(ctx)
+		got := int64(-1)
+		if cp != nil {
+			got = cp.NextIndex
+		}
+		if got != tc.wantNext {
+			t.Errorf("after markDone(%d, %d): NextIndex=%d, want %d", tc.start, tc.end, got, tc.wantNext)
+		}
+	}
+}
+
+func TestFetcherVerify(t *testing.T) {
+	ctx := context.Background()
+
+	cl := newFakeLogClient(t)
+	opts := FetcherOptions{BatchSize: 1, ParallelFetch: 2, Verify: true}
+	if got := fetchIndices(ctx, t, NewFetcher(cl, &opts)); len(got) != 4 {
+		t.Errorf("Fetched %v, want 4 entries", got)
+	}
+
+	// Resume a verifying scan from a checkpoint.
+	store := &MemoryCheckpointStore{}
+	cp := &Checkpoint{NextIndex: 2, STH: cl.sth, CompactRange: compactRangeOf(t, cl.entries[:2]).Hashes()}
+	if err := store.Save(ctx, cp); err != nil {
+		t.Fatalf("Save()=%v", err)
+	}
+	opts = FetcherOptions{BatchSize: 1, ParallelFetch: 2, Verify: true, Checkpoints: store}
+	if got := fetchIndices(ctx, t, NewFetcher(cl, &opts)); fmt.Sprint(got) != "[2 3]" {
+		t.Errorf("Fetched %v after resume, want [2 3]", got)
+	}
+}
+
+func TestFetcherVerifyMismatch(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	sth := *cl.sth
+	sth.SHA256RootHash[0] ^= 0xFF
+	cl.sth = &sth
+
+	opts := FetcherOptions{BatchSize: 1, ParallelFetch: 2, Verify: true}
+	err := NewFetcher(cl, &opts).Run(ctx, func(EntryBatch) {})
+	var mismatch *RootMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Run()=%v, want RootMismatchError", err)
+	}
+	if got, want := mismatch.STH.TreeSize, uint64(4); got != want {
+		t.Errorf("Mismatch at tree size %d, want %d", got, want)
+	}
+}
+
+func TestFetcherVerifyBadRange(t *testing.T) {
+	ctx := context.Background()
+	for _, opts := range []FetcherOptions{
+		{BatchSize: 1, ParallelFetch: 1, Verify: true, StartIndex: 1},
+		{BatchSize: 1, ParallelFetch: 1, Verify: true, EndIndex: 3},
+	} {
+		if _, err := NewFetcher(newFakeLogClient(t), &opts).Prepare(ctx); err == nil {
+			t.Errorf("Prepare(start=%d, end=%d)=nil, want error", opts.StartIndex, opts.EndIndex)
+		}
+	}
+}
+
+func TestProgressTrackerOverlap(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		desc       string
+		start, end int64
+	}{
+		{desc: "prefix", start: 5, end: 15},
+		{desc: "same-start", start: 20, end: 25},
+		{desc: "inside", start: 22, end: 28},
+		{desc: "straddle", start: 15, end: 21},
+		{desc: "reversed", start: 35, end: 32},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := newProgressTracker(nil, 10)
+			if err := p.markDone(ctx, 20, 30, nil, nil); err != nil {
+				t.Fatalf("markDone(20, 30)=%v", err)
+			}
+			if err := p.markDone(ctx, tc.start, tc.end, nil, nil); err == nil {
+				t.Errorf("markDone(%d, %d)=nil, want error", tc.start, tc.end)
+			}
+		})
+	}
+}
+
+func TestProgressTrackerSTHInsideRange(t *testing.T) {
+	ctx := context.Background()
+	cl := newFakeLogClient(t)
+	hashes := hashLeaves(EntryBatch{Entries: cl.entries})
+	root, err := compactRangeOf(t, cl.entries[:2]).GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash(): %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc         string
+		corrupt      bool
+		wantMismatch bool
+	}{
+		{desc: "match"},
+		{desc: "mismatch", corrupt: true, wantMismatch: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := newProgressTracker(nil, 0)
+			if err := p.enableVerification(nil); err != nil {
+				t.Fatalf("enableVerification()=%v", err)
+			}
+			sth := &ct.SignedTreeHead{TreeSize: 2}
+			copy(sth.SHA256RootHash[:], root)
+			if tc.corrupt {
+				sth.SHA256RootHash[0] ^= 0xFF
+			}
+			p.addSTH(sth)
+			p.addSTH(cl.sth)
+
+			err := p.markDone(ctx, 0, 4, hashes, nil)
+			var mismatch *RootMismatchError
+			if got := errors.As(err, &mismatch); got != tc.wantMismatch {
+				t.Fatalf("markDone(0, 4)=%v, want mismatch %v", err, tc.wantMismatch)
+			}
+			if tc.wantMismatch && mismatch.STH.TreeSize != 2 {
+				t.Errorf("Mismatch at tree size %d, want 2", mismatch.STH.TreeSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+// ParseQuery compiles a textual query into a LeafMatcher built from the
+// matchers in this package, for example:
+//
+//	subject~"\.example\.com$" && !precert && not_after > 2025-01-01
+//
+// Terms can be combined with && (and), || (or) and ! (not), and grouped with
+// parentheses; && binds tighter than ||. The supported terms are:
+//
+//	subject ~ REGEX    CN or any DNS SAN matches REGEX
+//	issuer ~ REGEX     issuer CN matches REGEX
+//	serial = NUMBER    serial number is NUMBER (decimal, or hex with 0x)
+//	timestamp OP TIME  the log entry timestamp compares to TIME
+//	not_before OP TIME the [pre-]certificate's NotBefore compares to TIME
+//	not_after OP TIME  the [pre-]certificate's NotAfter compares to TIME
+//	precert            the entry is a precertificate
+//	parse_error        the [pre-]certificate fails to parse
+//
+// where OP is one of =, !=, <, <=, > and >=, and TIME is a date (2006-01-02),
+// an RFC 3339 time, or for timestamp a number of milliseconds since the epoch.
+// Values can be double-quoted, in which case \" stands for a quote and all
+// other characters, including backslashes, are taken literally.
+func ParseQuery(query string) (LeafMatcher, error) {
+	p := &queryParser{lex: queryLexer{input: query}}
+	p.advance()
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	if lm, ok := m.(LeafMatcher); ok {
+		return lm, nil
+	}
+	return And(m), nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokValue
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// queryLexer splits a query into tokens.
+type queryLexer struct {
+	input string
+	pos   int
+}
+
+func isValueChar(c byte) bool {
+	return !strings.ContainsRune(" \t\r\n()!&|<>=~\"", rune(c))
+}
+
+func (l *queryLexer) next() (token, error) {
+	for l.pos < len(l.input) && strings.ContainsRune(" \t\r\n", rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos == len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	rest := l.input[l.pos:]
+	for _, t := range []struct {
+		text string
+		kind tokenKind
+	}{
+		{"&&", tokAnd}, {"||", tokOr}, {"!=", tokOp}, {"<=", tokOp}, {">=", tokOp}, {"==", tokOp},
+		{"(", tokLParen}, {")", tokRParen}, {"!", tokNot}, {"<", tokOp}, {">", tokOp}, {"=", tokOp}, {"~", tokOp},
+	} {
+		if strings.HasPrefix(rest, t.text) {
+			l.pos += len(t.text)
+			return token{kind: t.kind, text: t.text, pos: start}, nil
+		}
+	}
+	if rest[0] == '"' {
+		var sb strings.Builder
+		for l.pos++; l.pos < len(l.input); l.pos++ {
+			switch c := l.input[l.pos]; {
+			case c == '"':
+				l.pos++
+				return token{kind: tokValue, text: sb.String(), pos: start}, nil
+			case c == '\\' && l.pos+1 < len(l.input) && l.input[l.pos+1] == '"':
+				l.pos++
+				sb.WriteByte('"')
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return token{}, fmt.Errorf("query: unterminated string at offset %d", start)
+	}
+	for l.pos < len(l.input) && isValueChar(l.input[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		return token{}, fmt.Errorf("query: unexpected %q at offset %d", l.input[start], start)
+	}
+	return token{kind: tokIdent, text: l.input[start:l.pos], pos: start}, nil
+}
+
+// queryParser is a recursive descent parser for the query language.
+type queryParser struct {
+	lex queryLexer
+	tok token
+	err error // Lexer error, reported when the bad token is consumed.
+}
+
+func (p *queryParser) advance() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+// errorf returns an error at the current token.
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.tok.pos, format, args...)
+}
+
+func (p *queryParser) errorAt(pos int, format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("query: %s at offset %d", fmt.Sprintf(format, args...), pos)
+}
+
+func (p *queryParser) parseOr() (interface{}, error) {
+	ms, err := p.parseList(tokOr, p.parseAnd)
+	if err != nil || len(ms) == 1 {
+		return first(ms), err
+	}
+	return Or(ms...), nil
+}
+
+func (p *queryParser) parseAnd() (interface{}, error) {
+	ms, err := p.parseList(tokAnd, p.parseUnary)
+	if err != nil || len(ms) == 1 {
+		return first(ms), err
+	}
+	return And(ms...), nil
+}
+
+// parseList parses one or more sep-separated operands.
+func (p *queryParser) parseList(sep tokenKind, parse func() (interface{}, error)) ([]interface{}, error) {
+	var ms []interface{}
+	for {
+		m, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+		if p.tok.kind != sep {
+			return ms, nil
+		}
+		p.advance()
+	}
+}
+
+func first(ms []interface{}) interface{} {
+	if len(ms) == 0 {
+		return nil
+	}
+	return ms[0]
+}
+
+func (p *queryParser) parseUnary() (interface{}, error) {
+	switch p.tok.kind {
+	case tokNot:
+		p.advance()
+		m, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(m), nil
+	case tokLParen:
+		p.advance()
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("missing )")
+		}
+		p.advance()
+		return m, nil
+	case tokIdent:
+		return p.parseTerm()
+	}
+	return nil, p.errorf("expected term, got %q", p.tok.text)
+}
+
+func (p *queryParser) parseTerm() (interface{}, error) {
+	field, pos := p.tok.text, p.tok.pos
+	p.advance()
+	switch field {
+	case "precert":
+		return &matchEntryType{entryType: ct.PrecertLogEntryType}, nil
+	case "parse_error":
+		return CertParseFailMatcher{}, nil
+	}
+
+	if p.tok.kind != tokOp {
+		return nil, p.errorf("expected operator after %q", field)
+	}
+	op := p.tok.text
+	p.advance()
+	if p.tok.kind != tokIdent && p.tok.kind != tokValue {
+		return nil, p.errorf("expected value after %q", op)
+	}
+	value := p.tok.text
+	p.advance()
+
+	switch field {
+	case "subject", "issuer":
+		if op != "~" {
+			return nil, p.errorAt(pos, "%s only supports ~, got %q", field, op)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorAt(pos, "bad regex %q: %v", value, err)
+		}
+		if field == "subject" {
+			return MatchSubjectRegex{CertificateSubjectRegex: re, PrecertificateSubjectRegex: re}, nil
+		}
+		return MatchIssuerRegex{CertificateIssuerRegex: re, PrecertificateIssuerRegex: re}, nil
+	case "serial":
+		if op != "=" && op != "==" {
+			return nil, p.errorAt(pos, "serial only supports =, got %q", op)
+		}
+		var sn big.Int
+		if _, ok := sn.SetString(value, 0); !ok {
+			return nil, p.errorAt(pos, "bad serial number %q", value)
+		}
+		return MatchSerialNumber{SerialNumber: sn}, nil
+	case "timestamp", "not_before", "not_after":
+		cmp, err := parseCompareOp(op)
+		if err != nil {
+			return nil, p.errorAt(pos, "%v", err)
+		}
+		if field == "timestamp" {
+			ts, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				t, terr := parseQueryTime(value)
+				if terr != nil {
+					return nil, p.errorAt(pos, "%v", terr)
+				}
+				ts = uint64(t.UnixMilli())
+			}
+			return &matchTimestamp{op: cmp, timestamp: ts}, nil
+		}
+		t, err := parseQueryTime(value)
+		if err != nil {
+			return nil, p.errorAt(pos, "%v", err)
+		}
+		return &matchValidity{op: cmp, when: t, notAfter: field == "not_after"}, nil
+	}
+	return nil, p.errorAt(pos, "unknown field %q", field)
+}
+
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("bad time %q, want 2006-01-02 or RFC 3339", value)
+}
+
+// compareOp is a comparison operator in a query.
+type compareOp string
+
+func parseCompareOp(op string) (compareOp, error) {
+	switch op {
+	case "==":
+		return "=", nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compareOp(op), nil
+	}
+	return "", fmt.Errorf("bad comparison operator %q", op)
+}
+
+// holds returns whether the operator holds for a value that compares to the
+// reference value as c, i.e. -1 if less, 0 if equal, and 1 if greater.
+func (op compareOp) holds(c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// matchEntryType is a LeafMatcher which matches entries of the given type.
+type matchEntryType struct {
+	entryType ct.LogEntryType
+}
+
+func (m *matchEntryType) Matches(leaf *ct.LeafEntry) bool {
+	return m.matchInput(&leafInput{leaf: leaf})
+}
+
+func (m *matchEntryType) matchInput(in *leafInput) bool {
+	mtl := in.merkleTreeLeaf()
+	return mtl != nil && mtl.TimestampedEntry.EntryType == m.entryType
+}
+
+// matchTimestamp is a LeafMatcher which compares the log entry timestamp.
+type matchTimestamp struct {
+	op        compareOp
+	timestamp uint64
+}
+
+func (m *matchTimestamp) Matches(leaf *ct.LeafEntry) bool {
+	return m.matchInput(&leafInput{leaf: leaf})
+}
+
+func (m *matchTimestamp) matchInput(in *leafInput) bool {
+	mtl := in.merkleTreeLeaf()
+	if mtl == nil {
+		return false
+	}
+	ts := mtl.TimestampedEntry.Timestamp
+	switch {
+	case ts < m.timestamp:
+		return m.op.holds(-1)
+	case ts > m.timestamp:
+		return m.op.holds(1)
+	}
+	return m.op.holds(0)
+}
+
+// matchValidity is a Matcher which compares the NotBefore or NotAfter time of
+// [pre-]certificates.
+type matchValidity struct {
+	op       compareOp
+	when     time.Time
+	notAfter bool
+}
+
+func (m *matchValidity) CertificateMatches(c *x509.Certificate) bool {
+	t := c.NotBefore
+	if m.notAfter {
+		t = c.NotAfter
+	}
+	return m.op.holds(t.Compare(m.when))
+}
+
+func (m *matchValidity) PrecertificateMatches(p *ct.Precertificate) bool {
+	return m.CertificateMatches(p.TBSCertificate)
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	entries := testEntries(t)
+	for _, tc := range []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{query: `subject~"\.com$"`, want: "[0 2 3]"},
+		{query: `subject ~ "\.com$" && !precert && not_after > 2013-07-01`, want: "[2 3]"},
+		{query: `issuer~Google || serial=63034`, want: "[0 1]"},
+		{query: `serial = 0xF63A`, want: "[1]"},
+		{query: `!(timestamp >= 1364288407155 && timestamp <= 1364288407155)`, want: "[0 3]"},
+		{query: `timestamp < 2013-03-26T01:00:00Z`, want: "[0]"},
+		{query: `not_before < "2012-01-01T00:00:00Z" || precert`, want: "[1 3]"},
+		{query: `precert`, want: "[]"},
+		{query: `!parse_error && not_after != 2013-06-07T19:43:27Z`, want: "[1 2 3]"},
+		{query: `subject~"a\"b"`, want: "[]"},
+		{query: ``, wantErr: true},
+		{query: `subject`, wantErr: true},
+		{query: `subject = foo`, wantErr: true},
+		{query: `subject ~ "(" `, wantErr: true},
+		{query: `colour ~ red`, wantErr: true},
+		{query: `(precert`, wantErr: true},
+		{query: `precert precert`, wantErr: true},
+		{query: `precert && "unterminated`, wantErr: true},
+		{query: `not_after > yesterday`, wantErr: true},
+		{query: `serial > 5`, wantErr: true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			m, err := ParseQuery(tc.query)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseQuery()=%v, want error: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := matchingIndices(m, entries); got != tc.want {
+				t.Errorf("matched %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
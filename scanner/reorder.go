@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+// reorderBuffer passes EntryBatch values to a callback in index order, even
+// though they are added in arbitrary order. Batches that arrive ahead of
+// their turn are held in a bounded window; once it is full, adding further
+// out-of-order batches blocks until the window drains.
+//
+// The Fetcher can't deadlock on a full window: ranges are handed out to
+// workers in increasing order, so the worker holding the next expected batch
+// is never one of those blocked in add.
+type reorderBuffer struct {
+	fn     func(EntryBatch)
+	window int
+
+	mu       sync.Mutex
+	next     int64                // Start index of the next batch to deliver.
+	pending  map[int64]EntryBatch // Out-of-order batches, keyed by Start.
+	advanced chan struct{}        // Closed and replaced whenever next moves.
+}
+
+func newReorderBuffer(start int64, window int, fn func(EntryBatch)) *reorderBuffer {
+	return &reorderBuffer{
+		fn:       fn,
+		window:   window,
+		next:     start,
+		pending:  make(map[int64]EntryBatch),
+		advanced: make(chan struct{}),
+	}
+}
+
+// add delivers b, along with any pending batches that follow it, if b is the
+// next batch expected. Otherwise it stores b until its turn, first waiting
+// for room in the window. Returns an error only if ctx is done while waiting,
+// in which case b is dropped.
+func (r *reorderBuffer) add(ctx context.Context, b EntryBatch) error {
+	if len(b.Entries) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	for b.Start != r.next && len(r.pending) >= r.window {
+		advanced := r.advanced
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-advanced:
+		}
+		r.mu.Lock()
+	}
+	defer r.mu.Unlock()
+
+	if b.Start != r.next {
+		r.pending[b.Start] = b
+		return nil
+	}
+	// The callback is run under the lock, which is what keeps the order.
+	for ok := true; ok; b, ok = r.pending[r.next] {
+		delete(r.pending, b.Start)
+		r.fn(b)
+		r.next = b.Start + int64(len(b.Entries))
+	}
+	close(r.advanced)
+	r.advanced = make(chan struct{})
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+func batchOf(start, size int64) EntryBatch {
+	return EntryBatch{Start: start, Entries: make([]ct.LeafEntry, size)}
+}
+
+func TestReorderBufferDeliversInOrder(t *testing.T) {
+	ctx := context.Background()
+	var got []int64
+	rb := newReorderBuffer(10, 10, func(b EntryBatch) { got = append(got, b.Start) })
+
+	for _, b := range []EntryBatch{batchOf(15, 5), batchOf(25, 5), batchOf(10, 5), batchOf(20, 5), batchOf(30, 0)} {
+		if err := rb.add(ctx, b); err != nil {
+			t.Fatalf("add(%d)=%v", b.Start, err)
+		}
+	}
+	if want := []int64{10, 15, 20, 25}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Delivered %v, want %v", got, want)
+	}
+	if len(rb.pending) != 0 {
+		t.Errorf("%d batches still pending", len(rb.pending))
+	}
+}
+
+func TestReorderBufferBackpressure(t *testing.T) {
+	ctx := context.Background()
+	rb := newReorderBuffer(0, 1, func(EntryBatch) {})
+	if err := rb.add(ctx, batchOf(10, 10)); err != nil {
+		t.Fatalf("add(10)=%v", err)
+	}
+
+	// The window is full, so this blocks until the gap is filled.
+	added := make(chan error)
+	go func() { added <- rb.add(ctx, batchOf(20, 10)) }()
+	select {
+	case err := <-added:
+		t.Fatalf("add(20)=%v, want it to block", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := rb.add(ctx, batchOf(0, 10)); err != nil {
+		t.Fatalf("add(0)=%v", err)
+	}
+	if err := <-added; err != nil {
+		t.Fatalf("add(20)=%v", err)
+	}
+
+	// A blocked add returns when the context is cancelled.
+	if err := rb.add(ctx, batchOf(40, 10)); err != nil {
+		t.Fatalf("add(40)=%v", err)
+	}
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := rb.add(cctx, batchOf(50, 10)); err != context.DeadlineExceeded {
+		t.Errorf("add(50)=%v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -24,8 +24,10 @@ import (
 	"math/big"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"regexp"
+	"syscall"
 	"time"
 
 	ct "github.com/google/certificate-transparency-go"
@@ -43,9 +45,12 @@ const (
 var (
 	logURI = flag.String("log_uri", "https://ct.googleapis.com/aviator", "CT log base URI")
 
+	query             = flag.String("query", "", "Query to match entries with, e.g. 'subject~\"\\.example\\.com$\" && !precert' (overrides other match flags)")
+	watchList         = flag.String("watch_list", "", "File with domains to watch, one per line (reloaded on SIGHUP)")
 	matchSubjectRegex = flag.String("match_subject_regex", ".*", "Regex to match CN/SAN")
 	matchIssuerRegex  = flag.String("match_issuer_regex", "", "Regex to match in issuer CN")
 	precertsOnly      = flag.Bool("precerts_only", false, "Only match precerts")
+	unexpiredOnly     = flag.Bool("unexpired_only", false, "Only match [pre-]certificates which haven't expired")
 	serialNumber      = flag.String("serial_number", "", "Serial number of certificate of interest")
 	sctTimestamp      = flag.Uint64("sct_timestamp_ms", 0, "Timestamp of logged SCT")
 
@@ -53,11 +58,15 @@ var (
 	nfParseErrors  = flag.Bool("non_fatal_errors", false, "Treat non-fatal parse errors as also matching (with --parse_errors)")
 	validateErrors = flag.Bool("validate_errors", false, "Only match certificates with validation errors")
 
-	batchSize     = flag.Int("batch_size", 1000, "Max number of entries to request at per call to get-entries")
-	numWorkers    = flag.Int("num_workers", 2, "Number of concurrent matchers")
-	parallelFetch = flag.Int("parallel_fetch", 2, "Number of concurrent GetEntries fetches")
-	startIndex    = flag.Int64("start_index", 0, "Log index to start scanning at")
-	endIndex      = flag.Int64("end_index", 0, "Log index to end scanning at (non-inclusive, 0 = end of log)")
+	batchSize      = flag.Int("batch_size", 1000, "Max number of entries to request at per call to get-entries")
+	numWorkers     = flag.Int("num_workers", 2, "Number of concurrent matchers")
+	parallelFetch  = flag.Int("parallel_fetch", 2, "Number of concurrent GetEntries fetches")
+	startIndex     = flag.Int64("start_index", 0, "Log index to start scanning at")
+	endIndex       = flag.Int64("end_index", 0, "Log index to end scanning at (non-inclusive, 0 = end of log)")
+	requestRate    = flag.Float64("request_rate", 0, "Max number of get-entries requests per second (0 = unlimited)")
+	adaptiveBatch  = flag.Bool("adaptive_batch", false, "Learn the log's page size and back off all fetchers together when throttled")
+	verifyEntries  = flag.Bool("verify", false, "Verify fetched entries against the STH root hash")
+	checkpointFile = flag.String("checkpoint_file", "", "File to store scan progress in, and resume scanning from if it exists")
 
 	printChains = flag.Bool("print_chains", false, "If true prints the whole chain rather than a summary")
 	dumpDir     = flag.String("dump_dir", "", "Directory to store matched certificates in")
@@ -150,6 +159,13 @@ func createRegexes(regexValue string) (*regexp.Regexp, *regexp.Regexp) {
 }
 
 func createMatcherFromFlags(logClient *client.LogClient) (interface{}, error) {
+	if *watchList != "" {
+		return createWatchMatcher(*watchList)
+	}
+	if *query != "" {
+		log.Printf("Using query matcher %q", *query)
+		return scanner.ParseQuery(*query)
+	}
 	if *parseErrors {
 		return scanner.CertParseFailMatcher{MatchNonFatalErrs: *nfParseErrors}, nil
 	}
@@ -183,6 +199,37 @@ func createMatcherFromFlags(logClient *client.LogClient) (interface{}, error) {
 		PrecertificateSubjectRegex: precertRegex}, nil
 }
 
+// createWatchMatcher returns a DomainWatchMatcher for the watch list file at
+// path, which reloads the file whenever the process receives SIGHUP.
+func createWatchMatcher(path string) (*scanner.DomainWatchMatcher, error) {
+	watched, err := scanner.ReadWatchList(path)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := scanner.NewDomainWatchMatcher(watched)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Watching %d domains from %s", len(watched), path)
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			watched, err := scanner.ReadWatchList(path)
+			if err == nil {
+				err = matcher.Reload(watched)
+			}
+			if err != nil {
+				log.Printf("Failed to reload watch list, keeping the old one: %v", err)
+				continue
+			}
+			log.Printf("Reloaded %d domains from %s", len(watched), path)
+		}
+	}()
+	return matcher, nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -208,14 +255,23 @@ func main() {
 
 	opts := scanner.ScannerOptions{
 		FetcherOptions: scanner.FetcherOptions{
-			BatchSize:     *batchSize,
-			ParallelFetch: *parallelFetch,
-			StartIndex:    *startIndex,
-			EndIndex:      *endIndex,
+			BatchSize:         *batchSize,
+			ParallelFetch:     *parallelFetch,
+			StartIndex:        *startIndex,
+			EndIndex:          *endIndex,
+			RequestsPerSecond: *requestRate,
+			AdaptiveBatch:     *adaptiveBatch,
+			Verify:            *verifyEntries,
 		},
 		Matcher:    matcher,
 		NumWorkers: *numWorkers,
 	}
+	if *checkpointFile != "" {
+		opts.Checkpoints = scanner.NewFileCheckpointStore(*checkpointFile)
+	}
+	if *unexpiredOnly {
+		opts.NotAfterWindow.Start = time.Now()
+	}
 	s := scanner.NewScanner(logClient, opts)
 
 	ctx := context.Background()
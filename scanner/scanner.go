@@ -44,6 +44,16 @@ type ScannerOptions struct { // nolint:revive
 
 	// Number of fetched entries to buffer on their way to the callbacks.
 	BufferSize int
+
+	// If not zero, only entries whose log timestamp is in EntryTimestampWindow,
+	// and whose [pre-]certificate's NotBefore and NotAfter times are in
+	// NotBeforeWindow and NotAfterWindow respectively, are matched. E.g., set
+	// NotAfterWindow.Start to the current time to skip expired certificates.
+	// The windows are checked before the Matcher, using the cheapest possible
+	// parse of the entry, so most entries outside them are never fully parsed.
+	EntryTimestampWindow TimeWindow
+	NotBeforeWindow      TimeWindow
+	NotAfterWindow       TimeWindow
 }
 
 // DefaultScannerOptions returns a new ScannerOptions with sensible defaults.
@@ -71,7 +81,16 @@ type Scanner struct {
 	unparsableEntries         int64
 	entriesWithNonFatalErrors int64
 
+	// Counters of the time window filtering stages.
+	timestampRejected int64
+	validityRejected  int64
+	validityUnparsed  int64
+	filterPassed      int64
+	fullyParsed       int64
+
 	fetcher *Fetcher
+	// Applies the time windows from opts. Nil if there are none.
+	filter *timeFilter
 
 	// Configuration options for this Scanner instance.
 	opts ScannerOptions
@@ -83,6 +102,8 @@ type entryInfo struct {
 	index int64
 	// The log entry returned by the log server.
 	entry ct.LeafEntry
+	// If not nil, marked done once the entry has been processed.
+	done *sync.WaitGroup
 }
 
 // Takes the error returned by either x509.ParseCertificate() or
@@ -109,25 +130,73 @@ func (s *Scanner) isCertErrorFatal(err error, logEntry *ct.LogEntry, index int64
 func (s *Scanner) processEntry(info entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
 	atomic.AddInt64(&s.certsProcessed, 1)
 
+	// Entries that the filter can't parse cheaply are checked again once they
+	// are fully parsed.
+	var recheck bool
+	if s.filter != nil {
+		switch s.filter.check(&info.entry) {
+		case filterRejectedTimestamp:
+			atomic.AddInt64(&s.timestampRejected, 1)
+			return nil
+		case filterRejectedValidity:
+			atomic.AddInt64(&s.validityRejected, 1)
+			return nil
+		case filterPassedUnparsed:
+			atomic.AddInt64(&s.validityUnparsed, 1)
+			recheck = true
+		}
+	}
+	if !recheck {
+		atomic.AddInt64(&s.filterPassed, 1)
+	}
+
 	switch matcher := s.opts.Matcher.(type) {
 	case Matcher:
-		return s.processMatcherEntry(matcher, info, foundCert, foundPrecert)
+		return s.processMatcherEntry(matcher, info, recheck, foundCert, foundPrecert)
 	case LeafMatcher:
-		return s.processMatcherLeafEntry(matcher, info, foundCert, foundPrecert)
+		return s.processMatcherLeafEntry(matcher, info, recheck, foundCert, foundPrecert)
 	default:
 		return fmt.Errorf("unexpected matcher type %T", matcher)
 	}
 }
 
-func (s *Scanner) processMatcherEntry(matcher Matcher, info entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
+// parseEntry fully parses the [pre-]certificate of the given entry.
+func (s *Scanner) parseEntry(info entryInfo) (*ct.RawLogEntry, *ct.LogEntry, error) {
 	rawLogEntry, err := ct.RawLogEntryFromLeaf(info.index, &info.entry)
 	if err != nil {
-		return fmt.Errorf("failed to build raw log entry %d: %v", info.index, err)
+		return nil, nil, fmt.Errorf("failed to build raw log entry %d: %v", info.index, err)
 	}
-	// Matcher instances need the parsed [pre-]certificate.
+	atomic.AddInt64(&s.fullyParsed, 1)
 	logEntry, err := rawLogEntry.ToLogEntry()
 	if s.isCertErrorFatal(err, logEntry, info.index) {
-		return fmt.Errorf("failed to parse [pre-]certificate in MerkleTreeLeaf[%d]: %v", info.index, err)
+		return nil, nil, fmt.Errorf("failed to parse [pre-]certificate in MerkleTreeLeaf[%d]: %v", info.index, err)
+	}
+	return rawLogEntry, logEntry, nil
+}
+
+// recheckEntry applies the time filter to a fully parsed entry which it
+// could not check cheaply, and returns whether the entry passes.
+func (s *Scanner) recheckEntry(logEntry *ct.LogEntry) bool {
+	switch s.filter.checkParsed(logEntry) {
+	case filterRejectedTimestamp:
+		atomic.AddInt64(&s.timestampRejected, 1)
+		return false
+	case filterRejectedValidity:
+		atomic.AddInt64(&s.validityRejected, 1)
+		return false
+	}
+	atomic.AddInt64(&s.filterPassed, 1)
+	return true
+}
+
+func (s *Scanner) processMatcherEntry(matcher Matcher, info entryInfo, recheck bool, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
+	// Matcher instances need the parsed [pre-]certificate.
+	rawLogEntry, logEntry, err := s.parseEntry(info)
+	if err != nil {
+		return err
+	}
+	if recheck && !s.recheckEntry(logEntry) {
+		return nil
 	}
 
 	switch {
@@ -152,7 +221,16 @@ func (s *Scanner) processMatcherEntry(matcher Matcher, info entryInfo, foundCert
 	return nil
 }
 
-func (s *Scanner) processMatcherLeafEntry(matcher LeafMatcher, info entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
+func (s *Scanner) processMatcherLeafEntry(matcher LeafMatcher, info entryInfo, recheck bool, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) error {
+	if recheck {
+		_, logEntry, err := s.parseEntry(info)
+		if err != nil {
+			return err
+		}
+		if !s.recheckEntry(logEntry) {
+			return nil
+		}
+	}
 	if !matcher.Matches(&info.entry) {
 		return nil
 	}
@@ -182,9 +260,37 @@ func (s *Scanner) processMatcherLeafEntry(matcher LeafMatcher, info entryInfo, f
 // Returns true over the done channel when the entries channel is closed.
 func (s *Scanner) matcherJob(entries <-chan entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) {
 	for e := range entries {
-		if err := s.processEntry(e, foundCert, foundPrecert); err != nil {
-			atomic.AddInt64(&s.unparsableEntries, 1)
-			klog.Errorf("Failed to parse entry at index %d: %s", e.index, err.Error())
+		s.matchEntry(e, foundCert, foundPrecert)
+	}
+}
+
+// matchEntry processes a single entry, and marks it done.
+func (s *Scanner) matchEntry(e entryInfo, foundCert func(*ct.RawLogEntry), foundPrecert func(*ct.RawLogEntry)) {
+	if err := s.processEntry(e, foundCert, foundPrecert); err != nil {
+		atomic.AddInt64(&s.unparsableEntries, 1)
+		klog.Errorf("Failed to parse entry at index %d: %s", e.index, err.Error())
+	}
+	if e.done != nil {
+		e.done.Done()
+	}
+}
+
+// feed returns a Fetcher callback which passes the entries of each batch to
+// send, for matching.
+func (s *Scanner) feed(send func(entryInfo)) func(EntryBatch) {
+	return func(b EntryBatch) {
+		// With checkpointing, the Fetcher must only consider the batch done once
+		// all of its entries have gone through the matchers.
+		var done *sync.WaitGroup
+		if s.opts.Checkpoints != nil {
+			done = &sync.WaitGroup{}
+			done.Add(len(b.Entries))
+		}
+		for i, e := range b.Entries {
+			send(entryInfo{index: b.Start + int64(i), entry: e, done: done})
+		}
+		if done != nil {
+			done.Wait()
 		}
 	}
 }
@@ -264,6 +370,11 @@ func (s *Scanner) ScanLog(ctx context.Context, foundCert func(*ct.RawLogEntry),
 	s.precertsSeen = 0
 	s.unparsableEntries = 0
 	s.entriesWithNonFatalErrors = 0
+	s.timestampRejected = 0
+	s.validityRejected = 0
+	s.validityUnparsed = 0
+	s.filterPassed = 0
+	s.fullyParsed = 0
 
 	sth, err := s.fetcher.Prepare(ctx)
 	if err != nil {
@@ -291,12 +402,7 @@ func (s *Scanner) ScanLog(ctx context.Context, foundCert func(*ct.RawLogEntry),
 		}(w)
 	}
 
-	flatten := func(b EntryBatch) {
-		for i, e := range b.Entries {
-			entries <- entryInfo{index: b.Start + int64(i), entry: e}
-		}
-	}
-	err = s.fetcher.Run(ctx, flatten)
+	err = s.fetcher.Run(ctx, s.feed(func(e entryInfo) { entries <- e }))
 	close(entries) // Causes matcher workers to terminate.
 	wg.Wait()      // Wait until they terminate.
 	if err != nil {
@@ -307,16 +413,34 @@ func (s *Scanner) ScanLog(ctx context.Context, foundCert func(*ct.RawLogEntry),
 	klog.V(1).Infof("Saw %d precerts", atomic.LoadInt64(&s.precertsSeen))
 	klog.V(1).Infof("Saw %d unparsable entries", atomic.LoadInt64(&s.unparsableEntries))
 	klog.V(1).Infof("Saw %d non-fatal errors", atomic.LoadInt64(&s.entriesWithNonFatalErrors))
+	if s.filter != nil {
+		stats := s.FilterStats()
+		klog.V(1).Infof("Time windows rejected %d entries by timestamp and %d by validity, passed %d, checked %d after a full parse, fully parsed %d",
+			stats.TimestampRejected, stats.ValidityRejected, stats.Passed, stats.ValidityUnparsed, stats.FullyParsed)
+	}
 
 	return int64(s.fetcher.opts.EndIndex), nil
 }
 
+// FilterStats returns the counters of the time window filtering stages for
+// the current or last scan. It is safe to call concurrently with a scan.
+func (s *Scanner) FilterStats() FilterStats {
+	return FilterStats{
+		TimestampRejected: atomic.LoadInt64(&s.timestampRejected),
+		ValidityRejected:  atomic.LoadInt64(&s.validityRejected),
+		ValidityUnparsed:  atomic.LoadInt64(&s.validityUnparsed),
+		Passed:            atomic.LoadInt64(&s.filterPassed),
+		FullyParsed:       atomic.LoadInt64(&s.fullyParsed),
+	}
+}
+
 // NewScanner creates a Scanner instance using client to talk to the log,
 // taking configuration options from opts.
 func NewScanner(client LogClient, opts ScannerOptions) *Scanner {
 	var scanner Scanner
 	scanner.opts = opts
 	scanner.fetcher = NewFetcher(client, &scanner.opts.FetcherOptions)
+	scanner.filter = newTimeFilter(&scanner.opts)
 
 	// Set a default match-everything regex if none was provided.
 	if opts.Matcher == nil {
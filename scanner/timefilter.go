@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// TimeWindow is a half-open time range [Start, End). A zero Start or End
+// leaves the range unbounded on that side, so the zero TimeWindow contains
+// all times.
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsZero returns whether the window is unbounded on both sides.
+func (w TimeWindow) IsZero() bool {
+	return w.Start.IsZero() && w.End.IsZero()
+}
+
+// Contains returns whether t is within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	return (w.Start.IsZero() || !t.Before(w.Start)) && (w.End.IsZero() || t.Before(w.End))
+}
+
+// FilterStats holds the counters of the time window filtering stages of a
+// Scanner. The stages run from cheapest to most expensive, and each entry
+// stops at the first stage that rejects it.
+type FilterStats struct {
+	// TimestampRejected is the number of entries outside EntryTimestampWindow,
+	// found by parsing only the MerkleTreeLeaf.
+	TimestampRejected int64
+	// ValidityRejected is the number of entries outside NotBeforeWindow or
+	// NotAfterWindow, found by parsing only the validity of the TBSCertificate.
+	ValidityRejected int64
+	// ValidityUnparsed is the number of entries whose validity could not be
+	// parsed cheaply. These are checked against the windows once fully parsed,
+	// and counted in TimestampRejected, ValidityRejected or Passed after that.
+	ValidityUnparsed int64
+	// Passed is the number of entries passed on to matching.
+	Passed int64
+	// FullyParsed is the number of entries whose [pre-]certificate was fully
+	// parsed for matching.
+	FullyParsed int64
+}
+
+// filterResult is the outcome of applying a timeFilter to an entry.
+type filterResult int
+
+const (
+	filterPassed filterResult = iota
+	filterPassedUnparsed
+	filterRejectedTimestamp
+	filterRejectedValidity
+)
+
+// timeFilter applies the time windows of ScannerOptions to log entries.
+type timeFilter struct {
+	timestamp TimeWindow
+	notBefore TimeWindow
+	notAfter  TimeWindow
+}
+
+// newTimeFilter returns a filter for the windows in opts, or nil if they are
+// all unbounded.
+func newTimeFilter(opts *ScannerOptions) *timeFilter {
+	if opts.EntryTimestampWindow.IsZero() && opts.NotBeforeWindow.IsZero() && opts.NotAfterWindow.IsZero() {
+		return nil
+	}
+	return &timeFilter{
+		timestamp: opts.EntryTimestampWindow,
+		notBefore: opts.NotBeforeWindow,
+		notAfter:  opts.NotAfterWindow,
+	}
+}
+
+// check applies the filter to leaf. Entries that fail to parse are passed on,
+// so that they are reported by the later stages.
+func (f *timeFilter) check(leaf *ct.LeafEntry) filterResult {
+	var mtl ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(leaf.LeafInput, &mtl); err != nil || len(rest) > 0 {
+		return filterPassedUnparsed
+	}
+	if !f.timestamp.IsZero() && !f.timestamp.Contains(timestampToTime(mtl.TimestampedEntry.Timestamp)) {
+		return filterRejectedTimestamp
+	}
+	if f.notBefore.IsZero() && f.notAfter.IsZero() {
+		return filterPassed
+	}
+	notBefore, notAfter, err := leafValidity(&mtl)
+	if err != nil {
+		return filterPassedUnparsed
+	}
+	if !f.notBefore.Contains(notBefore) || !f.notAfter.Contains(notAfter) {
+		return filterRejectedValidity
+	}
+	return filterPassed
+}
+
+// checkParsed applies the filter to a fully parsed entry, for entries that
+// check could not parse.
+func (f *timeFilter) checkParsed(entry *ct.LogEntry) filterResult {
+	if !f.timestamp.Contains(timestampToTime(entry.Leaf.TimestampedEntry.Timestamp)) {
+		return filterRejectedTimestamp
+	}
+	cert := entry.X509Cert
+	if entry.Precert != nil {
+		cert = entry.Precert.TBSCertificate
+	}
+	if cert == nil {
+		return filterPassed
+	}
+	if !f.notBefore.Contains(cert.NotBefore) || !f.notAfter.Contains(cert.NotAfter) {
+		return filterRejectedValidity
+	}
+	return filterPassed
+}
+
+func timestampToTime(ts uint64) time.Time {
+	return time.UnixMilli(int64(ts))
+}
+
+// LeafValidity returns the NotBefore and NotAfter times of the
+// [pre-]certificate in leaf. It only parses as far as the validity field of
+// the TBSCertificate, so is much cheaper than a full parse, but it is also
+// stricter, and fails on some certificates which the x509 package accepts.
+func LeafValidity(leaf *ct.LeafEntry) (notBefore, notAfter time.Time, err error) {
+	var mtl ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(leaf.LeafInput, &mtl); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to unmarshal MerkleTreeLeaf: %v", err)
+	} else if len(rest) > 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("trailing data (%d bytes) after MerkleTreeLeaf", len(rest))
+	}
+	return leafValidity(&mtl)
+}
+
+func leafValidity(mtl *ct.MerkleTreeLeaf) (time.Time, time.Time, error) {
+	if mtl.TimestampedEntry == nil {
+		return time.Time{}, time.Time{}, errors.New("no TimestampedEntry")
+	}
+	var tbs cryptobyte.String
+	switch entry := mtl.TimestampedEntry; entry.EntryType {
+	case ct.X509LogEntryType:
+		if entry.X509Entry == nil {
+			return time.Time{}, time.Time{}, errors.New("no certificate")
+		}
+		var cert cryptobyte.String
+		input := cryptobyte.String(entry.X509Entry.Data)
+		if !input.ReadASN1(&cert, cryptobyte_asn1.SEQUENCE) || !cert.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+			return time.Time{}, time.Time{}, errors.New("malformed certificate")
+		}
+	case ct.PrecertLogEntryType:
+		if entry.PrecertEntry == nil {
+			return time.Time{}, time.Time{}, errors.New("no precertificate")
+		}
+		input := cryptobyte.String(entry.PrecertEntry.TBSCertificate)
+		if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+			return time.Time{}, time.Time{}, errors.New("malformed TBSCertificate")
+		}
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown entry type %v", entry.EntryType)
+	}
+
+	var validity cryptobyte.String
+	if !tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) || // version
+		!tbs.SkipASN1(cryptobyte_asn1.INTEGER) || // serialNumber
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // signature
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) || // issuer
+		!tbs.ReadASN1(&validity, cryptobyte_asn1.SEQUENCE) {
+		return time.Time{}, time.Time{}, errors.New("malformed TBSCertificate")
+	}
+	notBefore, ok := readASN1Time(&validity)
+	if !ok {
+		return time.Time{}, time.Time{}, errors.New("malformed notBefore")
+	}
+	notAfter, ok := readASN1Time(&validity)
+	if !ok {
+		return time.Time{}, time.Time{}, errors.New("malformed notAfter")
+	}
+	return notBefore, notAfter, nil
+}
+
+// readASN1Time reads a UTCTime or GeneralizedTime.
+func readASN1Time(s *cryptobyte.String) (time.Time, bool) {
+	var t time.Time
+	if s.PeekASN1Tag(cryptobyte_asn1.UTCTime) {
+		return t, s.ReadASN1UTCTime(&t)
+	}
+	return t, s.ReadASN1GeneralizedTime(&t)
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestLeafValidity(t *testing.T) {
+	for i, leaf := range testEntries(t) {
+		leaf := leaf
+		entry, err := ct.LogEntryFromLeaf(int64(i), &leaf)
+		if x509.IsFatal(err) {
+			t.Fatalf("LogEntryFromLeaf(%d): %v", i, err)
+		}
+		cert := entry.X509Cert
+		if cert == nil {
+			cert = entry.Precert.TBSCertificate
+		}
+		notBefore, notAfter, err := LeafValidity(&leaf)
+		if err != nil {
+			t.Fatalf("LeafValidity(%d): %v", i, err)
+		}
+		if !notBefore.Equal(cert.NotBefore) || !notAfter.Equal(cert.NotAfter) {
+			t.Errorf("LeafValidity(%d) = %v, %v; want %v, %v", i, notBefore, notAfter, cert.NotBefore, cert.NotAfter)
+		}
+	}
+
+	if _, _, err := LeafValidity(&ct.LeafEntry{LeafInput: []byte{0, 1}}); err == nil {
+		t.Error("LeafValidity(garbage) succeeded, want error")
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	for _, tc := range []struct {
+		w    TimeWindow
+		t    time.Time
+		want bool
+	}{
+		{w: TimeWindow{}, t: t1, want: true},
+		{w: TimeWindow{Start: t1}, t: t1, want: true},
+		{w: TimeWindow{Start: t2}, t: t1, want: false},
+		{w: TimeWindow{End: t2}, t: t1, want: true},
+		{w: TimeWindow{End: t2}, t: t2, want: false},
+		{w: TimeWindow{Start: t1, End: t2}, t: t1.Add(time.Minute), want: true},
+	} {
+		if got := tc.w.Contains(tc.t); got != tc.want {
+			t.Errorf("%+v.Contains(%v) = %v, want %v", tc.w, tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestScannerTimeWindows(t *testing.T) {
+	// Only entry 2 expires after 2013, and entry 0 has an earlier timestamp than
+	// the rest.
+	cutoff := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	tsCutoff := time.UnixMilli(1364258252899 + 1)
+	for _, tc := range []struct {
+		desc    string
+		matcher interface{}
+		opts    func(*ScannerOptions)
+		want    FilterStats
+		wantIdx []int64
+	}{
+		{
+			desc:    "unexpired",
+			matcher: &MatchAll{},
+			opts:    func(o *ScannerOptions) { o.NotAfterWindow.Start = cutoff },
+			want:    FilterStats{ValidityRejected: 3, Passed: 1, FullyParsed: 1},
+			wantIdx: []int64{2},
+		},
+		{
+			desc:    "timestamp",
+			matcher: &MatchAll{},
+			opts:    func(o *ScannerOptions) { o.EntryTimestampWindow.Start = tsCutoff },
+			want:    FilterStats{TimestampRejected: 1, Passed: 3, FullyParsed: 3},
+			wantIdx: []int64{1, 2, 3},
+		},
+		{
+			desc:    "timestamp-before-validity",
+			matcher: &MatchAll{},
+			opts: func(o *ScannerOptions) {
+				o.EntryTimestampWindow.End = tsCutoff
+				o.NotAfterWindow.Start = cutoff
+			},
+			want: FilterStats{TimestampRejected: 3, ValidityRejected: 1},
+		},
+		{
+			desc:    "leaf-matcher",
+			matcher: MatchSCTTimestamp{Timestamp: 1364288407155},
+			opts:    func(o *ScannerOptions) { o.NotAfterWindow.Start = cutoff },
+			want:    FilterStats{ValidityRejected: 3, Passed: 1},
+			wantIdx: []int64{2},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts := DefaultScannerOptions()
+			opts.Matcher = tc.matcher
+			tc.opts(opts)
+			s := NewScanner(newFakeLogClient(t), *opts)
+			if got := scanMatches(t, s); fmt.Sprint(got) != fmt.Sprint(tc.wantIdx) {
+				t.Fatalf("matched %v, want %v", got, tc.wantIdx)
+			}
+			if stats := s.FilterStats(); stats != tc.want {
+				t.Errorf("FilterStats() = %+v, want %+v", stats, tc.want)
+			}
+		})
+	}
+}
+
+func TestScannerTimeWindowsUnparsed(t *testing.T) {
+	// Entry 4 is entry 0 with fractional seconds in its validity, which
+	// LeafValidity can't read. It expires in 2013, so is out of the window.
+	cutoff := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		desc    string
+		matcher interface{}
+		want    FilterStats
+		wantIdx []int64
+	}{
+		{
+			desc:    "matcher",
+			matcher: &MatchAll{},
+			want:    FilterStats{ValidityRejected: 3, ValidityUnparsed: 1, Passed: 1, FullyParsed: 2},
+			wantIdx: []int64{2},
+		},
+		{
+			desc:    "leaf-matcher",
+			matcher: MatchSCTTimestamp{Timestamp: 1364258252899},
+			want:    FilterStats{ValidityRejected: 3, ValidityUnparsed: 1, Passed: 1, FullyParsed: 1},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cl := newFakeLogClient(t)
+			cl.entries = append(cl.entries, withValidity(t, cl.entries[0], "20130220133451.5Z", "20130607194327.5Z"))
+			root, err := compactRangeOf(t, cl.entries).GetRootHash(nil)
+			if err != nil {
+				t.Fatalf("GetRootHash(): %v", err)
+			}
+			cl.sth.TreeSize = uint64(len(cl.entries))
+			copy(cl.sth.SHA256RootHash[:], root)
+
+			opts := DefaultScannerOptions()
+			opts.Matcher = tc.matcher
+			opts.NotAfterWindow.Start = cutoff
+			s := NewScanner(cl, *opts)
+			if got := scanMatches(t, s); fmt.Sprint(got) != fmt.Sprint(tc.wantIdx) {
+				t.Fatalf("matched %v, want %v", got, tc.wantIdx)
+			}
+			if stats := s.FilterStats(); stats != tc.want {
+				t.Errorf("FilterStats() = %+v, want %+v", stats, tc.want)
+			}
+		})
+	}
+}
+
+func TestTimeFilterCheckParsed(t *testing.T) {
+	// Only entry 2 expires after 2013, and entry 0 has an earlier timestamp than
+	// the rest.
+	cutoff := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	tsCutoff := time.UnixMilli(1364258252899 + 1)
+	for _, tc := range []struct {
+		desc string
+		f    timeFilter
+		want []filterResult
+	}{
+		{
+			desc: "validity",
+			f:    timeFilter{notAfter: TimeWindow{Start: cutoff}},
+			want: []filterResult{filterRejectedValidity, filterRejectedValidity, filterPassed, filterRejectedValidity},
+		},
+		{
+			desc: "timestamp",
+			f:    timeFilter{timestamp: TimeWindow{Start: tsCutoff}},
+			want: []filterResult{filterRejectedTimestamp, filterPassed, filterPassed, filterPassed},
+		},
+		{
+			desc: "timestamp-before-validity",
+			f:    timeFilter{timestamp: TimeWindow{End: tsCutoff}, notAfter: TimeWindow{Start: cutoff}},
+			want: []filterResult{filterRejectedValidity, filterRejectedTimestamp, filterRejectedTimestamp, filterRejectedTimestamp},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			for i, leaf := range testEntries(t) {
+				leaf := leaf
+				entry, err := ct.LogEntryFromLeaf(int64(i), &leaf)
+				if x509.IsFatal(err) {
+					t.Fatalf("LogEntryFromLeaf(%d): %v", i, err)
+				}
+				if got := tc.f.checkParsed(entry); got != tc.want[i] {
+					t.Errorf("checkParsed(%d) = %v, want %v", i, got, tc.want[i])
+				}
+				if got := tc.f.check(&leaf); got != tc.want[i] {
+					t.Errorf("check(%d) = %v, want %v", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// scanMatches runs s, and returns the sorted indices of the matched entries.
+func scanMatches(t *testing.T, s *Scanner) []int64 {
+	t.Helper()
+	var mu sync.Mutex
+	var got []int64
+	found := func(e *ct.RawLogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e.Index)
+	}
+	if err := s.Scan(context.Background(), found, found); err != nil {
+		t.Fatalf("Scan(): %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	return got
+}
+
+// withValidity returns a copy of the X.509 entry leaf, with the validity of
+// its certificate replaced by the given GeneralizedTimes. The signature of
+// the certificate no longer matches.
+func withValidity(t *testing.T, leaf ct.LeafEntry, notBefore, notAfter string) ct.LeafEntry {
+	t.Helper()
+	var mtl ct.MerkleTreeLeaf
+	if _, err := tls.Unmarshal(leaf.LeafInput, &mtl); err != nil {
+		t.Fatalf("Unmarshal(): %v", err)
+	}
+
+	var cert, tbs, version, serial, sigAlg, issuer cryptobyte.String
+	input := cryptobyte.String(mtl.TimestampedEntry.X509Entry.Data)
+	if !input.ReadASN1(&cert, cryptobyte_asn1.SEQUENCE) ||
+		!cert.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.ReadASN1Element(&version, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) ||
+		!tbs.ReadASN1Element(&serial, cryptobyte_asn1.INTEGER) ||
+		!tbs.ReadASN1Element(&sigAlg, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.ReadASN1Element(&issuer, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) {
+		t.Fatal("Failed to parse certificate")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddBytes(version)
+			b.AddBytes(serial)
+			b.AddBytes(sigAlg)
+			b.AddBytes(issuer)
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				for _, ts := range []string{notBefore, notAfter} {
+					b.AddASN1(cryptobyte_asn1.GeneralizedTime, func(b *cryptobyte.Builder) {
+						b.AddBytes([]byte(ts))
+					})
+				}
+			})
+			b.AddBytes(tbs)
+		})
+		b.AddBytes(cert)
+	})
+	mtl.TimestampedEntry.X509Entry.Data = b.BytesOrPanic()
+	data, err := tls.Marshal(mtl)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	return ct.LeafEntry{LeafInput: data, ExtraData: leaf.ExtraData}
+}
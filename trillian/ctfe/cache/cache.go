@@ -0,0 +1,31 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache provides caches of the SCTs issued by the CTFE, which let it
+// answer duplicate submissions without contacting the Trillian backend.
+package cache
+
+import (
+	"context"
+)
+
+// SCTCache holds serialized SCTs, keyed by the identity of the submissions
+// they were issued for. Caches are bounded in size and time, so entries may
+// be dropped at any point.
+type SCTCache interface {
+	// Get returns the SCT stored under key, and whether there is one.
+	Get(ctx context.Context, key []byte) ([]byte, bool, error)
+	// Put stores sct under key.
+	Put(ctx context.Context, key []byte, sct []byte) error
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server that supports the commands used by RedisCache.
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	commands []string
+}
+
+// start serves on a new local port, and returns its address.
+func (f *fakeRedis) start(t *testing.T) string {
+	t.Helper()
+	f.data = make(map[string]string)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return lis.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		cmd, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range cmd.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var rsp string
+		switch {
+		case args[0] == "AUTH" && args[1] == f.password:
+			authed = true
+			rsp = "+OK\r\n"
+		case !authed:
+			rsp = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "GET":
+			if v, ok := f.data[args[1]]; ok {
+				rsp = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				rsp = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.data[args[1]] = args[2]
+			rsp = "+OK\r\n"
+		default:
+			rsp = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err := conn.Write([]byte(rsp)); err != nil {
+			return
+		}
+	}
+}
+
+func TestSCTCache(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func(t *testing.T) SCTCache
+	}{
+		{name: "lru", new: func(t *testing.T) SCTCache { return NewLRUCache(10, time.Hour) }},
+		{name: "redis", new: func(t *testing.T) SCTCache {
+			f := &fakeRedis{password: "secret"}
+			c := NewRedisCache(RedisOptions{Addr: f.start(t), Password: "secret", KeyPrefix: "sct:", TTL: time.Hour, Timeout: time.Second, PoolSize: 1})
+			t.Cleanup(func() { c.Close() })
+			return c
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := tc.new(t)
+			if _, ok, err := c.Get(ctx, []byte("key1")); err != nil || ok {
+				t.Fatalf("Get(missing)=_, %v, %v, want false, nil", ok, err)
+			}
+			if err := c.Put(ctx, []byte("key1"), []byte("sct\r\n1")); err != nil {
+				t.Fatalf("Put(key1): %v", err)
+			}
+			if err := c.Put(ctx, []byte("key2"), []byte("sct2")); err != nil {
+				t.Fatalf("Put(key2): %v", err)
+			}
+			for key, want := range map[string]string{"key1": "sct\r\n1", "key2": "sct2"} {
+				got, ok, err := c.Get(ctx, []byte(key))
+				if err != nil || !ok {
+					t.Fatalf("Get(%s)=_, %v, %v, want true, nil", key, ok, err)
+				}
+				if string(got) != want {
+					t.Errorf("Get(%s)=%q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	c := NewLRUCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+	has := func(key string) bool {
+		t.Helper()
+		_, ok, err := c.Get(ctx, []byte(key))
+		if err != nil {
+			t.Fatalf("Get(%s): %v", key, err)
+		}
+		return ok
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(ctx, []byte(key), []byte(key)); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+	}
+	// Using a makes b the least recently used, so c evicts it.
+	if !has("a") {
+		t.Error("a missing")
+	}
+	if err := c.Put(ctx, []byte("c"), []byte("c")); err != nil {
+		t.Fatalf("Put(c): %v", err)
+	}
+	if has("b") {
+		t.Error("b not evicted")
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := c.Put(ctx, []byte("c"), []byte("c")); err != nil {
+		t.Fatalf("Put(c) again: %v", err)
+	}
+	// a expires a minute after it was stored, and c a minute after it was
+	// stored again.
+	now = now.Add(30 * time.Second)
+	if has("a") {
+		t.Error("a not expired")
+	}
+	if !has("c") {
+		t.Error("c expired early")
+	}
+	now = now.Add(30 * time.Second)
+	if has("c") {
+		t.Error("c not expired")
+	}
+}
+
+func TestRedisCacheCommands(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{}
+	c := NewRedisCache(RedisOptions{Addr: f.start(t), KeyPrefix: "p:", TTL: 90 * time.Second, PoolSize: 1})
+	defer c.Close()
+	if err := c.Put(ctx, []byte{0xab}, []byte("sct")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, _, err := c.Get(ctx, []byte{0xab}); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	want := []string{"SET p:ab sct PX 90000", "GET p:ab"}
+	f.mu.Lock()
+	got := f.commands
+	f.mu.Unlock()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got commands %q, want %q", got, want)
+	}
+
+	// Error replies are returned as errors.
+	bad := NewRedisCache(RedisOptions{Addr: c.opts.Addr, Password: "wrong"})
+	if _, _, err := bad.Get(ctx, []byte{1}); err == nil {
+		t.Error("Get with wrong password succeeded")
+	}
+}
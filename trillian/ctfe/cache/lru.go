@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRUCache is an SCTCache in memory, which holds the most recently used SCTs
+// for up to a given time.
+type LRUCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	order *list.List               // Of *lruEntry, most recently used first.
+	items map[string]*list.Element // Keyed by the SCT's key.
+}
+
+type lruEntry struct {
+	key     string
+	sct     []byte
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache that holds up to size SCTs, each for up to
+// ttl after it was stored. A zero ttl keeps SCTs until they are evicted.
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the SCT stored under key, unless it was evicted or expired, and
+// marks it as most recently used.
+func (c *LRUCache) Get(_ context.Context, key []byte) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[string(key)]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := e.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.items, entry.key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(e)
+	return entry.sct, true, nil
+}
+
+// Put stores sct under key, evicting the least recently used SCT if the cache
+// is full.
+func (c *LRUCache) Put(_ context.Context, key []byte, sct []byte) error {
+	if c.size <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: string(key), sct: sct, expires: c.now().Add(c.ttl)}
+	if e, ok := c.items[entry.key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.items[entry.key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
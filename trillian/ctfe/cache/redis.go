@@ -0,0 +1,239 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisOptions describes the Redis server used by a RedisCache.
+type RedisOptions struct {
+	// Addr is the host:port of the server.
+	Addr string
+	// Password, if set, is sent with the AUTH command on each connection.
+	Password string
+	// KeyPrefix is prepended to the keys of SCTs, so that a server can be
+	// shared with other uses.
+	KeyPrefix string
+	// TTL is how long SCTs are kept for; zero keeps them until the server
+	// evicts them, according to its maxmemory-policy.
+	TTL time.Duration
+	// Timeout bounds each command, if the context has no earlier deadline.
+	// Zero means no timeout.
+	Timeout time.Duration
+	// PoolSize is the number of idle connections kept open.
+	PoolSize int
+}
+
+// RedisCache is an SCTCache in a Redis server, which can be shared by several
+// CTFE instances. The size of the cache is bounded by the server's maxmemory
+// setting.
+type RedisCache struct {
+	opts RedisOptions
+	pool chan *redisConn
+}
+
+// NewRedisCache returns a RedisCache using the server described by opts.
+// Connections are made when needed.
+func NewRedisCache(opts RedisOptions) *RedisCache {
+	return &RedisCache{opts: opts, pool: make(chan *redisConn, opts.PoolSize)}
+}
+
+// Get returns the SCT stored under key, and whether there is one.
+func (c *RedisCache) Get(ctx context.Context, key []byte) ([]byte, bool, error) {
+	rsp, err := c.do(ctx, "GET", c.key(key))
+	if err != nil {
+		return nil, false, err
+	}
+	if rsp == nil {
+		return nil, false, nil
+	}
+	sct, ok := rsp.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %v", rsp)
+	}
+	return sct, true, nil
+}
+
+// Put stores sct under key.
+func (c *RedisCache) Put(ctx context.Context, key []byte, sct []byte) error {
+	args := []string{"SET", c.key(key), string(sct)}
+	if c.opts.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(c.opts.TTL.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Close closes the idle connections.
+func (c *RedisCache) Close() error {
+	for {
+		select {
+		case conn := <-c.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisCache) key(key []byte) string {
+	return c.opts.KeyPrefix + hex.EncodeToString(key)
+}
+
+// do runs a command, and returns its reply.
+func (c *RedisCache) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := conn.do(ctx, c.opts.Timeout, args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		// The connection may be in an unknown state, so don't reuse it.
+		conn.Close()
+		return nil, err
+	}
+	select {
+	case c.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return rsp, err
+}
+
+// conn returns an idle connection, or a new one.
+func (c *RedisCache) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+	var d net.Dialer
+	if c.opts.Timeout > 0 {
+		d.Timeout = c.opts.Timeout
+	}
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to connect to %s: %v", c.opts.Addr, err)
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.opts.Password != "" {
+		if _, err := conn.do(ctx, c.opts.Timeout, "AUTH", c.opts.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: failed to authenticate: %v", err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is a connection speaking the Redis serialization protocol (RESP).
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do sends a command, and returns its reply: nil, a string for status
+// replies, an int64, a []byte, or a []interface{} of those.
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if timeout > 0 && (!ok || time.Now().Add(timeout).Before(deadline)) {
+		deadline, ok = time.Now().Add(timeout), true
+	}
+	if ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	} else if err := c.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	if _, err := c.Write(encodeCommand(args)); err != nil {
+		return nil, fmt.Errorf("redis: failed to send command: %v", err)
+	}
+	return readReply(c.r)
+}
+
+// encodeCommand encodes a command as an array of bulk strings.
+func encodeCommand(args []string) []byte {
+	b := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b = append(b, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		b = append(b, arg...)
+		b = append(b, "\r\n"...)
+	}
+	return b
+}
+
+// readReply reads a reply, or a command, in RESP.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to read reply: %v", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk string length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("redis: failed to read bulk string: %v", err)
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -51,11 +51,14 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 	// First make sure the certs parse as X.509
 	chain := make([]*x509.Certificate, 0, len(rawChain))
 	intermediatePool := x509util.NewPEMCertPool()
+	var parseErrs []error
 
 	for i, certBytes := range rawChain {
 		cert, err := x509.ParseCertificate(certBytes)
 		if x509.IsFatal(err) {
 			return nil, err
+		} else if err != nil {
+			parseErrs = append(parseErrs, fmt.Errorf("certificate %d: %v", i, err))
 		}
 
 		chain = append(chain, cert)
@@ -168,6 +171,9 @@ func ValidateChain(rawChain [][]byte, validationOpts CertValidationOpts) ([]*x50
 	// requirements detailed in Section 3.1.
 	for _, verifiedChain := range verifiedChains {
 		if chainsEquivalent(chain, verifiedChain) {
+			if err := validationOpts.lint.run(&LintSubmission{Chain: verifiedChain, ParseErrors: parseErrs}); err != nil {
+				return nil, err
+			}
 			return verifiedChain, nil
 		}
 	}
@@ -39,6 +39,7 @@ type ValidatedLogConfig struct {
 	NotAfterStart *time.Time
 	NotAfterLimit *time.Time
 	FrozenSTH     *ct.SignedTreeHead
+	LintRules     []LintRule
 }
 
 // LogConfigFromFile creates a slice of LogConfig options from the given
@@ -106,6 +107,8 @@ func MultiLogConfigFromFile(filename string) (*configpb.LogMultiConfig, error) {
 //     proto. If both are set then NotBeforeStart <= NotBeforeLimit.
 //   - Merge delays (if present) are correct.
 //   - Frozen STH (if present) is correct and signed by the provided public key.
+//   - A log storing issuance chains in CTFE storage is not a mirror, and has a
+//     storage connection string.
 //
 // Returns the validated structures (useful to avoid double validation).
 func ValidateLogConfig(cfg *configpb.LogConfig) (*ValidatedLogConfig, error) {
@@ -192,6 +195,30 @@ func ValidateLogConfig(cfg *configpb.LogConfig) (*ValidatedLogConfig, error) {
 		return nil, errors.New("expected merge delay exceeds MMD")
 	}
 
+	switch cfg.ExtraDataIssuanceChainStorageBackend {
+	case configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_TRILLIAN_GRPC:
+	case configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE:
+		if cfg.IsMirror {
+			return nil, errors.New("issuance chain storage in CTFE for mirror")
+		}
+		if len(cfg.CtfeStorageConnectionString) == 0 {
+			return nil, errors.New("empty CTFE storage connection string")
+		}
+	default:
+		return nil, fmt.Errorf("unknown issuance chain storage backend: %v", cfg.ExtraDataIssuanceChainStorageBackend)
+	}
+
+	if cfg.LintPolicy != nil {
+		if cfg.IsMirror {
+			return nil, errors.New("lint policy for mirror")
+		}
+		rules, err := LintRulesFromPolicy(cfg.LintPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lint policy: %v", err)
+		}
+		vCfg.LintRules = rules
+	}
+
 	if sth := cfg.FrozenSth; sth != nil {
 		verifier, err := ct.NewSignatureVerifier(vCfg.PubKey)
 		if err != nil {
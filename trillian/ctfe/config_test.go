@@ -259,6 +259,35 @@ func TestValidateLogConfig(t *testing.T) {
 				FrozenSth:  corruptedSTH,
 			},
 		},
+		{
+			desc:    "issuance-chain-storage-mirror",
+			wantErr: "issuance chain storage in CTFE for mirror",
+			cfg: &configpb.LogConfig{
+				LogId:                                123,
+				PublicKey:                            pubKey,
+				IsMirror:                             true,
+				ExtraDataIssuanceChainStorageBackend: configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE,
+				CtfeStorageConnectionString:          "memory://",
+			},
+		},
+		{
+			desc:    "empty-ctfe-storage-connection-string",
+			wantErr: "empty CTFE storage connection string",
+			cfg: &configpb.LogConfig{
+				LogId:                                123,
+				PrivateKey:                           privKey,
+				ExtraDataIssuanceChainStorageBackend: configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE,
+			},
+		},
+		{
+			desc:    "unknown-issuance-chain-storage-backend",
+			wantErr: "unknown issuance chain storage backend",
+			cfg: &configpb.LogConfig{
+				LogId:                                123,
+				PrivateKey:                           privKey,
+				ExtraDataIssuanceChainStorageBackend: 42,
+			},
+		},
 		{
 			desc: "ok",
 			cfg: &configpb.LogConfig{
@@ -266,6 +295,52 @@ func TestValidateLogConfig(t *testing.T) {
 				PrivateKey: privKey,
 			},
 		},
+		{
+			desc: "ok-issuance-chain-storage",
+			cfg: &configpb.LogConfig{
+				LogId:                                123,
+				PrivateKey:                           privKey,
+				ExtraDataIssuanceChainStorageBackend: configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE,
+				CtfeStorageConnectionString:          "memory://",
+			},
+		},
+		{
+			desc:    "lint-policy-mirror",
+			wantErr: "lint policy for mirror",
+			cfg: &configpb.LogConfig{
+				LogId:      123,
+				PublicKey:  pubKey,
+				IsMirror:   true,
+				LintPolicy: &configpb.LintPolicy{},
+			},
+		},
+		{
+			desc:    "invalid-lint-policy",
+			wantErr: "invalid lint policy",
+			cfg: &configpb.LogConfig{
+				LogId:      123,
+				PrivateKey: privKey,
+				LintPolicy: &configpb.LintPolicy{
+					SignatureAlgorithms: &configpb.LintPolicy_SignatureAlgorithms{
+						Action:  configpb.LintAction_LINT_ACTION_REJECT,
+						Allowed: []string{"MD5"},
+					},
+				},
+			},
+		},
+		{
+			desc: "ok-lint-policy",
+			cfg: &configpb.LogConfig{
+				LogId:      123,
+				PrivateKey: privKey,
+				LintPolicy: &configpb.LintPolicy{
+					KeySize: &configpb.LintPolicy_KeySize{
+						Action:     configpb.LintAction_LINT_ACTION_WARN,
+						MinRsaBits: 2048,
+					},
+				},
+			},
+		},
 		{
 			// Note: Substituting an arbitrary proto.Message as a PrivateKey will not
 			// fail the validation because the actual key loading happens at runtime.
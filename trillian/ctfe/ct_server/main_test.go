@@ -0,0 +1,62 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadAdminTokens(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		data    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			desc: "ok",
+			data: "# Admins.\nalice s3cret\n\n  bob   t0ken  \n",
+			want: map[string]string{"s3cret": "alice", "t0ken": "bob"},
+		},
+		{desc: "empty", data: "# None.\n", wantErr: "no tokens"},
+		{desc: "no-token", data: "alice\n", wantErr: "line 1"},
+		{desc: "duplicate", data: "alice s3cret\nbob s3cret\n", wantErr: "line 2: duplicate token"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens")
+			if err := os.WriteFile(path, []byte(tc.data), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			got, err := readAdminTokens(path)
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("readAdminTokens()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readAdminTokens(): %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("readAdminTokens() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/configpb"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog/v2"
+)
+
+// runningLog is a log instance served by a logServer.
+type runningLog struct {
+	cfg         *configpb.LogConfig
+	backendSpec string
+	rootsHash   [sha256.Size]byte
+	inst        *ctfe.Instance
+	// stop stops the instance's internal get-sth operations.
+	stop context.CancelFunc
+	// active is the number of requests being handled by the instance.
+	active atomic.Int64
+}
+
+// track returns a handler that counts the requests being handled by h.
+func (l *runningLog) track(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.active.Add(1)
+		defer l.active.Add(-1)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// drain waits until the log handles no requests, or the timeout expires. It
+// returns whether the log was drained.
+func (l *runningLog) drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for l.active.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
+// reloadStats counts the logs affected by a config change.
+type reloadStats struct {
+	added, updated, removed, unchanged int
+}
+
+// logServer serves the handlers of the configured logs. Its config can be
+// replaced while serving: the instances of new and changed logs are set up
+// first, then all handlers are swapped in at once, and the instances no longer
+// configured are drained of in-flight requests before being stopped.
+type logServer struct {
+	ctx          context.Context
+	dialOpts     []grpc.DialOption
+	drainTimeout time.Duration
+
+	mux atomic.Pointer[http.ServeMux]
+
+	mu     sync.Mutex                              // Serializes config changes, and guards the fields below.
+	conns  map[string]*grpc.ClientConn             // Keyed by backend spec.
+	chains map[string]storage.IssuanceChainStorage // Keyed by CTFE storage connection string.
+	logs   map[string]*runningLog                  // Keyed by log prefix.
+}
+
+func newLogServer(ctx context.Context, dialOpts []grpc.DialOption, drainTimeout time.Duration) *logServer {
+	s := &logServer{
+		ctx:          ctx,
+		dialOpts:     dialOpts,
+		drainTimeout: drainTimeout,
+	}
+	s.mux.Store(http.NewServeMux())
+	return s
+}
+
+// ServeHTTP dispatches the request to the handlers of the current config.
+func (s *logServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.Load().ServeHTTP(w, r)
+}
+
+// apply validates cfg and starts serving it. Logs whose config, backend and
+// roots are unchanged keep their instance; the others get a new one. If cfg
+// can't be served, the current config is left in place.
+func (s *logServer) apply(cfg *configpb.LogMultiConfig) (reloadStats, error) {
+	var stats reloadStats
+	beMap, err := ctfe.ValidateLogMultiConfig(cfg)
+	if err != nil {
+		return stats, fmt.Errorf("invalid config: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Dial new backends, and keep the connections to existing ones.
+	conns := make(map[string]*grpc.ClientConn)
+	var opened []io.Closer
+	var created []*runningLog
+	fail := func(err error) (reloadStats, error) {
+		for _, l := range created {
+			l.stop()
+		}
+		closeAll(opened)
+		return reloadStats{}, err
+	}
+	for _, be := range beMap {
+		if conn, ok := s.conns[be.BackendSpec]; ok {
+			conns[be.BackendSpec] = conn
+			continue
+		}
+		klog.Infof("Dialling backend: %v", be)
+		dialOpts := s.dialOpts
+		if s.logs == nil && len(beMap) == 1 {
+			// If there's only one backend at startup we use the blocking option
+			// as we can't serve anything until connected.
+			dialOpts = append(append([]grpc.DialOption(nil), dialOpts...), grpc.WithBlock())
+		}
+		conn, err := grpc.Dial(be.BackendSpec, dialOpts...)
+		if err != nil {
+			return fail(fmt.Errorf("could not dial RPC server: %v: %v", be, err))
+		}
+		conns[be.BackendSpec] = conn
+		opened = append(opened, conn)
+	}
+
+	// Open new issuance chain storages, and keep the existing ones, so that
+	// the logs sharing a storage use a single connection pool, and in-memory
+	// storages keep their chains across reloads.
+	chains := make(map[string]storage.IssuanceChainStorage)
+	for _, c := range cfg.LogConfigs.GetConfig() {
+		conn := c.CtfeStorageConnectionString
+		if c.ExtraDataIssuanceChainStorageBackend != configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE || chains[conn] != nil {
+			continue
+		}
+		if st, ok := s.chains[conn]; ok {
+			chains[conn] = st
+			continue
+		}
+		st, err := storage.OpenIssuanceChainStorage(s.ctx, conn)
+		if err != nil {
+			return fail(fmt.Errorf("failed to open issuance chain storage of log %q: %v", c.Prefix, err))
+		}
+		chains[conn] = st
+		if closer, ok := st.(io.Closer); ok {
+			opened = append(opened, closer)
+		}
+	}
+
+	// Set up instances for new and changed logs.
+	logs := make(map[string]*runningLog)
+	order := make([]*runningLog, 0, len(cfg.LogConfigs.GetConfig()))
+	for _, c := range cfg.LogConfigs.GetConfig() {
+		spec := beMap[c.LogBackendName].BackendSpec
+		rootsHash, err := hashFiles(c.RootsPemFile)
+		if err != nil {
+			return fail(fmt.Errorf("failed to read roots of log %q: %v", c.Prefix, err))
+		}
+		old, ok := s.logs[c.Prefix]
+		if ok && old.backendSpec == spec && old.rootsHash == rootsHash && proto.Equal(old.cfg, c) {
+			logs[c.Prefix] = old
+			order = append(order, old)
+			stats.unchanged++
+			continue
+		}
+		inst, err := setupInstance(s.ctx, trillian.NewTrillianLogClient(conns[spec]), chains[c.CtfeStorageConnectionString], *rpcDeadline, c, *maskInternalErrors)
+		if err != nil {
+			return fail(fmt.Errorf("failed to set up log instance for %q: %v", c.Prefix, err))
+		}
+		ctx, stop := context.WithCancel(s.ctx)
+		l := &runningLog{cfg: c, backendSpec: spec, rootsHash: rootsHash, inst: inst, stop: stop}
+		if *getSTHInterval > 0 {
+			go inst.RunUpdateSTH(ctx, *getSTHInterval)
+		}
+		created = append(created, l)
+		logs[c.Prefix] = l
+		order = append(order, l)
+		if ok {
+			stats.updated++
+		} else {
+			stats.added++
+		}
+	}
+	if err := checkDistinctKeys(order); err != nil {
+		return fail(err)
+	}
+	mux, err := buildMux(order)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Swap in the new handlers, then retire the instances, connections and
+	// storages that are no longer used.
+	s.mux.Store(mux)
+	var retired []*runningLog
+	for prefix, l := range s.logs {
+		if logs[prefix] != l {
+			retired = append(retired, l)
+		}
+		if _, ok := logs[prefix]; !ok {
+			stats.removed++
+		}
+	}
+	var closing []io.Closer
+	for spec, conn := range s.conns {
+		if _, ok := conns[spec]; !ok {
+			closing = append(closing, conn)
+		}
+	}
+	for conn, st := range s.chains {
+		if closer, ok := st.(io.Closer); ok && chains[conn] == nil {
+			closing = append(closing, closer)
+		}
+	}
+	s.conns, s.chains, s.logs = conns, chains, logs
+	if len(retired) > 0 || len(closing) > 0 {
+		go s.retire(retired, closing)
+	}
+	return stats, nil
+}
+
+// retire drains and stops the given logs, then closes the given backend
+// connections and storages.
+func (s *logServer) retire(logs []*runningLog, closing []io.Closer) {
+	var wg sync.WaitGroup
+	for _, l := range logs {
+		wg.Add(1)
+		go func(l *runningLog) {
+			defer wg.Done()
+			if !l.drain(s.drainTimeout) {
+				klog.Warningf("Log %q still has %d requests in flight after %v, stopping it anyway", l.cfg.Prefix, l.active.Load(), s.drainTimeout)
+			}
+			l.stop()
+			klog.Infof("Stopped the previous instance of log %q", l.cfg.Prefix)
+		}(l)
+	}
+	wg.Wait()
+	closeAll(closing)
+}
+
+// closeAll closes the given backend connections and storages.
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			klog.Warningf("Failed to close %T: %v", c, err)
+		}
+	}
+}
+
+// instance returns the instance of the log with the given prefix, or nil if
+// there is none.
+func (s *logServer) instance(prefix string) *ctfe.Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.logs[prefix]; ok {
+		return l.inst
+	}
+	return nil
+}
+
+// refreshRoots rebuilds the roots of all logs from the root store.
+func (s *logServer) refreshRoots(ctx context.Context) {
+	s.mu.Lock()
+	logs := make([]*runningLog, 0, len(s.logs))
+	for _, l := range s.logs {
+		logs = append(logs, l)
+	}
+	s.mu.Unlock()
+	for _, l := range logs {
+		if err := l.inst.RefreshRoots(ctx); err != nil {
+			klog.Warningf("%s: failed to refresh roots: %v", l.cfg.Prefix, err)
+		}
+	}
+}
+
+// close stops all logs and closes all backend connections and storages.
+func (s *logServer) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range s.logs {
+		l.stop()
+	}
+	var closing []io.Closer
+	for _, conn := range s.conns {
+		closing = append(closing, conn)
+	}
+	for _, st := range s.chains {
+		if closer, ok := st.(io.Closer); ok {
+			closing = append(closing, closer)
+		}
+	}
+	closeAll(closing)
+}
+
+// checkDistinctKeys ensures that no two logs share the same private key.
+func checkDistinctKeys(logs []*runningLog) error {
+	var publicKeys []crypto.PublicKey
+	for _, l := range logs {
+		publicKey := l.inst.GetPublicKey()
+		if publicKey == nil {
+			continue
+		}
+		for _, p := range publicKeys {
+			if pub, ok := publicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && pub.Equal(p) {
+				return errors.New("same private key used by more than one log")
+			}
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return nil
+}
+
+// buildMux returns a ServeMux with the handlers of the given logs, and the
+// server-wide handlers.
+func buildMux(logs []*runningLog) (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	patterns := make(map[string]bool)
+	for _, l := range logs {
+		prefix := logHandlerPrefix(l.cfg, *handlerPrefix)
+		for path, handler := range l.inst.Handlers {
+			pattern := prefix + path
+			// ServeMux panics on duplicate patterns, so check for them first.
+			if patterns[pattern] {
+				return nil, fmt.Errorf("log %q: duplicate handler path %q", l.cfg.Prefix, pattern)
+			}
+			patterns[pattern] = true
+			mux.Handle(pattern, l.track(handler))
+		}
+	}
+
+	// Return a 200 on the root, for GCE default health checking :/
+	mux.HandleFunc("/", func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/" {
+			resp.WriteHeader(http.StatusOK)
+		} else {
+			resp.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// Export a healthz target.
+	mux.HandleFunc("/healthz", func(resp http.ResponseWriter, req *http.Request) {
+		// TODO(al): Wire this up to tell the truth.
+		if _, err := resp.Write([]byte("ok")); err != nil {
+			klog.Errorf("resp.Write(): %v", err)
+		}
+	})
+	return mux, nil
+}
+
+// hashFiles returns a hash of the contents of the given files.
+func hashFiles(paths []string) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// configReloader reloads the config of a logServer on SIGHUP, and when the
+// config file or the roots files it refers to change.
+type configReloader struct {
+	s    *logServer
+	read func() (*configpb.LogMultiConfig, error)
+	// paths are the config file and the roots files of the last config read,
+	// and stamp is the hash of their contents at the time.
+	paths []string
+	stamp [sha256.Size]byte
+
+	reloads          monitoring.Counter
+	logChanges       monitoring.Counter
+	lastReloadOK     monitoring.Gauge
+	lastReloadTime   monitoring.Gauge
+	lastSuccessfulAt monitoring.Gauge
+}
+
+func newConfigReloader(s *logServer, read func() (*configpb.LogMultiConfig, error), mf monitoring.MetricFactory) *configReloader {
+	return &configReloader{
+		s:                s,
+		read:             read,
+		reloads:          mf.NewCounter("config_reloads", "Number of config reloads, by result", "result"),
+		logChanges:       mf.NewCounter("config_reload_log_changes", "Number of logs changed by config reloads, by change", "change"),
+		lastReloadOK:     mf.NewGauge("config_last_reload_successful", "Whether the last config reload succeeded"),
+		lastReloadTime:   mf.NewGauge("config_last_reload_timestamp_seconds", "Time of the last config reload"),
+		lastSuccessfulAt: mf.NewGauge("config_last_reload_success_timestamp_seconds", "Time of the last successful config reload"),
+	}
+}
+
+// reload reads the config and applies it.
+func (c *configReloader) reload() error {
+	cfg, err := c.read()
+	if err != nil {
+		// Keep watching the files of the current config, but don't retry
+		// until they change again.
+		c.stamp = c.currentStamp()
+		c.record(err)
+		return err
+	}
+	return c.load(cfg)
+}
+
+// load applies cfg.
+func (c *configReloader) load(cfg *configpb.LogMultiConfig) error {
+	c.watchFiles(cfg)
+	stats, err := c.s.apply(cfg)
+	if err == nil {
+		c.logChanges.Add(float64(stats.added), "added")
+		c.logChanges.Add(float64(stats.updated), "updated")
+		c.logChanges.Add(float64(stats.removed), "removed")
+		klog.Infof("Config loaded: %d logs added, %d updated, %d removed, %d unchanged", stats.added, stats.updated, stats.removed, stats.unchanged)
+	}
+	c.record(err)
+	return err
+}
+
+// record updates the metrics with the outcome of a reload.
+func (c *configReloader) record(err error) {
+	now := float64(time.Now().Unix())
+	c.lastReloadTime.Set(now)
+	if err != nil {
+		c.reloads.Inc("failure")
+		c.lastReloadOK.Set(0)
+		return
+	}
+	c.reloads.Inc("success")
+	c.lastReloadOK.Set(1)
+	c.lastSuccessfulAt.Set(now)
+}
+
+// watchFiles sets the files to watch for changes to those of cfg.
+func (c *configReloader) watchFiles(cfg *configpb.LogMultiConfig) {
+	c.paths = []string{*logConfig}
+	for _, lc := range cfg.LogConfigs.GetConfig() {
+		c.paths = append(c.paths, lc.RootsPemFile...)
+	}
+	c.stamp = c.currentStamp()
+}
+
+// currentStamp returns a hash of the contents of the watched files, or zero
+// if any of them can't be read.
+func (c *configReloader) currentStamp() [sha256.Size]byte {
+	sum, err := hashFiles(c.paths)
+	if err != nil {
+		return [sha256.Size]byte{}
+	}
+	return sum
+}
+
+// run reloads the config on SIGHUP, and when the watched files change if
+// interval is positive, until ctx is done.
+func (c *configReloader) run(ctx context.Context, interval time.Duration) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+			klog.Info("SIGHUP received, reloading config")
+		case <-tick:
+			if c.currentStamp() == c.stamp {
+				continue
+			}
+			klog.Info("Config files changed, reloading config")
+		}
+		if err := c.reload(); err != nil {
+			klog.Errorf("Failed to reload config, keeping the current one: %v", err)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe/configpb"
+	"github.com/google/trillian/crypto/keys"
+	keyspem "github.com/google/trillian/crypto/keys/pem"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func init() {
+	keys.RegisterHandler(&keyspb.PEMKeyFile{}, keyspem.FromProto)
+}
+
+// startBackend starts a gRPC server for a logServer to connect to, and returns
+// its address.
+func startBackend(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// copyFile copies src to a new file in dir, and returns its path.
+func copyFile(t *testing.T, src, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", src, err)
+	}
+	dst := filepath.Join(dir, filepath.Base(src))
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", dst, err)
+	}
+	return dst
+}
+
+func multiConfig(backend string, logs ...*configpb.LogConfig) *configpb.LogMultiConfig {
+	return &configpb.LogMultiConfig{
+		Backends: &configpb.LogBackendSet{
+			Backend: []*configpb.LogBackend{{Name: "be", BackendSpec: backend}},
+		},
+		LogConfigs: &configpb.LogConfigSet{Config: logs},
+	}
+}
+
+// countRoots returns the number of roots served by the log at prefix, or -1
+// if it is not served.
+func countRoots(t *testing.T, s *logServer, prefix string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+prefix+"/ct/v1/get-roots", nil))
+	if rec.Code == http.StatusNotFound {
+		return -1
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get-roots for %q returned %d", prefix, rec.Code)
+	}
+	var rsp struct{ Certificates [][]byte }
+	if err := json.Unmarshal(rec.Body.Bytes(), &rsp); err != nil {
+		t.Fatalf("Failed to parse get-roots response: %v", err)
+	}
+	return len(rsp.Certificates)
+}
+
+func TestLogServerApply(t *testing.T) {
+	*getSTHInterval = 0
+	dir := t.TempDir()
+	roots := copyFile(t, "../../testdata/fake-ca.cert", dir)
+	privKey, err := anypb.New(&keyspb.PEMKeyFile{Path: "../../testdata/ct-http-server.privkey.pem", Password: "dirk"})
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+	pubPEM, err := os.ReadFile("../../testdata/ct-http-server.pubkey.pem")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	block, _ := pem.Decode(pubPEM)
+
+	logA := &configpb.LogConfig{LogId: 1, Prefix: "a", RootsPemFile: []string{roots}, PrivateKey: privKey, LogBackendName: "be"}
+	logB := &configpb.LogConfig{LogId: 2, Prefix: "b", IsMirror: true, PublicKey: &keyspb.PublicKey{Der: block.Bytes}, LogBackendName: "be"}
+	backend := startBackend(t)
+
+	s := newLogServer(context.Background(), []grpc.DialOption{grpc.WithInsecure()}, time.Second)
+	defer s.close()
+
+	stats, err := s.apply(multiConfig(backend, logA))
+	if err != nil {
+		t.Fatalf("apply(a): %v", err)
+	}
+	if want := (reloadStats{added: 1}); stats != want {
+		t.Errorf("apply(a)=%+v, want %+v", stats, want)
+	}
+	if got := countRoots(t, s, "a"); got != 1 {
+		t.Errorf("Log a serves %d roots, want 1", got)
+	}
+	instA := s.logs["a"].inst
+
+	stats, err = s.apply(multiConfig(backend, logA, logB))
+	if err != nil {
+		t.Fatalf("apply(a, b): %v", err)
+	}
+	if want := (reloadStats{added: 1, unchanged: 1}); stats != want {
+		t.Errorf("apply(a, b)=%+v, want %+v", stats, want)
+	}
+	if s.logs["a"].inst != instA {
+		t.Error("apply(a, b) replaced the instance of unchanged log a")
+	}
+
+	// Adding a root to the roots file updates the log.
+	caCert, err := os.ReadFile("../../testdata/int-ca.cert")
+	if err != nil {
+		t.Fatalf("Failed to read root: %v", err)
+	}
+	f, err := os.OpenFile(roots, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open roots: %v", err)
+	}
+	if _, err := f.Write(caCert); err != nil {
+		t.Fatalf("Failed to append root: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close roots: %v", err)
+	}
+	stats, err = s.apply(multiConfig(backend, logA, logB))
+	if err != nil {
+		t.Fatalf("apply(a, b) with new root: %v", err)
+	}
+	if want := (reloadStats{updated: 1, unchanged: 1}); stats != want {
+		t.Errorf("apply(a, b) with new root=%+v, want %+v", stats, want)
+	}
+	if got := countRoots(t, s, "a"); got != 2 {
+		t.Errorf("Log a serves %d roots, want 2", got)
+	}
+
+	// An invalid config leaves the current one in place.
+	dup := &configpb.LogConfig{LogId: 3, Prefix: "b", IsMirror: true, PublicKey: logB.PublicKey, LogBackendName: "be"}
+	if _, err := s.apply(multiConfig(backend, logB, dup)); err == nil {
+		t.Error("apply(duplicate prefix)=nil, want error")
+	}
+	if got := countRoots(t, s, "a"); got != 2 {
+		t.Errorf("Log a serves %d roots after failed reload, want 2", got)
+	}
+
+	stats, err = s.apply(multiConfig(backend, logB))
+	if err != nil {
+		t.Fatalf("apply(b): %v", err)
+	}
+	if want := (reloadStats{removed: 1, unchanged: 1}); stats != want {
+		t.Errorf("apply(b)=%+v, want %+v", stats, want)
+	}
+	if got := countRoots(t, s, "a"); got != -1 {
+		t.Errorf("Removed log a still served")
+	}
+}
+
+func TestLogServerIssuanceChainStorage(t *testing.T) {
+	*getSTHInterval = 0
+	dir := t.TempDir()
+	roots := copyFile(t, "../../testdata/fake-ca.cert", dir)
+	privKey, err := anypb.New(&keyspb.PEMKeyFile{Path: "../../testdata/ct-http-server.privkey.pem", Password: "dirk"})
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+	ctfeStorage := configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE
+	logA := &configpb.LogConfig{LogId: 1, Prefix: "a", RootsPemFile: []string{roots}, PrivateKey: privKey, LogBackendName: "be", ExtraDataIssuanceChainStorageBackend: ctfeStorage, CtfeStorageConnectionString: "memory://"}
+	backend := startBackend(t)
+
+	s := newLogServer(context.Background(), []grpc.DialOption{grpc.WithInsecure()}, time.Second)
+	defer s.close()
+
+	if _, err := s.apply(multiConfig(backend, logA)); err != nil {
+		t.Fatalf("apply(a): %v", err)
+	}
+	chains := s.chains["memory://"]
+	if chains == nil {
+		t.Fatal("No issuance chain storage opened for log a")
+	}
+
+	// Changed logs keep using the same storage.
+	changedA := proto.Clone(logA).(*configpb.LogConfig)
+	changedA.RejectExpired = true
+	if stats, err := s.apply(multiConfig(backend, changedA)); err != nil || stats.updated != 1 {
+		t.Fatalf("apply(changed a)=%+v, %v, want 1 update", stats, err)
+	}
+	if s.chains["memory://"] != chains {
+		t.Error("apply(changed a) opened a new issuance chain storage")
+	}
+
+	// Storages no longer used are dropped.
+	logB := proto.Clone(logA).(*configpb.LogConfig)
+	logB.ExtraDataIssuanceChainStorageBackend, logB.CtfeStorageConnectionString = configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_TRILLIAN_GRPC, ""
+	if _, err := s.apply(multiConfig(backend, logB)); err != nil {
+		t.Fatalf("apply(b): %v", err)
+	}
+	if len(s.chains) != 0 {
+		t.Errorf("Got %d issuance chain storages without logs using them, want 0", len(s.chains))
+	}
+
+	// A storage that can't be opened fails the reload.
+	badA := proto.Clone(logA).(*configpb.LogConfig)
+	badA.CtfeStorageConnectionString = "nosuchdriver://db"
+	if _, err := s.apply(multiConfig(backend, badA)); err == nil {
+		t.Error("apply(unknown driver)=nil, want error")
+	}
+}
+
+func TestRunningLogDrain(t *testing.T) {
+	l := &runningLog{}
+	started, release := make(chan struct{}), make(chan struct{})
+	h := l.track(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	}))
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+	if l.drain(10 * time.Millisecond) {
+		t.Error("drain()=true with a request in flight")
+	}
+	close(release)
+	<-done
+	if !l.drain(time.Second) {
+		t.Error("drain()=false with no request in flight")
+	}
+}
+
+func TestConfigReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "ct_server.cfg")
+	if err := os.WriteFile(cfgFile, []byte("config"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	roots := copyFile(t, "../../testdata/fake-ca.cert", dir)
+	*logConfig = cfgFile
+
+	c := newConfigReloader(nil, nil, monitoring.InertMetricFactory{})
+	c.watchFiles(multiConfig("", &configpb.LogConfig{RootsPemFile: []string{roots}}))
+	if c.currentStamp() != c.stamp {
+		t.Fatal("Stamp changed without changes to the files")
+	}
+	if err := os.WriteFile(roots, []byte("new roots"), 0o644); err != nil {
+		t.Fatalf("Failed to write roots: %v", err)
+	}
+	if c.currentStamp() == c.stamp {
+		t.Error("Stamp unchanged after changing the roots file")
+	}
+
+	// A config that can't be read is not retried until the files change
+	// again.
+	c.read = func() (*configpb.LogMultiConfig, error) { return nil, errors.New("malformed config") }
+	if err := c.reload(); err == nil {
+		t.Fatal("reload()=nil, want error")
+	}
+	if c.currentStamp() != c.stamp {
+		t.Error("Stamp not updated after failing to read the config")
+	}
+}
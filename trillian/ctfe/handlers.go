@@ -119,6 +119,8 @@ var (
 	rspsCounter        monitoring.Counter   // logid, ep, rc => value
 	rspLatency         monitoring.Histogram // logid, ep, rc => value
 	alignedGetEntries  monitoring.Counter   // logid, aligned => count
+	sctCacheLookups    monitoring.Counter   // logid, ep, result => count
+	lintFindings       monitoring.Counter   // logid, check, action => count
 )
 
 // setupMetrics initializes all the exported metrics.
@@ -135,6 +137,8 @@ func setupMetrics(mf monitoring.MetricFactory) {
 	rspsCounter = mf.NewCounter("http_rsps", "Number of responses", "logid", "ep", "rc")
 	rspLatency = mf.NewHistogram("http_latency", "Latency of responses in seconds", "logid", "ep", "rc")
 	alignedGetEntries = mf.NewCounter("aligned_get_entries", "Number of get-entries requests which were aligned to size limit boundaries", "logid", "aligned")
+	sctCacheLookups = mf.NewCounter("sct_cache_lookups", "Number of add-[pre-]chain requests looked up in the SCT cache, by result (hit, miss or error)", "logid", "ep", "result")
+	lintFindings = mf.NewCounter("lint_findings", "Number of problems found in submissions by lint checks, by the action taken (record, warn or reject)", "logid", "check", "action")
 }
 
 // Entrypoints is a list of entrypoint names as exposed in statistics/logging.
@@ -232,6 +236,8 @@ type CertValidationOpts struct {
 	extKeyUsages []x509.ExtKeyUsage
 	// rejectExtIds contains a list of X.509 extension IDs to reject during chain verification.
 	rejectExtIds []asn1.ObjectIdentifier
+	// lint runs the log's lint rules on verified chains. nil runs none.
+	lint *linter
 }
 
 // NewCertValidationOpts builds validation options based on parameters.
@@ -270,6 +276,12 @@ type logInfo struct {
 	signer crypto.Signer
 	// sthGetter provides STHs for the log
 	sthGetter STHGetter
+	// issuanceChainService stores the issuance chains of entries outside of
+	// Trillian, if the log is configured to do so; otherwise it is nil
+	issuanceChainService *issuanceChainService
+	// roots holds the roots accepted by the log, which can be changed at
+	// runtime; if nil, they are those of validationOpts
+	roots *rootSet
 }
 
 // newLogInfo creates a new instance of logInfo.
@@ -353,8 +365,28 @@ func (li *logInfo) Handlers(prefix string) PathHandlers {
 	return ph
 }
 
+// trustedRoots returns the roots that the log currently accepts.
+func (li *logInfo) trustedRoots() *x509util.PEMCertPool {
+	if li.roots == nil {
+		return li.validationOpts.trustedRoots
+	}
+	return li.roots.pool(li.TimeSource.Now())
+}
+
 // SendHTTPError generates a custom error page to give more information on why something didn't work
 func (li *logInfo) SendHTTPError(w http.ResponseWriter, statusCode int, err error) {
+	// Submissions rejected by lint checks get the findings in a form that the
+	// submitter can act on.
+	var lintErr *LintError
+	if errors.As(err, &lintErr) {
+		w.Header().Set(contentTypeHeader, contentTypeJSON)
+		w.WriteHeader(statusCode)
+		rsp := lintErrorResponse{Error: err.Error(), Findings: lintErr.Findings}
+		if err := json.NewEncoder(w).Encode(rsp); err != nil {
+			klog.Warningf("%s: failed to write lint error response: %v", li.LogPrefix, err)
+		}
+		return
+	}
 	errorBody := http.StatusText(statusCode)
 	if !li.instanceOpts.MaskInternalErrors || statusCode != http.StatusInternalServerError {
 		errorBody += fmt.Sprintf("\n%v", err)
@@ -362,6 +394,13 @@ func (li *logInfo) SendHTTPError(w http.ResponseWriter, statusCode int, err erro
 	http.Error(w, errorBody, statusCode)
 }
 
+// lintErrorResponse is the body of the error response for submissions
+// rejected by lint checks.
+type lintErrorResponse struct {
+	Error    string        `json:"error"`
+	Findings []LintFinding `json:"lint_findings"`
+}
+
 // getSTH returns the current STH as known to the STH getter, and updates tree
 // size / timestamp metrics correspondingly.
 func (li *logInfo) getSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
@@ -443,11 +482,20 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 	}
 	chain, err := verifyAddChain(li, addChainReq, isPrecert)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("failed to verify add-chain contents: %s", err)
+		return http.StatusBadRequest, fmt.Errorf("failed to verify add-chain contents: %w", err)
 	}
 	for _, cert := range chain {
 		li.RequestLog.AddCertToChain(ctx, cert)
 	}
+	// Answer duplicate submissions with the SCT issued for the original,
+	// without sending them to the backend.
+	if sct := li.cachedSCT(ctx, method, addChainReq, isPrecert); sct != nil {
+		if err := marshalAndWriteAddChainResponse(sct, li.signer, w); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to write response: %s", err)
+		}
+		klog.V(3).Infof("%s: %s <= cached SCT", li.LogPrefix, method)
+		return http.StatusOK, nil
+	}
 	// Get the current time in the form used throughout RFC6962, namely milliseconds since Unix
 	// epoch, and use this throughout.
 	timeMillis := uint64(li.TimeSource.Now().UnixNano() / millisPerNano)
@@ -457,7 +505,7 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("failed to build MerkleTreeLeaf: %s", err)
 	}
-	leaf, err := buildLogLeafForAddChain(li, *merkleLeaf, chain, isPrecert)
+	leaf, err := buildLogLeafForAddChain(ctx, li, *merkleLeaf, chain, isPrecert)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("failed to build LogLeaf: %s", err)
 	}
@@ -508,6 +556,7 @@ func addChainInternal(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 	}
 	// We could possibly fail to issue the SCT after this but it's v. unlikely.
 	li.RequestLog.IssueSCT(ctx, sctBytes)
+	li.cacheSCT(ctx, addChainReq, isPrecert, sctBytes)
 	err = marshalAndWriteAddChainResponse(sct, li.signer, w)
 	if err != nil {
 		// reason is logged and http status is already set
@@ -760,6 +809,13 @@ func getEntries(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http
 		}
 	}
 	leaves = rsp.Leaves
+	if li.issuanceChainService != nil {
+		for _, leaf := range leaves {
+			if err := li.issuanceChainService.fixLogLeaf(ctx, leaf); err != nil {
+				return http.StatusInternalServerError, fmt.Errorf("failed to fix log leaf: %v", err)
+			}
+		}
+	}
 
 	// Now we've checked the RPC response and it seems to be valid we need
 	// to serialize the leaves in JSON format for the HTTP response. Doing a
@@ -788,8 +844,9 @@ func getEntries(ctx context.Context, li *logInfo, w http.ResponseWriter, r *http
 
 func getRoots(_ context.Context, li *logInfo, w http.ResponseWriter, _ *http.Request) (int, error) {
 	// Pull out the raw certificates from the parsed versions
-	rawCerts := make([][]byte, 0, len(li.validationOpts.trustedRoots.RawCertificates()))
-	for _, cert := range li.validationOpts.trustedRoots.RawCertificates() {
+	roots := li.trustedRoots().RawCertificates()
+	rawCerts := make([][]byte, 0, len(roots))
+	for _, cert := range roots {
 		rawCerts = append(rawCerts, cert.Raw)
 	}
 
@@ -844,6 +901,11 @@ func getEntryAndProof(ctx context.Context, li *logInfo, w http.ResponseWriter, r
 	if treeSize > 1 && len(rsp.Proof.Hashes) == 0 {
 		return http.StatusInternalServerError, fmt.Errorf("got RPC bad response (missing proof), possible extra info: %v", rsp)
 	}
+	if li.issuanceChainService != nil {
+		if err := li.issuanceChainService.fixLogLeaf(ctx, rsp.Leaf); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to fix log leaf: %v", err)
+		}
+	}
 
 	// Build and marshal the response to the client
 	jsonRsp := ct.GetEntryAndProofResponse{
@@ -878,11 +940,13 @@ func getRPCDeadlineTime(li *logInfo) time.Time {
 // cert is of the correct type and chains to a trusted root.
 func verifyAddChain(li *logInfo, req ct.AddChainRequest, expectingPrecert bool) ([]*x509.Certificate, error) {
 	// We already checked that the chain is not empty so can move on to verification
-	validPath, err := ValidateChain(req.Chain, li.validationOpts)
+	opts := li.validationOpts
+	opts.trustedRoots = li.trustedRoots()
+	validPath, err := ValidateChain(req.Chain, opts)
 	if err != nil {
 		// We rejected it because the cert failed checks or we could not find a path to a root etc.
 		// Lots of possible causes for errors
-		return nil, fmt.Errorf("chain failed to verify: %s", err)
+		return nil, fmt.Errorf("chain failed to verify: %w", err)
 	}
 
 	isPrecert, err := IsPrecertificate(validPath[0])
@@ -913,10 +977,13 @@ func extractRawCerts(chain []*x509.Certificate) []ct.ASN1Cert {
 
 // buildLogLeafForAddChain does the hashing to build a LogLeaf that will be
 // sent to the backend by add-chain and add-pre-chain endpoints.
-func buildLogLeafForAddChain(li *logInfo,
+func buildLogLeafForAddChain(ctx context.Context, li *logInfo,
 	merkleLeaf ct.MerkleTreeLeaf, chain []*x509.Certificate, isPrecert bool,
 ) (trillian.LogLeaf, error) {
 	raw := extractRawCerts(chain)
+	if li.issuanceChainService != nil {
+		return li.issuanceChainService.buildLogLeaf(ctx, li.LogPrefix, merkleLeaf, 0, raw[0], raw[1:], isPrecert)
+	}
 	return util.BuildLogLeaf(li.LogPrefix, merkleLeaf, 0, raw[0], raw[1:], isPrecert)
 }
 
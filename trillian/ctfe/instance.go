@@ -29,6 +29,9 @@ import (
 
 	"github.com/google/certificate-transparency-go/asn1"
 	"github.com/google/certificate-transparency-go/schedule"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/cache"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/configpb"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
 	"github.com/google/certificate-transparency-go/trillian/util"
 	"github.com/google/certificate-transparency-go/x509"
 	"github.com/google/certificate-transparency-go/x509util"
@@ -72,6 +75,27 @@ type InstanceOptions struct {
 	// MaskInternalErrors indicates if internal server errors should be masked
 	// or returned to the user containing the full error message.
 	MaskInternalErrors bool
+	// IssuanceChainCacheSize is the number of issuance chains to cache in
+	// memory, for logs that store them in CTFE storage. Zero disables the
+	// cache.
+	IssuanceChainCacheSize int
+	// IssuanceChainStorage holds the issuance chains of the log, if it keeps
+	// them in CTFE storage. It can be shared by logs with the same
+	// ctfe_storage_connection_string. If nil, the storage is opened from the
+	// connection string, and is never closed.
+	IssuanceChainStorage storage.IssuanceChainStorage
+	// SCTCache holds the SCTs issued by the log, so that duplicate add-chain and
+	// add-pre-chain requests are answered, once their chain is verified,
+	// without contacting the backend. If nil, every request goes to the
+	// backend.
+	SCTCache cache.SCTCache
+	// LintRules are run on submissions after the rules of the log's lint
+	// policy, e.g. to add checks that are not built in.
+	LintRules []LintRule
+	// RootStore records the changes made to the log's roots at runtime,
+	// through a RootAdmin, and is read to apply them on top of the roots of
+	// the config. If nil, the roots can't be changed at runtime.
+	RootStore storage.RootStore
 }
 
 // Instance is a set up log/mirror instance. It must be created with the
@@ -167,6 +191,7 @@ func setUpLogInfo(ctx context.Context, opts InstanceOptions) (*logInfo, error) {
 		notAfterLimit:   vCfg.NotAfterLimit,
 		acceptOnlyCA:    cfg.AcceptOnlyCa,
 		extKeyUsages:    vCfg.KeyUsages,
+		lint:            newLinter(cfg.LogId, cfg.Prefix, append(append([]LintRule(nil), vCfg.LintRules...), opts.LintRules...)),
 	}
 	var err error
 	validationOpts.rejectExtIds, err = parseOIDs(cfg.RejectExtensions)
@@ -175,6 +200,21 @@ func setUpLogInfo(ctx context.Context, opts InstanceOptions) (*logInfo, error) {
 	}
 
 	logInfo := newLogInfo(opts, validationOpts, signer, new(util.SystemTimeSource))
+	if logInfo.roots, err = newRootSet(ctx, cfg.LogId, roots, opts.RootStore); err != nil {
+		return nil, fmt.Errorf("failed to set up roots: %v", err)
+	}
+	if cfg.ExtraDataIssuanceChainStorageBackend == configpb.IssuanceChainStorageBackend_ISSUANCE_CHAIN_STORAGE_BACKEND_CTFE {
+		s := opts.IssuanceChainStorage
+		if s == nil {
+			if s, err = storage.OpenIssuanceChainStorage(ctx, cfg.CtfeStorageConnectionString); err != nil {
+				return nil, fmt.Errorf("failed to open issuance chain storage: %v", err)
+			}
+		}
+		if opts.IssuanceChainCacheSize > 0 {
+			s = storage.NewLRUIssuanceChainStorage(s, opts.IssuanceChainCacheSize)
+		}
+		logInfo.issuanceChainService = newIssuanceChainService(s)
+	}
 	return logInfo, nil
 }
 
@@ -0,0 +1,127 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	"github.com/google/certificate-transparency-go/trillian/util"
+	"github.com/google/trillian"
+)
+
+// issuanceChainService stores the issuance chains of a log's entries in an
+// IssuanceChainStorage, so that each distinct chain is stored once rather
+// than in the extra data of every entry. The extra data of entries in
+// Trillian holds the hash of the chain instead, and the RFC 6962 extra data
+// is rebuilt when serving entries.
+type issuanceChainService struct {
+	storage storage.IssuanceChainStorage
+}
+
+func newIssuanceChainService(s storage.IssuanceChainStorage) *issuanceChainService {
+	return &issuanceChainService{storage: s}
+}
+
+// add stores chain, and returns its hash.
+func (s *issuanceChainService) add(ctx context.Context, chain []ct.ASN1Cert) ([]byte, error) {
+	data, err := tls.Marshal(ct.CertificateChain{Entries: chain})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize chain: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	if err := s.storage.Add(ctx, hash[:], data); err != nil {
+		return nil, fmt.Errorf("failed to store chain: %v", err)
+	}
+	return hash[:], nil
+}
+
+// get returns the chain with the given hash.
+func (s *issuanceChainService) get(ctx context.Context, hash []byte) ([]ct.ASN1Cert, error) {
+	data, err := s.storage.FindByKey(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find chain %x: %w", hash, err)
+	}
+	var chain ct.CertificateChain
+	if rest, err := tls.Unmarshal(data, &chain); err != nil {
+		return nil, fmt.Errorf("failed to parse chain %x: %v", hash, err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data (%d bytes) after chain %x", len(rest), hash)
+	}
+	return chain.Entries, nil
+}
+
+// buildLogLeaf stores chain, and returns a LogLeaf for cert whose extra data
+// holds the hash of chain.
+func (s *issuanceChainService) buildLogLeaf(ctx context.Context, logPrefix string,
+	merkleLeaf ct.MerkleTreeLeaf, leafIndex int64,
+	cert ct.ASN1Cert, chain []ct.ASN1Cert, isPrecert bool,
+) (trillian.LogLeaf, error) {
+	hash, err := s.add(ctx, chain)
+	if err != nil {
+		return trillian.LogLeaf{}, err
+	}
+	return util.BuildLogLeafWithChainHash(logPrefix, merkleLeaf, leafIndex, cert, hash, isPrecert)
+}
+
+// fixLogLeaf replaces the extra data of leaf, if it holds the hash of a
+// stored chain, with the RFC 6962 extra data. Leaves whose extra data is
+// already in RFC 6962 form, e.g. as they were added before the log stored
+// chains separately, are left unchanged.
+func (s *issuanceChainService) fixLogLeaf(ctx context.Context, leaf *trillian.LogLeaf) error {
+	var merkleLeaf ct.MerkleTreeLeaf
+	if rest, err := tls.Unmarshal(leaf.LeafValue, &merkleLeaf); err != nil {
+		return fmt.Errorf("failed to parse Merkle leaf %d: %v", leaf.LeafIndex, err)
+	} else if len(rest) > 0 {
+		return fmt.Errorf("trailing data (%d bytes) after Merkle leaf %d", len(rest), leaf.LeafIndex)
+	}
+	if merkleLeaf.TimestampedEntry == nil {
+		return fmt.Errorf("no timestamped entry in Merkle leaf %d", leaf.LeafIndex)
+	}
+
+	// The hash forms can't be mistaken for the RFC 6962 forms: after the
+	// precertificate, if any, they are 33 bytes starting with the 1-byte
+	// length 32, whereas the RFC 6962 chain has a 3-byte length, which starts
+	// with 32 only for chains of over 2MB.
+	switch merkleLeaf.TimestampedEntry.EntryType {
+	case ct.X509LogEntryType:
+		var extra ct.CertificateChainHash
+		if rest, err := tls.Unmarshal(leaf.ExtraData, &extra); err != nil || len(rest) > 0 {
+			return nil
+		}
+		chain, err := s.get(ctx, extra.IssuanceChainHash)
+		if err != nil {
+			return err
+		}
+		leaf.ExtraData, err = util.ExtraDataForChain(ct.ASN1Cert{}, chain, false)
+		return err
+	case ct.PrecertLogEntryType:
+		var extra ct.PrecertChainEntryHash
+		if rest, err := tls.Unmarshal(leaf.ExtraData, &extra); err != nil || len(rest) > 0 {
+			return nil
+		}
+		chain, err := s.get(ctx, extra.IssuanceChainHash)
+		if err != nil {
+			return err
+		}
+		leaf.ExtraData, err = util.ExtraDataForChain(extra.PreCertificate, chain, true)
+		return err
+	}
+	return nil
+}
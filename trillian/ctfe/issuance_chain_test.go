@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	cttestonly "github.com/google/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/google/certificate-transparency-go/trillian/util"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+)
+
+func TestIssuanceChainService(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		chain     []string
+		isPrecert bool
+	}{
+		{
+			desc:  "cert",
+			chain: []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM},
+		},
+		{
+			desc:  "cert-no-issuers",
+			chain: []string{cttestonly.LeafSignedByFakeIntermediateCertPEM},
+		},
+		{
+			desc:      "precert",
+			chain:     []string{cttestonly.PrecertPEMValid, cttestonly.CACertPEM},
+			isPrecert: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			var certs []*x509.Certificate
+			for _, pem := range tc.chain {
+				cert, err := x509util.CertificateFromPEM([]byte(pem))
+				if x509.IsFatal(err) {
+					t.Fatalf("Failed to parse certificate: %v", err)
+				}
+				certs = append(certs, cert)
+			}
+			etype := ct.X509LogEntryType
+			if tc.isPrecert {
+				etype = ct.PrecertLogEntryType
+			}
+			merkleLeaf, err := ct.MerkleTreeLeafFromChain(certs, etype, 1000)
+			if err != nil {
+				t.Fatalf("MerkleTreeLeafFromChain: %v", err)
+			}
+			raw := extractRawCerts(certs)
+			want, err := util.BuildLogLeaf("test", *merkleLeaf, 0, raw[0], raw[1:], tc.isPrecert)
+			if err != nil {
+				t.Fatalf("BuildLogLeaf: %v", err)
+			}
+
+			s := newIssuanceChainService(storage.NewMemoryIssuanceChainStorage())
+			leaf, err := s.buildLogLeaf(ctx, "test", *merkleLeaf, 0, raw[0], raw[1:], tc.isPrecert)
+			if err != nil {
+				t.Fatalf("buildLogLeaf: %v", err)
+			}
+			if !bytes.Equal(leaf.LeafValue, want.LeafValue) || !bytes.Equal(leaf.LeafIdentityHash, want.LeafIdentityHash) {
+				t.Errorf("buildLogLeaf built a different Merkle leaf than BuildLogLeaf")
+			}
+			if bytes.Equal(leaf.ExtraData, want.ExtraData) {
+				t.Errorf("buildLogLeaf kept the chain in the extra data")
+			}
+
+			// The same chain is stored under the same hash.
+			again, err := s.buildLogLeaf(ctx, "test", *merkleLeaf, 1, raw[0], raw[1:], tc.isPrecert)
+			if err != nil {
+				t.Fatalf("buildLogLeaf again: %v", err)
+			}
+			if !bytes.Equal(again.ExtraData, leaf.ExtraData) {
+				t.Errorf("buildLogLeaf again gave extra data %x, want %x", again.ExtraData, leaf.ExtraData)
+			}
+
+			if err := s.fixLogLeaf(ctx, &leaf); err != nil {
+				t.Fatalf("fixLogLeaf: %v", err)
+			}
+			if !bytes.Equal(leaf.ExtraData, want.ExtraData) {
+				t.Errorf("fixLogLeaf gave extra data %x, want %x", leaf.ExtraData, want.ExtraData)
+			}
+
+			// Leaves with RFC 6962 extra data are left unchanged.
+			if err := s.fixLogLeaf(ctx, &want); err != nil {
+				t.Fatalf("fixLogLeaf(legacy): %v", err)
+			}
+			if !bytes.Equal(leaf.ExtraData, want.ExtraData) {
+				t.Errorf("fixLogLeaf(legacy) changed the extra data to %x", want.ExtraData)
+			}
+
+			// Chains missing from storage are an error.
+			empty := newIssuanceChainService(storage.NewMemoryIssuanceChainStorage())
+			if err := empty.fixLogLeaf(ctx, &again); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("fixLogLeaf(missing chain)=%v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe/configpb"
+	"github.com/google/certificate-transparency-go/x509"
+	"k8s.io/klog/v2"
+)
+
+// LintSubmission is an add-[pre-]chain submission, as seen by lint checks.
+type LintSubmission struct {
+	// Chain is the verified chain, from the submitted (pre-)certificate to
+	// a trusted root.
+	Chain []*x509.Certificate
+	// ParseErrors holds the non-fatal errors found when parsing the submitted
+	// certificates.
+	ParseErrors []error
+}
+
+// LintCheck is a check run on submissions once their chain is verified.
+type LintCheck interface {
+	// Name identifies the check in metrics and error responses.
+	Name() string
+	// Check returns a description of each problem found in the submission.
+	Check(s *LintSubmission) []string
+}
+
+// LintRule is a LintCheck, and what the log does with its findings.
+type LintRule struct {
+	Check  LintCheck
+	Action configpb.LintAction
+}
+
+// LintFinding is a problem found in a submission by a lint check.
+type LintFinding struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// LintError is returned for submissions rejected by lint rules. Its findings
+// are sent to the submitter in the error response.
+type LintError struct {
+	Findings []LintFinding
+}
+
+func (e *LintError) Error() string {
+	msgs := make([]string, 0, len(e.Findings))
+	for _, f := range e.Findings {
+		msgs = append(msgs, f.Check+": "+f.Message)
+	}
+	return "rejected by lint checks: " + strings.Join(msgs, "; ")
+}
+
+// LintRulesFromPolicy returns the rules for the enabled checks of a log's
+// lint policy, or an error if the policy is invalid.
+func LintRulesFromPolicy(p *configpb.LintPolicy) ([]LintRule, error) {
+	var rules []LintRule
+	add := func(action configpb.LintAction, check LintCheck) error {
+		if _, ok := configpb.LintAction_name[int32(action)]; !ok {
+			return fmt.Errorf("%s: unknown action: %v", check.Name(), action)
+		}
+		if action != configpb.LintAction_LINT_ACTION_DISABLED {
+			rules = append(rules, LintRule{Check: check, Action: action})
+		}
+		return nil
+	}
+
+	if ks := p.GetKeySize(); ks != nil {
+		if ks.MinRsaBits < 0 || ks.MinEcdsaBits < 0 {
+			return nil, errors.New("key_size: negative minimum size")
+		}
+		if err := add(ks.Action, keySizeCheck{minRSABits: int(ks.MinRsaBits), minECDSABits: int(ks.MinEcdsaBits)}); err != nil {
+			return nil, err
+		}
+	}
+	if sa := p.GetSignatureAlgorithms(); sa != nil {
+		check := sigAlgCheck{allowed: make(map[x509.SignatureAlgorithm]bool)}
+		for _, name := range sa.Allowed {
+			algo, ok := sigAlgByName(name)
+			if !ok {
+				return nil, fmt.Errorf("signature_algorithms: unknown algorithm: %s", name)
+			}
+			check.allowed[algo] = true
+		}
+		if len(check.allowed) == 0 && sa.Action != configpb.LintAction_LINT_ACTION_DISABLED {
+			return nil, errors.New("signature_algorithms: no allowed algorithms")
+		}
+		if err := add(sa.Action, check); err != nil {
+			return nil, err
+		}
+	}
+	if mv := p.GetMaxValidity(); mv != nil {
+		if mv.MaxValidityDays <= 0 && mv.Action != configpb.LintAction_LINT_ACTION_DISABLED {
+			return nil, errors.New("max_validity: non-positive maximum validity")
+		}
+		if err := add(mv.Action, maxValidityCheck{max: time.Duration(mv.MaxValidityDays) * 24 * time.Hour}); err != nil {
+			return nil, err
+		}
+	}
+	if rs := p.GetRequiredSans(); rs != nil {
+		if err := add(rs.Action, requiredSANsCheck{}); err != nil {
+			return nil, err
+		}
+	}
+	if pw := p.GetParseWarnings(); pw != nil {
+		if err := add(pw.Action, parseWarningsCheck{}); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// sigAlgByName returns the signature algorithm with the given name, as
+// returned by its String method.
+func sigAlgByName(name string) (x509.SignatureAlgorithm, bool) {
+	for algo := x509.UnknownSignatureAlgorithm + 1; ; algo++ {
+		s := algo.String()
+		if s == strconv.Itoa(int(algo)) {
+			// Past the last known algorithm.
+			return x509.UnknownSignatureAlgorithm, false
+		}
+		if s == name {
+			return algo, true
+		}
+	}
+}
+
+// linter runs the lint rules of a log on its submissions.
+type linter struct {
+	logID  string // For metrics.
+	prefix string // For log messages.
+	rules  []LintRule
+}
+
+// newLinter returns a linter for the given rules, or nil if there are none.
+func newLinter(logID int64, prefix string, rules []LintRule) *linter {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &linter{logID: strconv.FormatInt(logID, 10), prefix: prefix, rules: rules}
+}
+
+// run runs all the rules on the submission, and returns a *LintError holding
+// the findings of the rules that reject it, if there are any. A nil linter
+// accepts all submissions.
+func (l *linter) run(s *LintSubmission) error {
+	if l == nil {
+		return nil
+	}
+	var rejected []LintFinding
+	for _, rule := range l.rules {
+		name := rule.Check.Name()
+		action := strings.ToLower(strings.TrimPrefix(rule.Action.String(), "LINT_ACTION_"))
+		for _, msg := range rule.Check.Check(s) {
+			lintFindings.Inc(l.logID, name, action)
+			switch rule.Action {
+			case configpb.LintAction_LINT_ACTION_WARN:
+				klog.Warningf("%s: lint check %s: %s", l.prefix, name, msg)
+			case configpb.LintAction_LINT_ACTION_REJECT:
+				rejected = append(rejected, LintFinding{Check: name, Message: msg})
+			}
+		}
+	}
+	if len(rejected) > 0 {
+		return &LintError{Findings: rejected}
+	}
+	return nil
+}
+
+// issued returns the certificates of the chain signed by an issuer, i.e. all
+// but the root.
+func issued(chain []*x509.Certificate) []*x509.Certificate {
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain[:len(chain)-1]
+}
+
+// keySizeCheck checks the sizes of the public keys of the chain.
+type keySizeCheck struct {
+	minRSABits   int
+	minECDSABits int
+}
+
+func (keySizeCheck) Name() string { return "key_size" }
+
+func (c keySizeCheck) Check(s *LintSubmission) []string {
+	var msgs []string
+	for i, cert := range issued(s.Chain) {
+		switch pub := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if bits := pub.N.BitLen(); bits < c.minRSABits {
+				msgs = append(msgs, fmt.Sprintf("certificate %d has a %d-bit RSA key, want at least %d bits", i, bits, c.minRSABits))
+			}
+		case *ecdsa.PublicKey:
+			if bits := pub.Curve.Params().BitSize; bits < c.minECDSABits {
+				msgs = append(msgs, fmt.Sprintf("certificate %d has a %d-bit ECDSA key, want at least %d bits", i, bits, c.minECDSABits))
+			}
+		}
+	}
+	return msgs
+}
+
+// sigAlgCheck checks the algorithms the chain is signed with.
+type sigAlgCheck struct {
+	allowed map[x509.SignatureAlgorithm]bool
+}
+
+func (sigAlgCheck) Name() string { return "signature_algorithm" }
+
+func (c sigAlgCheck) Check(s *LintSubmission) []string {
+	var msgs []string
+	for i, cert := range issued(s.Chain) {
+		if !c.allowed[cert.SignatureAlgorithm] {
+			msgs = append(msgs, fmt.Sprintf("certificate %d is signed with disallowed algorithm %v", i, cert.SignatureAlgorithm))
+		}
+	}
+	return msgs
+}
+
+// maxValidityCheck checks the validity period of the submitted certificate.
+type maxValidityCheck struct {
+	max time.Duration
+}
+
+func (maxValidityCheck) Name() string { return "max_validity" }
+
+func (c maxValidityCheck) Check(s *LintSubmission) []string {
+	cert := s.Chain[0]
+	if validity := cert.NotAfter.Sub(cert.NotBefore); validity > c.max {
+		return []string{fmt.Sprintf("validity period of %v exceeds %v", validity, c.max)}
+	}
+	return nil
+}
+
+// requiredSANsCheck checks that the submitted certificate, unless it is a CA
+// certificate, has a Subject Alternative Name.
+type requiredSANsCheck struct{}
+
+func (requiredSANsCheck) Name() string { return "required_sans" }
+
+func (requiredSANsCheck) Check(s *LintSubmission) []string {
+	cert := s.Chain[0]
+	if cert.IsCA {
+		return nil
+	}
+	if len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.IPAddresses)+len(cert.URIs) == 0 {
+		return []string{"certificate has no Subject Alternative Name"}
+	}
+	return nil
+}
+
+// parseWarningsCheck reports the non-fatal errors found when parsing the
+// submitted certificates.
+type parseWarningsCheck struct{}
+
+func (parseWarningsCheck) Name() string { return "parse_warnings" }
+
+func (parseWarningsCheck) Check(s *LintSubmission) []string {
+	msgs := make([]string, 0, len(s.ParseErrors))
+	for _, err := range s.ParseErrors {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
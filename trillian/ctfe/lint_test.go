@@ -0,0 +1,269 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/configpb"
+	cttestonly "github.com/google/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const (
+	record = configpb.LintAction_LINT_ACTION_RECORD
+	warn   = configpb.LintAction_LINT_ACTION_WARN
+	reject = configpb.LintAction_LINT_ACTION_REJECT
+)
+
+func TestLintRulesFromPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		policy    *configpb.LintPolicy
+		wantErr   string
+		wantNames []string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "disabled",
+			policy: &configpb.LintPolicy{
+				KeySize:             &configpb.LintPolicy_KeySize{MinRsaBits: 2048},
+				SignatureAlgorithms: &configpb.LintPolicy_SignatureAlgorithms{},
+				MaxValidity:         &configpb.LintPolicy_MaxValidity{},
+			},
+		},
+		{
+			desc: "all",
+			policy: &configpb.LintPolicy{
+				KeySize:             &configpb.LintPolicy_KeySize{Action: reject, MinRsaBits: 2048, MinEcdsaBits: 256},
+				SignatureAlgorithms: &configpb.LintPolicy_SignatureAlgorithms{Action: warn, Allowed: []string{"SHA256-RSA", "ECDSA-SHA256"}},
+				MaxValidity:         &configpb.LintPolicy_MaxValidity{Action: record, MaxValidityDays: 398},
+				RequiredSans:        &configpb.LintPolicy_RequiredSANs{Action: reject},
+				ParseWarnings:       &configpb.LintPolicy_ParseWarnings{Action: warn},
+			},
+			wantNames: []string{"key_size", "signature_algorithm", "max_validity", "required_sans", "parse_warnings"},
+		},
+		{
+			desc:    "negative-key-size",
+			policy:  &configpb.LintPolicy{KeySize: &configpb.LintPolicy_KeySize{Action: reject, MinRsaBits: -1}},
+			wantErr: "negative minimum size",
+		},
+		{
+			desc:    "unknown-algorithm",
+			policy:  &configpb.LintPolicy{SignatureAlgorithms: &configpb.LintPolicy_SignatureAlgorithms{Action: reject, Allowed: []string{"SHA256-RSA", "ROT13"}}},
+			wantErr: "unknown algorithm: ROT13",
+		},
+		{
+			desc:    "no-algorithms",
+			policy:  &configpb.LintPolicy{SignatureAlgorithms: &configpb.LintPolicy_SignatureAlgorithms{Action: reject}},
+			wantErr: "no allowed algorithms",
+		},
+		{
+			desc:    "no-max-validity",
+			policy:  &configpb.LintPolicy{MaxValidity: &configpb.LintPolicy_MaxValidity{Action: warn}},
+			wantErr: "non-positive maximum validity",
+		},
+		{
+			desc:    "unknown-action",
+			policy:  &configpb.LintPolicy{RequiredSans: &configpb.LintPolicy_RequiredSANs{Action: 42}},
+			wantErr: "unknown action",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			rules, err := LintRulesFromPolicy(tc.policy)
+			if len(tc.wantErr) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("LintRulesFromPolicy()=%v, want err containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LintRulesFromPolicy(): %v", err)
+			}
+			var names []string
+			for _, rule := range rules {
+				names = append(names, rule.Check.Name())
+			}
+			if diff := cmp.Diff(tc.wantNames, names); diff != "" {
+				t.Errorf("LintRulesFromPolicy() rules diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func lintChainOrDie(t *testing.T) []*x509.Certificate {
+	t.Helper()
+	var chain []*x509.Certificate
+	for _, pem := range []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM, cttestonly.FakeCACertPEM} {
+		cert, err := x509util.CertificateFromPEM([]byte(pem))
+		if x509.IsFatal(err) {
+			t.Fatalf("Failed to parse certificate: %v", err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain
+}
+
+func TestLintChecks(t *testing.T) {
+	// The leaf has a P-256 key, a validity period of 1155 days and SANs. Both
+	// it and the intermediate are signed with SHA256-RSA.
+	s := &LintSubmission{
+		Chain:       lintChainOrDie(t),
+		ParseErrors: []error{errors.New("certificate 1: bad serial")},
+	}
+	for _, tc := range []struct {
+		desc  string
+		check LintCheck
+		want  []string
+	}{
+		{
+			desc:  "key-size-ok",
+			check: keySizeCheck{minRSABits: 2048, minECDSABits: 256},
+		},
+		{
+			desc:  "key-size-ecdsa",
+			check: keySizeCheck{minECDSABits: 384},
+			want:  []string{"certificate 0 has a 256-bit ECDSA key, want at least 384 bits"},
+		},
+		{
+			desc:  "signature-algorithm-ok",
+			check: sigAlgCheck{allowed: map[x509.SignatureAlgorithm]bool{x509.SHA256WithRSA: true}},
+		},
+		{
+			desc:  "signature-algorithm",
+			check: sigAlgCheck{allowed: map[x509.SignatureAlgorithm]bool{x509.ECDSAWithSHA256: true}},
+			want: []string{
+				"certificate 0 is signed with disallowed algorithm SHA256-RSA",
+				"certificate 1 is signed with disallowed algorithm SHA256-RSA",
+			},
+		},
+		{
+			desc:  "max-validity-ok",
+			check: maxValidityCheck{max: 1155 * 24 * time.Hour},
+		},
+		{
+			desc:  "max-validity",
+			check: maxValidityCheck{max: 398 * 24 * time.Hour},
+			want:  []string{"validity period of 27720h0m0s exceeds 9552h0m0s"},
+		},
+		{
+			desc:  "required-sans",
+			check: requiredSANsCheck{},
+		},
+		{
+			desc:  "parse-warnings",
+			check: parseWarningsCheck{},
+			want:  []string{"certificate 1: bad serial"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := tc.check.Check(s)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Check() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// A leaf without SANs fails the check, unless it is a CA certificate.
+	noSANs := &LintSubmission{Chain: s.Chain[1:]}
+	if got := (requiredSANsCheck{}).Check(noSANs); len(got) != 0 {
+		t.Errorf("Check(CA certificate)=%v, want none", got)
+	}
+	leaf := *s.Chain[0]
+	leaf.DNSNames = nil
+	noSANs.Chain = append([]*x509.Certificate{&leaf}, s.Chain[1:]...)
+	if got := (requiredSANsCheck{}).Check(noSANs); len(got) != 1 {
+		t.Errorf("Check(leaf without SANs)=%v, want one finding", got)
+	}
+}
+
+func TestAddChainLintPolicy(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+
+	for _, tc := range []struct {
+		desc     string
+		policy   *configpb.LintPolicy
+		wantCode int
+		want     []LintFinding
+	}{
+		{
+			desc: "warn",
+			policy: &configpb.LintPolicy{
+				KeySize: &configpb.LintPolicy_KeySize{Action: warn, MinEcdsaBits: 384},
+			},
+			// The submission gets past the lint stage, to the failing backend.
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			desc: "reject",
+			policy: &configpb.LintPolicy{
+				KeySize:      &configpb.LintPolicy_KeySize{Action: reject, MinEcdsaBits: 384},
+				MaxValidity:  &configpb.LintPolicy_MaxValidity{Action: reject, MaxValidityDays: 398},
+				RequiredSans: &configpb.LintPolicy_RequiredSANs{Action: reject},
+			},
+			wantCode: http.StatusBadRequest,
+			want: []LintFinding{
+				{Check: "key_size", Message: "certificate 0 has a 256-bit ECDSA key, want at least 384 bits"},
+				{Check: "max_validity", Message: "validity period of 27720h0m0s exceeds 9552h0m0s"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+			defer info.mockCtrl.Finish()
+			rules, err := LintRulesFromPolicy(tc.policy)
+			if err != nil {
+				t.Fatalf("LintRulesFromPolicy(): %v", err)
+			}
+			info.li.validationOpts.lint = newLinter(0x42, "test", rules)
+			if tc.want == nil {
+				info.client.EXPECT().QueueLeaf(deadlineMatcher(), gomock.Any()).Return(nil, errors.New("backend unavailable"))
+			}
+
+			recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+			if recorder.Code != tc.wantCode {
+				t.Fatalf("addChain()=%d (body:%v); want %d", recorder.Code, recorder.Body, tc.wantCode)
+			}
+			if tc.want == nil {
+				return
+			}
+			if got, want := recorder.Header().Get(contentTypeHeader), contentTypeJSON; got != want {
+				t.Errorf("Content-Type=%q, want %q", got, want)
+			}
+			var rsp lintErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &rsp); err != nil {
+				t.Fatalf("Failed to unmarshal response %q: %v", recorder.Body, err)
+			}
+			if diff := cmp.Diff(tc.want, rsp.Findings); diff != "" {
+				t.Errorf("Findings diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
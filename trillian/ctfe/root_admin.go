@@ -0,0 +1,252 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	"github.com/google/certificate-transparency-go/x509"
+	"k8s.io/klog/v2"
+)
+
+// RootAdminPathPrefix is the prefix of the paths served by RootAdmin.
+const RootAdminPathPrefix = "/admin/v1/logs/"
+
+// RefreshRoots rebuilds the roots accepted by the log from its root store,
+// picking up the changes made through other CTFE instances.
+func (i *Instance) RefreshRoots(ctx context.Context) error {
+	if i.li.roots == nil {
+		return nil
+	}
+	return i.li.roots.refresh(ctx)
+}
+
+// RootAdmin serves an HTTP API for managing the roots accepted by logs at
+// runtime. For the log with a given prefix, it serves:
+//
+//	GET    /admin/v1/logs/<prefix>/roots                lists the accepted roots
+//	POST   /admin/v1/logs/<prefix>/roots                adds a root
+//	DELETE /admin/v1/logs/<prefix>/roots/<fingerprint>  removes a root
+//	GET    /admin/v1/logs/<prefix>/audit                lists the changes made
+//
+// A root is added with a JSON object holding its base64 DER encoding in the
+// "certificate" field, and identified for removal by the hex SHA-256 hash of
+// its DER encoding. A removal takes effect at the RFC 3339 time given by the
+// "remove_after" parameter, if it is in the future.
+//
+// Every request must carry one of the admin's tokens, as in
+// "Authorization: Bearer <token>". The changes are recorded in the root store
+// of the log, along with the user of the token.
+type RootAdmin struct {
+	instance func(prefix string) *Instance
+	users    map[string]string // Keyed by token.
+	now      func() time.Time
+}
+
+// NewRootAdmin returns a RootAdmin for the logs returned by instance, which
+// returns nil for unknown prefixes. The users map is keyed by their tokens.
+func NewRootAdmin(instance func(prefix string) *Instance, users map[string]string) *RootAdmin {
+	return &RootAdmin{instance: instance, users: users, now: time.Now}
+}
+
+// adminRoot describes a root in RootAdmin responses.
+type adminRoot struct {
+	Fingerprint string     `json:"fingerprint"`
+	Subject     string     `json:"subject"`
+	NotAfter    time.Time  `json:"not_after"`
+	Source      string     `json:"source"` // "config" or "admin".
+	RemoveAfter *time.Time `json:"remove_after,omitempty"`
+	Certificate []byte     `json:"certificate"`
+}
+
+func newAdminRoot(e *rootEntry) adminRoot {
+	r := adminRoot{
+		Fingerprint: hex.EncodeToString(e.fingerprint[:]),
+		Subject:     e.cert.Subject.String(),
+		NotAfter:    e.cert.NotAfter,
+		Source:      "admin",
+		Certificate: e.cert.Raw,
+	}
+	if e.fromConfig {
+		r.Source = "config"
+	}
+	if !e.removeAfter.IsZero() {
+		r.RemoveAfter = &e.removeAfter
+	}
+	return r
+}
+
+// adminRootChange describes a change to the roots in RootAdmin responses.
+type adminRootChange struct {
+	Time        time.Time  `json:"time"`
+	User        string     `json:"user"`
+	Action      string     `json:"action"`
+	Fingerprint string     `json:"fingerprint"`
+	RemoveAfter *time.Time `json:"remove_after,omitempty"`
+}
+
+// addRootRequest is the body of requests to add a root.
+type addRootRequest struct {
+	Certificate []byte `json:"certificate"`
+}
+
+// ServeHTTP authenticates the request, and dispatches it by path and method.
+func (a *RootAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := a.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, RootAdminPathPrefix), "/")
+	if !strings.HasPrefix(r.URL.Path, RootAdminPathPrefix) || len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	inst := a.instance(parts[0])
+	if inst == nil || inst.li.roots == nil {
+		http.Error(w, fmt.Sprintf("unknown log: %s", parts[0]), http.StatusNotFound)
+		return
+	}
+	li, ctx := inst.li, r.Context()
+
+	var rsp interface{}
+	var err error
+	switch {
+	case len(parts) == 2 && parts[1] == "roots" && r.Method == http.MethodGet:
+		rsp = a.listRoots(li.roots)
+	case len(parts) == 2 && parts[1] == "roots" && r.Method == http.MethodPost:
+		rsp, err = a.addRoot(ctx, li, user, r)
+	case len(parts) == 3 && parts[1] == "roots" && r.Method == http.MethodDelete:
+		rsp, err = a.removeRoot(ctx, li, user, parts[2], r)
+	case len(parts) == 2 && parts[1] == "audit" && r.Method == http.MethodGet:
+		rsp, err = a.listChanges(ctx, li.roots)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		var reqErr requestError
+		switch {
+		case errors.As(err, &reqErr):
+			code = http.StatusBadRequest
+		case errors.Is(err, errUnknownRoot):
+			code = http.StatusNotFound
+		case errors.Is(err, errRootsNotManaged):
+			code = http.StatusNotImplemented
+		}
+		klog.Warningf("%s: root admin request %s %s by %s failed: %v", li.LogPrefix, r.Method, r.URL.Path, user, err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Header().Set(contentTypeHeader, contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(rsp); err != nil {
+		klog.Warningf("%s: failed to write root admin response: %v", li.LogPrefix, err)
+	}
+}
+
+// requestError is an error caused by an invalid request.
+type requestError struct{ error }
+
+// authenticate returns the user of the token in the request, if it is valid.
+func (a *RootAdmin) authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || len(token) == 0 {
+		return "", false
+	}
+	// Compare with all tokens in constant time, so that the response time
+	// doesn't reveal how close the token is to a valid one.
+	var user string
+	for t, u := range a.users {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			user = u
+		}
+	}
+	return user, len(user) > 0
+}
+
+func (a *RootAdmin) listRoots(roots *rootSet) interface{} {
+	list := []adminRoot{}
+	for _, e := range roots.list(a.now()) {
+		list = append(list, newAdminRoot(e))
+	}
+	return map[string][]adminRoot{"roots": list}
+}
+
+func (a *RootAdmin) addRoot(ctx context.Context, li *logInfo, user string, r *http.Request) (interface{}, error) {
+	var req addRootRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, requestError{fmt.Errorf("failed to parse request: %v", err)}
+	}
+	cert, err := x509.ParseCertificate(req.Certificate)
+	if x509.IsFatal(err) {
+		return nil, requestError{fmt.Errorf("failed to parse certificate: %v", err)}
+	}
+	if !cert.IsCA {
+		return nil, requestError{errors.New("certificate is not a CA certificate")}
+	}
+	e, err := li.roots.add(ctx, user, cert, a.now())
+	if err != nil {
+		return nil, err
+	}
+	klog.Infof("%s: root %x (%s) added by %s", li.LogPrefix, e.fingerprint, cert.Subject, user)
+	return newAdminRoot(e), nil
+}
+
+func (a *RootAdmin) removeRoot(ctx context.Context, li *logInfo, user, fingerprint string, r *http.Request) (interface{}, error) {
+	fp, err := hex.DecodeString(fingerprint)
+	if err != nil {
+		return nil, requestError{fmt.Errorf("invalid fingerprint: %v", err)}
+	}
+	var removeAfter time.Time
+	if s := r.URL.Query().Get("remove_after"); len(s) > 0 {
+		if removeAfter, err = time.Parse(time.RFC3339, s); err != nil {
+			return nil, requestError{fmt.Errorf("invalid remove_after: %v", err)}
+		}
+	}
+	e, err := li.roots.remove(ctx, user, fp, removeAfter, a.now())
+	if err != nil {
+		return nil, err
+	}
+	klog.Infof("%s: root %x (%s) removed by %s, from %v", li.LogPrefix, e.fingerprint, e.cert.Subject, user, e.removeAfter)
+	return newAdminRoot(e), nil
+}
+
+func (a *RootAdmin) listChanges(ctx context.Context, roots *rootSet) (interface{}, error) {
+	changes, err := roots.changes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := []adminRootChange{}
+	for _, c := range changes {
+		ac := adminRootChange{Time: c.Time, User: c.User, Action: string(c.Action), Fingerprint: hex.EncodeToString(c.Fingerprint)}
+		if c.Action == storage.RootRemoved && !c.RemoveAfter.IsZero() {
+			ac.RemoveAfter = &c.RemoveAfter
+		}
+		list = append(list, ac)
+	}
+	return map[string][]adminRootChange{"changes": list}, nil
+}
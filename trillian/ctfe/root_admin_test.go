@@ -0,0 +1,248 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	cttestonly "github.com/google/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/google/certificate-transparency-go/trillian/util"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+)
+
+func certOrDie(t *testing.T, pem string) *x509.Certificate {
+	t.Helper()
+	cert, err := x509util.CertificateFromPEM([]byte(pem))
+	if x509.IsFatal(err) {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func fingerprint(cert *x509.Certificate) []byte {
+	fp := sha256.Sum256(cert.Raw)
+	return fp[:]
+}
+
+func TestRootSet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	root, added := certOrDie(t, cttestonly.FakeCACertPEM), certOrDie(t, cttestonly.FakeIntermediateCertPEM)
+	config := x509util.NewPEMCertPool()
+	config.AddCert(root)
+
+	accepts := func(s *rootSet, at time.Time, want ...*x509.Certificate) {
+		t.Helper()
+		pool := s.pool(at)
+		if got := len(pool.RawCertificates()); got != len(want) {
+			t.Errorf("pool(%v) has %d roots, want %d", at, got, len(want))
+		}
+		for _, cert := range want {
+			if !pool.Included(cert) {
+				t.Errorf("pool(%v) lacks %s", at, cert.Subject)
+			}
+		}
+	}
+
+	fixed, err := newRootSet(ctx, 1, config, nil)
+	if err != nil {
+		t.Fatalf("newRootSet(): %v", err)
+	}
+	accepts(fixed, now, root)
+	if _, err := fixed.add(ctx, "alice", added, now); err != errRootsNotManaged {
+		t.Errorf("add() without store=%v, want %v", err, errRootsNotManaged)
+	}
+
+	store := storage.NewMemoryRootStore()
+	s, err := newRootSet(ctx, 1, config, store)
+	if err != nil {
+		t.Fatalf("newRootSet(): %v", err)
+	}
+	if _, err := s.add(ctx, "alice", added, now); err != nil {
+		t.Fatalf("add(): %v", err)
+	}
+	accepts(s, now, root, added)
+
+	// The config root stops being accepted in an hour.
+	if _, err := s.remove(ctx, "bob", fingerprint(root), now.Add(time.Hour), now); err != nil {
+		t.Fatalf("remove(): %v", err)
+	}
+	accepts(s, now.Add(time.Hour-time.Second), root, added)
+	accepts(s, now.Add(time.Hour), added)
+	if _, err := s.remove(ctx, "bob", fingerprint(root), time.Time{}, now.Add(time.Hour)); err != errUnknownRoot {
+		t.Errorf("remove(removed root)=%v, want %v", err, errUnknownRoot)
+	}
+
+	// Another instance sharing the store sees the changes, and the added root
+	// is removed immediately.
+	other, err := newRootSet(ctx, 1, config, store)
+	if err != nil {
+		t.Fatalf("newRootSet(): %v", err)
+	}
+	accepts(other, now.Add(time.Hour), added)
+	if _, err := other.remove(ctx, "carol", fingerprint(added), time.Time{}, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("remove(): %v", err)
+	}
+	accepts(s, now.Add(2*time.Hour), added)
+	if err := s.refresh(ctx); err != nil {
+		t.Fatalf("refresh(): %v", err)
+	}
+	accepts(s, now.Add(2*time.Hour))
+
+	// Adding the root again cancels its removal.
+	if _, err := s.add(ctx, "alice", root, now.Add(3*time.Hour)); err != nil {
+		t.Fatalf("add(): %v", err)
+	}
+	accepts(s, now.Add(4*time.Hour), root)
+
+	// The roots of other logs are not affected.
+	unrelated, err := newRootSet(ctx, 2, config, store)
+	if err != nil {
+		t.Fatalf("newRootSet(): %v", err)
+	}
+	accepts(unrelated, now.Add(4*time.Hour), root)
+}
+
+func TestRootAdmin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+	root, added, leaf := certOrDie(t, cttestonly.FakeCACertPEM), certOrDie(t, cttestonly.FakeIntermediateCertPEM), certOrDie(t, cttestonly.LeafSignedByFakeIntermediateCertPEM)
+	config := x509util.NewPEMCertPool()
+	config.AddCert(root)
+
+	newInstance := func(store storage.RootStore) *Instance {
+		roots, err := newRootSet(ctx, 1, config, store)
+		if err != nil {
+			t.Fatalf("newRootSet(): %v", err)
+		}
+		return &Instance{li: &logInfo{LogPrefix: "test{1}", TimeSource: util.NewFixedTimeSource(now), roots: roots}}
+	}
+	instances := map[string]*Instance{
+		"test":  newInstance(storage.NewMemoryRootStore()),
+		"fixed": newInstance(nil),
+	}
+	admin := NewRootAdmin(func(prefix string) *Instance { return instances[prefix] }, map[string]string{"secret": "alice"})
+	admin.now = func() time.Time { return now }
+
+	do := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		var data []byte
+		if body != nil {
+			var err error
+			if data, err = json.Marshal(body); err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, req)
+		return w
+	}
+	listRoots := func() []adminRoot {
+		t.Helper()
+		w := do(http.MethodGet, "/admin/v1/logs/test/roots", "secret", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET roots=%d (%s), want 200", w.Code, w.Body)
+		}
+		var rsp struct{ Roots []adminRoot }
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return rsp.Roots
+	}
+
+	for _, tc := range []struct {
+		desc   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		want   int
+	}{
+		{desc: "no-token", method: http.MethodGet, path: "/admin/v1/logs/test/roots", want: http.StatusUnauthorized},
+		{desc: "bad-token", method: http.MethodGet, path: "/admin/v1/logs/test/roots", token: "guess", want: http.StatusUnauthorized},
+		{desc: "unknown-log", method: http.MethodGet, path: "/admin/v1/logs/other/roots", token: "secret", want: http.StatusNotFound},
+		{desc: "unknown-path", method: http.MethodGet, path: "/admin/v1/logs/test/leaves", token: "secret", want: http.StatusNotFound},
+		{desc: "not-ca", method: http.MethodPost, path: "/admin/v1/logs/test/roots", token: "secret", body: addRootRequest{Certificate: leaf.Raw}, want: http.StatusBadRequest},
+		{desc: "not-cert", method: http.MethodPost, path: "/admin/v1/logs/test/roots", token: "secret", body: addRootRequest{Certificate: []byte("root")}, want: http.StatusBadRequest},
+		{desc: "bad-fingerprint", method: http.MethodDelete, path: "/admin/v1/logs/test/roots/xyz", token: "secret", want: http.StatusBadRequest},
+		{desc: "unknown-root", method: http.MethodDelete, path: "/admin/v1/logs/test/roots/" + hex.EncodeToString(fingerprint(added)), token: "secret", want: http.StatusNotFound},
+		{desc: "no-store", method: http.MethodPost, path: "/admin/v1/logs/fixed/roots", token: "secret", body: addRootRequest{Certificate: added.Raw}, want: http.StatusNotImplemented},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if w := do(tc.method, tc.path, tc.token, tc.body); w.Code != tc.want {
+				t.Errorf("%s %s=%d (%s), want %d", tc.method, tc.path, w.Code, w.Body, tc.want)
+			}
+		})
+	}
+
+	if got := listRoots(); len(got) != 1 || got[0].Source != "config" {
+		t.Fatalf("Got roots %+v, want the config root", got)
+	}
+	if w := do(http.MethodPost, "/admin/v1/logs/test/roots", "secret", addRootRequest{Certificate: added.Raw}); w.Code != http.StatusOK {
+		t.Fatalf("POST roots=%d (%s), want 200", w.Code, w.Body)
+	}
+	if !instances["test"].li.trustedRoots().Included(added) {
+		t.Error("Added root not accepted")
+	}
+	removeAfter := now.Add(24 * time.Hour)
+	path := "/admin/v1/logs/test/roots/" + hex.EncodeToString(fingerprint(root)) + "?remove_after=" + removeAfter.Format(time.RFC3339)
+	if w := do(http.MethodDelete, path, "secret", nil); w.Code != http.StatusOK {
+		t.Fatalf("DELETE root=%d (%s), want 200", w.Code, w.Body)
+	}
+	got := listRoots()
+	if len(got) != 2 {
+		t.Fatalf("Got %d roots, want 2", len(got))
+	}
+	if got[0].RemoveAfter == nil || !got[0].RemoveAfter.Equal(removeAfter) {
+		t.Errorf("Got config root removal at %v, want %v", got[0].RemoveAfter, removeAfter)
+	}
+	if got[1].Source != "admin" || !bytes.Equal(got[1].Certificate, added.Raw) {
+		t.Errorf("Got root %+v, want the added root", got[1])
+	}
+
+	w := do(http.MethodGet, "/admin/v1/logs/test/audit", "secret", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET audit=%d (%s), want 200", w.Code, w.Body)
+	}
+	var audit struct{ Changes []adminRootChange }
+	if err := json.Unmarshal(w.Body.Bytes(), &audit); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(audit.Changes) != 2 {
+		t.Fatalf("Got %d changes, want 2", len(audit.Changes))
+	}
+	for i, want := range []adminRootChange{
+		{User: "alice", Action: "add", Fingerprint: hex.EncodeToString(fingerprint(added))},
+		{User: "alice", Action: "remove", Fingerprint: hex.EncodeToString(fingerprint(root))},
+	} {
+		if c := audit.Changes[i]; c.User != want.User || c.Action != want.Action || c.Fingerprint != want.Fingerprint || !c.Time.Equal(now) {
+			t.Errorf("Change %d is %+v, want %+v at %v", i, c, want, now)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/certificate-transparency-go/trillian/ctfe/storage"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+)
+
+var (
+	// errRootsNotManaged is returned for changes to the roots of a log that
+	// has no root store.
+	errRootsNotManaged = errors.New("roots cannot be changed at runtime without a root store")
+	// errUnknownRoot is returned for the removal of a root that the log does
+	// not accept.
+	errUnknownRoot = errors.New("unknown root")
+)
+
+// rootEntry is a root accepted by a log, possibly until a given time.
+type rootEntry struct {
+	cert        *x509.Certificate
+	fingerprint [sha256.Size]byte
+	// fromConfig is whether the root is in the log's roots_pem_file.
+	fromConfig bool
+	// removeAfter is when the log stops accepting the root; zero means never.
+	removeAfter time.Time
+}
+
+// acceptedAt returns whether the log accepts the root at the given time.
+func (e *rootEntry) acceptedAt(now time.Time) bool {
+	return e.removeAfter.IsZero() || now.Before(e.removeAfter)
+}
+
+// rootState is the roots of a log after the changes made so far.
+type rootState struct {
+	entries []*rootEntry // In the order they were first added.
+	// pool holds the roots accepted from poolTime until poolExpiry, when
+	// the next scheduled removal takes effect. A zero poolExpiry means
+	// none is scheduled.
+	pool       *x509util.PEMCertPool
+	poolTime   time.Time
+	poolExpiry time.Time
+}
+
+// rootSet holds the roots accepted by a log: those of its config, with the
+// changes made at runtime replayed on top from the trail in its root store.
+type rootSet struct {
+	logID  int64
+	config *x509util.PEMCertPool
+	store  storage.RootStore // nil if the roots can't be changed.
+
+	mu    sync.Mutex // Serializes changes and refreshes.
+	state atomic.Pointer[rootState]
+}
+
+// newRootSet returns the roots of a log, given those of its config and its
+// root store, which may be nil.
+func newRootSet(ctx context.Context, logID int64, config *x509util.PEMCertPool, store storage.RootStore) (*rootSet, error) {
+	s := &rootSet{logID: logID, config: config, store: store}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refresh rebuilds the roots from the trail in the root store, which may have
+// been changed by other CTFE instances.
+func (s *rootSet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshLocked(ctx)
+}
+
+func (s *rootSet) refreshLocked(ctx context.Context) error {
+	var changes []*storage.RootChange
+	if s.store != nil {
+		var err error
+		if changes, err = s.store.Changes(ctx, s.logID); err != nil {
+			return fmt.Errorf("failed to read root changes: %v", err)
+		}
+	}
+	entries, err := replayRootChanges(s.config, changes)
+	if err != nil {
+		return err
+	}
+	s.state.Store(&rootState{entries: entries})
+	return nil
+}
+
+// replayRootChanges returns the roots of a log after the given changes to the
+// roots of its config.
+func replayRootChanges(config *x509util.PEMCertPool, changes []*storage.RootChange) ([]*rootEntry, error) {
+	var entries []*rootEntry
+	byFingerprint := make(map[[sha256.Size]byte]*rootEntry)
+	add := func(cert *x509.Certificate, fromConfig bool) *rootEntry {
+		fp := sha256.Sum256(cert.Raw)
+		e, ok := byFingerprint[fp]
+		if !ok {
+			e = &rootEntry{cert: cert, fingerprint: fp, fromConfig: fromConfig}
+			byFingerprint[fp] = e
+			entries = append(entries, e)
+		}
+		return e
+	}
+	for _, cert := range config.RawCertificates() {
+		add(cert, true)
+	}
+
+	for i, c := range changes {
+		switch c.Action {
+		case storage.RootAdded:
+			cert, err := x509.ParseCertificate(c.Certificate)
+			if x509.IsFatal(err) {
+				return nil, fmt.Errorf("root change %d: failed to parse certificate: %v", i, err)
+			}
+			add(cert, false).removeAfter = time.Time{}
+		case storage.RootRemoved:
+			var fp [sha256.Size]byte
+			copy(fp[:], c.Fingerprint)
+			e, ok := byFingerprint[fp]
+			if !ok {
+				// The root was added through the config, which no longer has it.
+				continue
+			}
+			e.removeAfter = c.RemoveAfter
+			if e.removeAfter.IsZero() || e.removeAfter.Before(c.Time) {
+				e.removeAfter = c.Time
+			}
+		default:
+			return nil, fmt.Errorf("root change %d: unknown action %q", i, c.Action)
+		}
+	}
+	return entries, nil
+}
+
+// pool returns the roots accepted at the given time.
+func (s *rootSet) pool(now time.Time) *x509util.PEMCertPool {
+	for {
+		st := s.state.Load()
+		if st.pool != nil && !now.Before(st.poolTime) && (st.poolExpiry.IsZero() || now.Before(st.poolExpiry)) {
+			return st.pool
+		}
+		next := &rootState{entries: st.entries, pool: x509util.NewPEMCertPool(), poolTime: now}
+		for _, e := range st.entries {
+			if !e.acceptedAt(now) {
+				continue
+			}
+			next.pool.AddCert(e.cert)
+			if ra := e.removeAfter; !ra.IsZero() && (next.poolExpiry.IsZero() || ra.Before(next.poolExpiry)) {
+				next.poolExpiry = ra
+			}
+		}
+		// Retry if the roots changed in the meantime.
+		if s.state.CompareAndSwap(st, next) {
+			return next.pool
+		}
+	}
+}
+
+// list returns the roots accepted at the given time, including those that
+// are scheduled for removal.
+func (s *rootSet) list(now time.Time) []*rootEntry {
+	var entries []*rootEntry
+	for _, e := range s.state.Load().entries {
+		if e.acceptedAt(now) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// find returns the root with the given fingerprint, if it is accepted at the
+// given time.
+func (s *rootSet) find(fingerprint []byte, now time.Time) *rootEntry {
+	for _, e := range s.list(now) {
+		if bytes.Equal(e.fingerprint[:], fingerprint) {
+			return e
+		}
+	}
+	return nil
+}
+
+// add records the addition of a root by the given user, and returns it.
+func (s *rootSet) add(ctx context.Context, user string, cert *x509.Certificate, now time.Time) (*rootEntry, error) {
+	fp := sha256.Sum256(cert.Raw)
+	err := s.change(ctx, &storage.RootChange{
+		LogID:       s.logID,
+		Time:        now,
+		User:        user,
+		Action:      storage.RootAdded,
+		Fingerprint: fp[:],
+		Certificate: cert.Raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.find(fp[:], now), nil
+}
+
+// remove records the removal of a root by the given user, effective at
+// removeAfter, or now if that is earlier, and returns the root.
+func (s *rootSet) remove(ctx context.Context, user string, fingerprint []byte, removeAfter, now time.Time) (*rootEntry, error) {
+	e := s.find(fingerprint, now)
+	if e == nil {
+		return nil, errUnknownRoot
+	}
+	if removeAfter.Before(now) {
+		removeAfter = now
+	}
+	err := s.change(ctx, &storage.RootChange{
+		LogID:       s.logID,
+		Time:        now,
+		User:        user,
+		Action:      storage.RootRemoved,
+		Fingerprint: e.fingerprint[:],
+		RemoveAfter: removeAfter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rootEntry{cert: e.cert, fingerprint: e.fingerprint, fromConfig: e.fromConfig, removeAfter: removeAfter}, nil
+}
+
+// change appends c to the trail in the root store, then rebuilds the roots
+// from it.
+func (s *rootSet) change(ctx context.Context, c *storage.RootChange) error {
+	if s.store == nil {
+		return errRootsNotManaged
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Append(ctx, c); err != nil {
+		return fmt.Errorf("failed to record root change: %v", err)
+	}
+	return s.refreshLocked(ctx)
+}
+
+// changes returns the trail of changes to the roots.
+func (s *rootSet) changes(ctx context.Context) ([]*storage.RootChange, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	return s.store.Changes(ctx, s.logID)
+}
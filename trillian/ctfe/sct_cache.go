@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"k8s.io/klog/v2"
+)
+
+// sctCacheKey returns the key of the SCTs cached for a submission: the log ID,
+// the entry type, and the leaf identity hash, i.e. the SHA-256 hash of the
+// submitted (pre-)certificate.
+func sctCacheKey(logID int64, isPrecert bool, leafDER []byte) []byte {
+	key := make([]byte, 9, 9+sha256.Size)
+	binary.BigEndian.PutUint64(key, uint64(logID))
+	if isPrecert {
+		key[8] = 1
+	}
+	hash := sha256.Sum256(leafDER)
+	return append(key, hash[:]...)
+}
+
+// cachedSCT returns the SCT cached for the submission in req, or nil if there
+// is none. The chain of req must have been verified: like the backend, which
+// would return the original leaf for it, the cache only stands in for
+// QueueLeaf. Frozen logs never use the cache, so that they issue no SCTs.
+func (li *logInfo) cachedSCT(ctx context.Context, method EntrypointName, req ct.AddChainRequest, isPrecert bool) *ct.SignedCertificateTimestamp {
+	c := li.instanceOpts.SCTCache
+	if c == nil || len(req.Chain) == 0 || li.instanceOpts.Validated.FrozenSTH != nil {
+		return nil
+	}
+	logID, ep := strconv.FormatInt(li.logID, 10), string(method)
+	data, ok, err := c.Get(ctx, sctCacheKey(li.logID, isPrecert, req.Chain[0]))
+	if err != nil {
+		klog.Warningf("%s: failed to look up SCT cache: %v", li.LogPrefix, err)
+		sctCacheLookups.Inc(logID, ep, "error")
+		return nil
+	}
+	if !ok {
+		sctCacheLookups.Inc(logID, ep, "miss")
+		return nil
+	}
+	var sct ct.SignedCertificateTimestamp
+	if rest, err := tls.Unmarshal(data, &sct); err != nil || len(rest) > 0 {
+		klog.Warningf("%s: failed to parse cached SCT (%d bytes left): %v", li.LogPrefix, len(rest), err)
+		sctCacheLookups.Inc(logID, ep, "error")
+		return nil
+	}
+	sctCacheLookups.Inc(logID, ep, "hit")
+	li.RequestLog.IssueSCT(ctx, data)
+	return &sct
+}
+
+// cacheSCT caches the SCT issued for the submission in req.
+func (li *logInfo) cacheSCT(ctx context.Context, req ct.AddChainRequest, isPrecert bool, sct []byte) {
+	c := li.instanceOpts.SCTCache
+	if c == nil || len(req.Chain) == 0 {
+		return
+	}
+	if err := c.Put(ctx, sctCacheKey(li.logID, isPrecert, req.Chain[0]), sct); err != nil {
+		klog.Warningf("%s: failed to cache SCT: %v", li.LogPrefix, err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctfe
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/trillian/ctfe/cache"
+	cttestonly "github.com/google/certificate-transparency-go/trillian/ctfe/testonly"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAddChainSCTCache(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	info.li.instanceOpts.SCTCache = cache.NewLRUCache(10, time.Hour)
+
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	merkleLeaf, err := ct.MerkleTreeLeafFromChain(pool.RawCertificates(), ct.X509LogEntryType, fakeTimeMillis)
+	if err != nil {
+		t.Fatalf("MerkleTreeLeafFromChain: %v", err)
+	}
+	leafChain := append([]*x509.Certificate{}, pool.RawCertificates()...)
+	leafChain = append(leafChain, info.roots.RawCertificates()[0])
+	leaf := logLeafForCert(t, leafChain, merkleLeaf, false)
+	rsp := trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: leaf, Status: status.New(codes.OK, "ok").Proto()}}
+	// Only the first submission reaches the backend.
+	info.client.EXPECT().QueueLeaf(deadlineMatcher(), cmpMatcher{&trillian.QueueLeafRequest{LogId: 0x42, Leaf: leaf}}).Return(&rsp, nil).Times(1)
+
+	var bodies [][]byte
+	for i := 0; i < 3; i++ {
+		recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("addChain()=%d (body:%v); want %d", recorder.Code, recorder.Body, http.StatusOK)
+		}
+		bodies = append(bodies, recorder.Body.Bytes())
+	}
+	for i, body := range bodies[1:] {
+		if !bytes.Equal(body, bodies[0]) {
+			t.Errorf("Duplicate submission %d got response %s, want %s", i+1, body, bodies[0])
+		}
+	}
+
+	// A cached leaf is still verified, so it can't be submitted without its
+	// chain.
+	leafOnly := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM})
+	if recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *leafOnly)); recorder.Code != http.StatusBadRequest {
+		t.Errorf("addChain(leaf only)=%d (body:%v); want %d", recorder.Code, recorder.Body, http.StatusBadRequest)
+	}
+
+	// The same certificate submitted as a precertificate is not a duplicate.
+	recorder := makeAddPrechainRequest(t, info.li, createJSONChain(t, *pool))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("addPrechain()=%d (body:%v); want %d", recorder.Code, recorder.Body, http.StatusBadRequest)
+	}
+}
+
+func TestAddChainSCTCacheFrozen(t *testing.T) {
+	signer, err := setupSigner(fakeSignature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer: %v", err)
+	}
+	info := setupTest(t, []string{cttestonly.FakeCACertPEM}, signer)
+	defer info.mockCtrl.Finish()
+	info.li.instanceOpts.SCTCache = cache.NewLRUCache(10, time.Hour)
+	info.li.instanceOpts.Validated.FrozenSTH = &ct.SignedTreeHead{TreeSize: 1}
+
+	pool := loadCertsIntoPoolOrDie(t, []string{cttestonly.LeafSignedByFakeIntermediateCertPEM, cttestonly.FakeIntermediateCertPEM})
+	sct, err := tls.Marshal(ct.SignedCertificateTimestamp{SCTVersion: ct.V1, Timestamp: fakeTimeMillis})
+	if err != nil {
+		t.Fatalf("Failed to marshal SCT: %v", err)
+	}
+	info.li.cacheSCT(context.Background(), ct.AddChainRequest{Chain: [][]byte{pool.RawCertificates()[0].Raw}}, false, sct)
+	// The submission reaches the backend, which rejects it.
+	info.client.EXPECT().QueueLeaf(deadlineMatcher(), gomock.Any()).Return(nil, status.Error(codes.FailedPrecondition, "tree is frozen")).Times(1)
+
+	if recorder := makeAddChainRequest(t, info.li, createJSONChain(t, *pool)); recorder.Code == http.StatusOK {
+		t.Errorf("addChain()=%d (body:%v) for frozen log; want failure", recorder.Code, recorder.Body)
+	}
+}
+
+func TestSCTCacheKey(t *testing.T) {
+	der := []byte("certificate")
+	key := sctCacheKey(0x42, false, der)
+	for _, other := range [][]byte{
+		sctCacheKey(0x43, false, der),
+		sctCacheKey(0x42, true, der),
+		sctCacheKey(0x42, false, []byte("other certificate")),
+	} {
+		if bytes.Equal(key, other) {
+			t.Errorf("sctCacheKey()=%x for different submissions", key)
+		}
+	}
+}
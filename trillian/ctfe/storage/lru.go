@@ -0,0 +1,113 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// LRUIssuanceChainStorage is an IssuanceChainStorage that caches the most
+// recently used chains of another IssuanceChainStorage in memory. As chains
+// are immutable, cached chains never go stale.
+type LRUIssuanceChainStorage struct {
+	s    IssuanceChainStorage
+	size int
+
+	mu    sync.Mutex
+	order *list.List               // Of *lruEntry, most recently used first.
+	items map[string]*list.Element // Keyed by the chain's key.
+}
+
+type lruEntry struct {
+	key   string
+	chain []byte
+}
+
+// NewLRUIssuanceChainStorage returns an LRUIssuanceChainStorage that caches up
+// to size chains of s.
+func NewLRUIssuanceChainStorage(s IssuanceChainStorage, size int) *LRUIssuanceChainStorage {
+	return &LRUIssuanceChainStorage{
+		s:     s,
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// FindByKey returns the chain stored under key, from the cache if possible.
+func (c *LRUIssuanceChainStorage) FindByKey(ctx context.Context, key []byte) ([]byte, error) {
+	if chain, ok := c.get(key); ok {
+		return chain, nil
+	}
+	chain, err := c.s.FindByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, chain)
+	return chain, nil
+}
+
+// Add stores chain under key in the underlying storage, unless it is already
+// stored there. Chains known to be stored are cached, so that adding them
+// again is cheap.
+func (c *LRUIssuanceChainStorage) Add(ctx context.Context, key []byte, chain []byte) error {
+	_, err := c.FindByKey(ctx, key)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := c.s.Add(ctx, key, chain); err != nil {
+		return err
+	}
+	c.put(key, chain)
+	return nil
+}
+
+// get returns the cached chain with the given key, and marks it as most
+// recently used.
+func (c *LRUIssuanceChainStorage) get(key []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[string(key)]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).chain, true
+}
+
+// put caches chain under key, evicting the least recently used chain if the
+// cache is full.
+func (c *LRUIssuanceChainStorage) put(key []byte, chain []byte) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[string(key)]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[string(key)] = c.order.PushFront(&lruEntry{key: string(key), chain: chain})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
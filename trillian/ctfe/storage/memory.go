@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryIssuanceChainStorage is an IssuanceChainStorage that keeps chains in
+// memory. As the chains are lost when the process exits, while the entries
+// referring to them are not, it is only suitable for testing.
+type MemoryIssuanceChainStorage struct {
+	mu     sync.RWMutex
+	chains map[string][]byte
+}
+
+// NewMemoryIssuanceChainStorage returns an empty MemoryIssuanceChainStorage.
+func NewMemoryIssuanceChainStorage() *MemoryIssuanceChainStorage {
+	return &MemoryIssuanceChainStorage{chains: make(map[string][]byte)}
+}
+
+// FindByKey returns the chain stored under key, or ErrNotFound.
+func (s *MemoryIssuanceChainStorage) FindByKey(_ context.Context, key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chain, ok := s.chains[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return chain, nil
+}
+
+// Add stores chain under key, unless a chain is already stored under it.
+func (s *MemoryIssuanceChainStorage) Add(_ context.Context, key []byte, chain []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chains[string(key)]; !ok {
+		s.chains[string(key)] = append([]byte(nil), chain...)
+	}
+	return nil
+}
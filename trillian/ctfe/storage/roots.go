@@ -0,0 +1,191 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RootAction is the kind of a change to the roots accepted by a log.
+type RootAction string
+
+const (
+	// RootAdded makes the log accept a root, and cancels any removal of it.
+	RootAdded RootAction = "add"
+	// RootRemoved makes the log stop accepting a root, either when the change
+	// is made or at a later time.
+	RootRemoved RootAction = "remove"
+)
+
+// RootChange is a change to the roots accepted by a log, made at runtime
+// rather than through its config.
+type RootChange struct {
+	// LogID is the tree ID of the log.
+	LogID int64
+	// Time is when the change was made.
+	Time time.Time
+	// User identifies who made the change.
+	User string
+	// Action is the kind of change.
+	Action RootAction
+	// Fingerprint is the SHA-256 hash of the root's DER encoding.
+	Fingerprint []byte
+	// Certificate is the DER encoding of the root, for RootAdded changes.
+	Certificate []byte
+	// RemoveAfter, for RootRemoved changes, is when the log stops accepting
+	// the root. If zero, it stops at Time.
+	RemoveAfter time.Time
+}
+
+// RootStore is an append-only audit trail of changes to the roots of logs,
+// from which the CTFE rebuilds the roots that each log accepts.
+type RootStore interface {
+	// Append adds a change to the end of the trail of its log.
+	Append(ctx context.Context, c *RootChange) error
+	// Changes returns the trail of a log, in the order the changes were
+	// appended.
+	Changes(ctx context.Context, logID int64) ([]*RootChange, error)
+}
+
+// OpenRootStore opens the root store described by a connection string, of
+// the same form as for OpenIssuanceChainStorage. The database must hold the
+// RootChange table from Schema.
+func OpenRootStore(ctx context.Context, conn string) (RootStore, error) {
+	driver, dsn, ok := strings.Cut(conn, "://")
+	if !ok || driver == "" {
+		return nil, fmt.Errorf("connection string %q is not of the form driver://data-source-name", conn)
+	}
+	if driver == "memory" {
+		return NewMemoryRootStore(), nil
+	}
+	db, err := openDB(ctx, driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLRootStore(db), nil
+}
+
+// MemoryRootStore is a RootStore that keeps changes in memory. As they are
+// lost when the process exits, it is only suitable for testing.
+type MemoryRootStore struct {
+	mu      sync.RWMutex
+	changes map[int64][]*RootChange
+}
+
+// NewMemoryRootStore returns an empty MemoryRootStore.
+func NewMemoryRootStore() *MemoryRootStore {
+	return &MemoryRootStore{changes: make(map[int64][]*RootChange)}
+}
+
+// Append adds a copy of c to the end of the trail of its log.
+func (s *MemoryRootStore) Append(_ context.Context, c *RootChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cc := *c
+	s.changes[c.LogID] = append(s.changes[c.LogID], &cc)
+	return nil
+}
+
+// Changes returns copies of the changes to the roots of a log.
+func (s *MemoryRootStore) Changes(_ context.Context, logID int64) ([]*RootChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	changes := make([]*RootChange, 0, len(s.changes[logID]))
+	for _, c := range s.changes[logID] {
+		cc := *c
+		changes = append(changes, &cc)
+	}
+	return changes, nil
+}
+
+const (
+	selectRootChangesSQL   = "SELECT ChangeTime, UserName, Action, Fingerprint, Certificate, RemoveAfter FROM RootChange WHERE LogID = ? ORDER BY Seq"
+	selectRootChangeSeqSQL = "SELECT COALESCE(MAX(Seq), -1) + 1 FROM RootChange WHERE LogID = ?"
+	insertRootChangeSQL    = "INSERT INTO RootChange (LogID, Seq, ChangeTime, UserName, Action, Fingerprint, Certificate, RemoveAfter) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+)
+
+// SQLRootStore is a RootStore in the RootChange table of an SQL database, as
+// created by Schema. Times are stored as nanoseconds since the Unix epoch.
+type SQLRootStore struct {
+	db *sql.DB
+}
+
+// NewSQLRootStore returns an SQLRootStore using db.
+func NewSQLRootStore(db *sql.DB) *SQLRootStore {
+	return &SQLRootStore{db: db}
+}
+
+// Append adds c to the end of the trail of its log. It fails if another
+// change to the log is appended concurrently, as both would take the same
+// position in the trail.
+func (s *SQLRootStore) Append(ctx context.Context, c *RootChange) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	var seq int64
+	if err := tx.QueryRowContext(ctx, selectRootChangeSeqSQL, c.LogID).Scan(&seq); err != nil {
+		return fmt.Errorf("failed to read root changes: %v", err)
+	}
+	var removeAfter int64
+	if !c.RemoveAfter.IsZero() {
+		removeAfter = c.RemoveAfter.UnixNano()
+	}
+	if _, err := tx.ExecContext(ctx, insertRootChangeSQL, c.LogID, seq, c.Time.UnixNano(), c.User, string(c.Action), c.Fingerprint, c.Certificate, removeAfter); err != nil {
+		return fmt.Errorf("failed to write root change: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit root change: %v", err)
+	}
+	return nil
+}
+
+// Changes returns the changes to the roots of a log.
+func (s *SQLRootStore) Changes(ctx context.Context, logID int64) ([]*RootChange, error) {
+	rows, err := s.db.QueryContext(ctx, selectRootChangesSQL, logID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root changes: %v", err)
+	}
+	defer rows.Close()
+	var changes []*RootChange
+	for rows.Next() {
+		c := &RootChange{LogID: logID}
+		var changeTime, removeAfter int64
+		var action string
+		if err := rows.Scan(&changeTime, &c.User, &action, &c.Fingerprint, &c.Certificate, &removeAfter); err != nil {
+			return nil, fmt.Errorf("failed to read root change: %v", err)
+		}
+		c.Time, c.Action = time.Unix(0, changeTime), RootAction(action)
+		if removeAfter != 0 {
+			c.RemoveAfter = time.Unix(0, removeAfter)
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read root changes: %v", err)
+	}
+	return changes, nil
+}
+
+// Close closes the database.
+func (s *SQLRootStore) Close() error {
+	return s.db.Close()
+}
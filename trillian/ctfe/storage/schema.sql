@@ -0,0 +1,24 @@
+-- CTFE storage tables, for use with SQLIssuanceChainStorage and SQLRootStore.
+
+-- IssuanceChain holds each distinct issuance chain once, keyed by the SHA-256
+-- hash of its TLS encoding.
+CREATE TABLE IF NOT EXISTS IssuanceChain (
+  IdentityHash BINARY(32) NOT NULL,
+  ChainValue   LONGBLOB NOT NULL,
+  PRIMARY KEY (IdentityHash)
+);
+
+-- RootChange holds the audit trail of changes made at runtime to the roots
+-- accepted by each log, numbered by Seq in the order they were made. Times are
+-- in nanoseconds since the Unix epoch, and a zero RemoveAfter means none.
+CREATE TABLE IF NOT EXISTS RootChange (
+  LogID       BIGINT NOT NULL,
+  Seq         BIGINT NOT NULL,
+  ChangeTime  BIGINT NOT NULL,
+  UserName    VARCHAR(255) NOT NULL,
+  Action      VARCHAR(16) NOT NULL,
+  Fingerprint BINARY(32) NOT NULL,
+  Certificate LONGBLOB,
+  RemoveAfter BIGINT NOT NULL,
+  PRIMARY KEY (LogID, Seq)
+);
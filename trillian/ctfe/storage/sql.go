@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	selectIssuanceChainSQL = "SELECT ChainValue FROM IssuanceChain WHERE IdentityHash = ?"
+	insertIssuanceChainSQL = "INSERT INTO IssuanceChain (IdentityHash, ChainValue) VALUES (?, ?)"
+)
+
+// SQLIssuanceChainStorage is an IssuanceChainStorage in the IssuanceChain
+// table of an SQL database, as created by Schema. The queries use "?"
+// placeholders, as accepted by e.g. MySQL and SQLite drivers.
+type SQLIssuanceChainStorage struct {
+	db *sql.DB
+}
+
+// NewSQLIssuanceChainStorage returns an SQLIssuanceChainStorage using db.
+func NewSQLIssuanceChainStorage(db *sql.DB) *SQLIssuanceChainStorage {
+	return &SQLIssuanceChainStorage{db: db}
+}
+
+// FindByKey returns the chain stored under key, or ErrNotFound.
+func (s *SQLIssuanceChainStorage) FindByKey(ctx context.Context, key []byte) ([]byte, error) {
+	var chain []byte
+	err := s.db.QueryRowContext(ctx, selectIssuanceChainSQL, key).Scan(&chain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read issuance chain: %v", err)
+	}
+	return chain, nil
+}
+
+// Add stores chain under key, unless a chain is already stored under it.
+func (s *SQLIssuanceChainStorage) Add(ctx context.Context, key []byte, chain []byte) error {
+	_, err := s.db.ExecContext(ctx, insertIssuanceChainSQL, key, chain)
+	if err == nil {
+		return nil
+	}
+	// The insert fails if the key is taken, e.g. by a concurrent request
+	// adding the same chain. Which error is returned depends on the driver, so
+	// look for the chain instead.
+	if _, findErr := s.FindByKey(ctx, key); findErr == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to write issuance chain: %v", err)
+}
+
+// Close closes the database.
+func (s *SQLIssuanceChainStorage) Close() error {
+	return s.db.Close()
+}
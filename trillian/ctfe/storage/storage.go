@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides storage for the CTFE, which holds data that the
+// CTFE keeps outside of Trillian, such as deduplicated issuance chains.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	_ "embed" // For the schema.
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when the requested data is not in storage.
+var ErrNotFound = errors.New("not found")
+
+// Schema holds the SQL statements that create the tables used by
+// SQLIssuanceChainStorage and SQLRootStore.
+//
+//go:embed schema.sql
+var Schema string
+
+// IssuanceChainStorage stores issuance chains, i.e. the intermediate
+// certificates of submitted chains, keyed by their hash.
+type IssuanceChainStorage interface {
+	// FindByKey returns the chain stored under key, or ErrNotFound.
+	FindByKey(ctx context.Context, key []byte) ([]byte, error)
+	// Add stores chain under key. Adding a chain that is already stored
+	// succeeds without changing it.
+	Add(ctx context.Context, key []byte, chain []byte) error
+}
+
+// OpenIssuanceChainStorage opens the issuance chain storage described by a
+// connection string of the form "driver://data-source-name". The driver is
+// the name of a database/sql driver, which must be registered by the binary,
+// and the data source name is passed to it. The special connection string
+// "memory://" returns an empty in-memory storage.
+func OpenIssuanceChainStorage(ctx context.Context, conn string) (IssuanceChainStorage, error) {
+	driver, dsn, ok := strings.Cut(conn, "://")
+	if !ok || driver == "" {
+		return nil, fmt.Errorf("connection string %q is not of the form driver://data-source-name", conn)
+	}
+	if driver == "memory" {
+		return NewMemoryIssuanceChainStorage(), nil
+	}
+	db, err := openDB(ctx, driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLIssuanceChainStorage(db), nil
+}
+
+// openDB opens a database with a database/sql driver, and checks that it can
+// be reached.
+func openDB(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %v", driver, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %v", driver, err)
+	}
+	return db, nil
+}
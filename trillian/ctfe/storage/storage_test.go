@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	_ "github.com/mattn/go-sqlite3" // For the SQL storage.
+)
+
+func newSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ctfe.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := db.Exec(Schema); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func newSQLiteStorage(t *testing.T) *SQLIssuanceChainStorage {
+	t.Helper()
+	return NewSQLIssuanceChainStorage(newSQLiteDB(t))
+}
+
+func TestIssuanceChainStorage(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func(t *testing.T) IssuanceChainStorage
+	}{
+		{name: "memory", new: func(t *testing.T) IssuanceChainStorage { return NewMemoryIssuanceChainStorage() }},
+		{name: "sql", new: func(t *testing.T) IssuanceChainStorage { return newSQLiteStorage(t) }},
+		{name: "lru", new: func(t *testing.T) IssuanceChainStorage {
+			return NewLRUIssuanceChainStorage(NewMemoryIssuanceChainStorage(), 1)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := tc.new(t)
+			key1, key2 := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+			if _, err := s.FindByKey(ctx, key1); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("FindByKey(missing)=_, %v, want ErrNotFound", err)
+			}
+			if err := s.Add(ctx, key1, []byte("chain1")); err != nil {
+				t.Fatalf("Add(key1): %v", err)
+			}
+			if err := s.Add(ctx, key2, []byte("chain2")); err != nil {
+				t.Fatalf("Add(key2): %v", err)
+			}
+			// Adding again is a no-op.
+			if err := s.Add(ctx, key1, []byte("other")); err != nil {
+				t.Fatalf("Add(key1) again: %v", err)
+			}
+			for key, want := range map[string]string{string(key1): "chain1", string(key2): "chain2"} {
+				got, err := s.FindByKey(ctx, []byte(key))
+				if err != nil {
+					t.Fatalf("FindByKey(%x): %v", key, err)
+				}
+				if string(got) != want {
+					t.Errorf("FindByKey(%x)=%q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+// countingStorage counts the calls to an IssuanceChainStorage.
+type countingStorage struct {
+	IssuanceChainStorage
+	finds, adds int
+}
+
+func (s *countingStorage) FindByKey(ctx context.Context, key []byte) ([]byte, error) {
+	s.finds++
+	return s.IssuanceChainStorage.FindByKey(ctx, key)
+}
+
+func (s *countingStorage) Add(ctx context.Context, key []byte, chain []byte) error {
+	s.adds++
+	return s.IssuanceChainStorage.Add(ctx, key, chain)
+}
+
+func TestLRUIssuanceChainStorage(t *testing.T) {
+	ctx := context.Background()
+	under := &countingStorage{IssuanceChainStorage: NewMemoryIssuanceChainStorage()}
+	c := NewLRUIssuanceChainStorage(under, 2)
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key%d", i)) }
+	for i := 0; i < 3; i++ {
+		if err := c.Add(ctx, key(i), []byte{byte(i)}); err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+	}
+	// Chains 1 and 2 are cached, and chain 0 was evicted.
+	for _, i := range []int{1, 2, 1} {
+		if _, err := c.FindByKey(ctx, key(i)); err != nil {
+			t.Fatalf("FindByKey(%d): %v", i, err)
+		}
+	}
+	// Adding a cached chain again is a no-op, and makes it the most recently
+	// used.
+	if err := c.Add(ctx, key(2), []byte{2}); err != nil {
+		t.Fatalf("Add(2) again: %v", err)
+	}
+	// Each Add looked for the chain before adding it.
+	if under.finds != 3 || under.adds != 3 {
+		t.Errorf("Underlying storage got %d finds and %d adds, want 3 and 3", under.finds, under.adds)
+	}
+	// Chain 0 is read from the underlying storage, and evicts chain 1.
+	if got, err := c.FindByKey(ctx, key(0)); err != nil || !bytes.Equal(got, []byte{0}) {
+		t.Fatalf("FindByKey(0)=%x, %v, want 00, nil", got, err)
+	}
+	if _, err := c.FindByKey(ctx, key(2)); err != nil {
+		t.Fatalf("FindByKey(2): %v", err)
+	}
+	if under.finds != 4 {
+		t.Errorf("Underlying storage got %d finds, want 4", under.finds)
+	}
+	if _, err := c.FindByKey(ctx, key(1)); err != nil {
+		t.Fatalf("FindByKey(1): %v", err)
+	}
+	if under.finds != 5 {
+		t.Errorf("Underlying storage got %d finds, want 5", under.finds)
+	}
+	if _, err := c.FindByKey(ctx, key(3)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindByKey(missing)=_, %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenIssuanceChainStorage(t *testing.T) {
+	ctx := context.Background()
+	if _, err := OpenIssuanceChainStorage(ctx, "memory://"); err != nil {
+		t.Errorf("OpenIssuanceChainStorage(memory://): %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "ctfe.db")
+	s, err := OpenIssuanceChainStorage(ctx, "sqlite3://"+path)
+	if err != nil {
+		t.Fatalf("OpenIssuanceChainStorage(sqlite3): %v", err)
+	}
+	if _, ok := s.(*SQLIssuanceChainStorage); !ok {
+		t.Errorf("OpenIssuanceChainStorage(sqlite3)=%T, want *SQLIssuanceChainStorage", s)
+	}
+	for _, conn := range []string{"", "mysql", "://dsn", "nosuchdriver://dsn"} {
+		if _, err := OpenIssuanceChainStorage(ctx, conn); err == nil {
+			t.Errorf("OpenIssuanceChainStorage(%q)=nil, want error", conn)
+		}
+	}
+}
+
+func TestRootStore(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func(t *testing.T) RootStore
+	}{
+		{name: "memory", new: func(t *testing.T) RootStore { return NewMemoryRootStore() }},
+		{name: "sql", new: func(t *testing.T) RootStore { return NewSQLRootStore(newSQLiteDB(t)) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := tc.new(t)
+			now := time.Unix(1700000000, 123)
+			fp := bytes.Repeat([]byte{1}, 32)
+			want := []*RootChange{
+				{LogID: 1, Time: now, User: "alice", Action: RootAdded, Fingerprint: fp, Certificate: []byte("root")},
+				{LogID: 1, Time: now.Add(time.Minute), User: "bob", Action: RootRemoved, Fingerprint: fp, RemoveAfter: now.Add(time.Hour)},
+				{LogID: 1, Time: now.Add(2 * time.Minute), User: "alice", Action: RootRemoved, Fingerprint: fp},
+			}
+			other := &RootChange{LogID: 2, Time: now, User: "carol", Action: RootAdded, Fingerprint: fp, Certificate: []byte("root")}
+			for _, c := range append(want[:1:1], other, want[1], want[2]) {
+				if err := s.Append(ctx, c); err != nil {
+					t.Fatalf("Append(%+v): %v", c, err)
+				}
+			}
+			got, err := s.Changes(ctx, 1)
+			if err != nil {
+				t.Fatalf("Changes(): %v", err)
+			}
+			if diff := cmp.Diff(want, got, cmpopts.EquateEmpty(), cmp.Comparer(time.Time.Equal)); diff != "" {
+				t.Errorf("Changes() diff (-want +got):\n%s", diff)
+			}
+			if got, err := s.Changes(ctx, 3); err != nil || len(got) != 0 {
+				t.Errorf("Changes(unknown log)=%v, %v, want none", got, err)
+			}
+		})
+	}
+}
+
+func TestOpenRootStore(t *testing.T) {
+	ctx := context.Background()
+	if _, err := OpenRootStore(ctx, "memory://"); err != nil {
+		t.Errorf("OpenRootStore(memory://): %v", err)
+	}
+	s, err := OpenRootStore(ctx, "sqlite3://"+filepath.Join(t.TempDir(), "ctfe.db"))
+	if err != nil {
+		t.Fatalf("OpenRootStore(sqlite3): %v", err)
+	}
+	if _, ok := s.(*SQLRootStore); !ok {
+		t.Errorf("OpenRootStore(sqlite3)=%T, want *SQLRootStore", s)
+	}
+	for _, conn := range []string{"", "mysql", "nosuchdriver://dsn"} {
+		if _, err := OpenRootStore(ctx, conn); err == nil {
+			t.Errorf("OpenRootStore(%q)=nil, want error", conn)
+		}
+	}
+}
@@ -29,15 +29,36 @@ func BuildLogLeaf(logPrefix string,
 	merkleLeaf ct.MerkleTreeLeaf, leafIndex int64,
 	cert ct.ASN1Cert, chain []ct.ASN1Cert, isPrecert bool,
 ) (trillian.LogLeaf, error) {
-	leafData, err := tls.Marshal(merkleLeaf)
+	extraData, err := ExtraDataForChain(cert, chain, isPrecert)
 	if err != nil {
-		klog.Warningf("%s: Failed to serialize Merkle leaf: %v", logPrefix, err)
+		klog.Warningf("%s: Failed to serialize chain for ExtraData: %v", logPrefix, err)
 		return trillian.LogLeaf{}, err
 	}
+	return buildLogLeaf(logPrefix, merkleLeaf, leafIndex, cert, extraData)
+}
 
-	extraData, err := ExtraDataForChain(cert, chain, isPrecert)
+// BuildLogLeafWithChainHash returns a Trillian LogLeaf structure for a
+// (pre-)cert whose chain of certificates leading it up to a known root is
+// stored separately, and identified by chainHash.
+func BuildLogLeafWithChainHash(logPrefix string,
+	merkleLeaf ct.MerkleTreeLeaf, leafIndex int64,
+	cert ct.ASN1Cert, chainHash []byte, isPrecert bool,
+) (trillian.LogLeaf, error) {
+	extraData, err := ExtraDataForChainHash(cert, chainHash, isPrecert)
 	if err != nil {
-		klog.Warningf("%s: Failed to serialize chain for ExtraData: %v", logPrefix, err)
+		klog.Warningf("%s: Failed to serialize chain hash for ExtraData: %v", logPrefix, err)
+		return trillian.LogLeaf{}, err
+	}
+	return buildLogLeaf(logPrefix, merkleLeaf, leafIndex, cert, extraData)
+}
+
+func buildLogLeaf(logPrefix string,
+	merkleLeaf ct.MerkleTreeLeaf, leafIndex int64,
+	cert ct.ASN1Cert, extraData []byte,
+) (trillian.LogLeaf, error) {
+	leafData, err := tls.Marshal(merkleLeaf)
+	if err != nil {
+		klog.Warningf("%s: Failed to serialize Merkle leaf: %v", logPrefix, err)
 		return trillian.LogLeaf{}, err
 	}
 
@@ -71,3 +92,20 @@ func ExtraDataForChain(cert ct.ASN1Cert, chain []ct.ASN1Cert, isPrecert bool) ([
 	}
 	return tls.Marshal(extra)
 }
+
+// ExtraDataForChainHash creates the extra data associated with a log entry
+// whose chain is stored separately, and identified by chainHash. It has the
+// same form as the extra data described in RFC6962 section 4.6, but with the
+// chain replaced by its hash.
+func ExtraDataForChainHash(cert ct.ASN1Cert, chainHash []byte, isPrecert bool) ([]byte, error) {
+	var extra interface{}
+	if isPrecert {
+		extra = ct.PrecertChainEntryHash{
+			PreCertificate:    cert,
+			IssuanceChainHash: chainHash,
+		}
+	} else {
+		extra = ct.CertificateChainHash{IssuanceChainHash: chainHash}
+	}
+	return tls.Marshal(extra)
+}
@@ -248,6 +248,23 @@ type CertificateChain struct {
 	Entries []ASN1Cert `tls:"minlen:0,maxlen:16777215"`
 }
 
+// PrecertChainEntryHash holds a precertificate together with the SHA-256 hash
+// of its TLS-encoded CertificateChain, which is stored separately. It is used
+// in place of a PrecertChainEntry by logs that deduplicate issuance chains;
+// it is not part of RFC 6962.
+type PrecertChainEntryHash struct {
+	PreCertificate    ASN1Cert `tls:"minlen:1,maxlen:16777215"`
+	IssuanceChainHash []byte   `tls:"minlen:32,maxlen:32"`
+}
+
+// CertificateChainHash holds the SHA-256 hash of a TLS-encoded
+// CertificateChain, which is stored separately. It is used in place of a
+// CertificateChain by logs that deduplicate issuance chains; it is not part
+// of RFC 6962.
+type CertificateChainHash struct {
+	IssuanceChainHash []byte `tls:"minlen:32,maxlen:32"`
+}
+
 // JSONDataEntry holds arbitrary data.
 type JSONDataEntry struct {
 	Data []byte `tls:"minlen:0,maxlen:1677215"`